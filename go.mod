@@ -3,5 +3,5 @@ module go-football-league
 go 1.24.5
 
 require github.com/mattn/go-sqlite3 v1.14.28
-        
-require github.com/gorilla/mux v1.8.1 // indirect
+
+require github.com/gorilla/mux v1.8.1
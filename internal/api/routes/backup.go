@@ -0,0 +1,55 @@
+package routes
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"go-football-league/internal/league"
+)
+
+// ExportData handles GET /api/export?season=
+// Returns the whole database, or one season, as a versioned JSON export file.
+func ExportData(w http.ResponseWriter, r *http.Request) {
+	seasonID := 0
+	if s := r.URL.Query().Get("season"); s != "" {
+		id, err := strconv.Atoi(s)
+		if err != nil {
+			http.Error(w, "Invalid season ID", http.StatusBadRequest)
+			return
+		}
+		seasonID = id
+	}
+
+	export, err := league.ExportContext(r.Context(), seasonID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	name := "league-export.json"
+	if seasonID != 0 {
+		name = fmt.Sprintf("league-season-%d.json", seasonID)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", name))
+	json.NewEncoder(w).Encode(export)
+}
+
+// RestoreData handles POST /api/restore
+// Recreates the data of an export file in an empty store.
+func RestoreData(w http.ResponseWriter, r *http.Request) {
+	export, err := league.ReadExport(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := league.RestoreContext(r.Context(), export); err != nil {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("Export restored successfully"))
+}
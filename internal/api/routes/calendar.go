@@ -0,0 +1,50 @@
+package routes
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"go-football-league/internal/league"
+)
+
+// GetTeamFixturesICS handles GET /api/teams/{id}/fixtures.ics
+// Returns the team's fixtures of the current season as an iCalendar feed.
+func GetTeamFixturesICS(w http.ResponseWriter, r *http.Request) {
+	teamID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid team ID", http.StatusBadRequest)
+		return
+	}
+
+	calendar, err := league.TeamFixturesICSContext(r.Context(), teamID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	writeICalendar(w, calendar, "team-"+strconv.Itoa(teamID)+".ics")
+}
+
+// GetSeasonFixturesICS handles GET /api/seasons/{id}/fixtures.ics
+// Returns all fixtures of a season as an iCalendar feed.
+func GetSeasonFixturesICS(w http.ResponseWriter, r *http.Request) {
+	seasonID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid season ID", http.StatusBadRequest)
+		return
+	}
+
+	calendar, err := league.SeasonFixturesICSContext(r.Context(), seasonID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	writeICalendar(w, calendar, "season-"+strconv.Itoa(seasonID)+".ics")
+}
+
+// writeICalendar sends an iCalendar document as the response.
+func writeICalendar(w http.ResponseWriter, calendar, filename string) {
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	w.Header().Set("Content-Disposition", `inline; filename="`+filename+`"`)
+	w.Write([]byte(calendar))
+}
@@ -0,0 +1,94 @@
+package routes
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	models "go-football-league/internal/domain"
+	"go-football-league/internal/league"
+)
+
+// GetFixtureConstraints handles GET /api/fixture-constraints
+// Returns the constraints the fixture scheduler tries to satisfy.
+func GetFixtureConstraints(w http.ResponseWriter, r *http.Request) {
+	constraints, err := league.GetFixtureConstraintsContext(r.Context())
+	if err != nil {
+		http.Error(w, "Failed to fetch fixture constraints", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(constraints)
+}
+
+// CreateFixtureConstraint handles POST /api/fixture-constraints
+// Adds a shared stadium, derby week or unavailable date for the next generated fixture.
+func CreateFixtureConstraint(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Kind        string `json:"kind"`
+		TeamID      int    `json:"team_id"`
+		OtherTeamID int    `json:"other_team_id"`
+		Week        int    `json:"week"`
+		Date        string `json:"date"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Failed to parse request body", http.StatusBadRequest)
+		return
+	}
+
+	c := models.FixtureConstraint{
+		Kind:        req.Kind,
+		TeamID:      req.TeamID,
+		OtherTeamID: req.OtherTeamID,
+		Week:        req.Week,
+		Date:        req.Date,
+	}
+	id, err := league.CreateFixtureConstraintContext(r.Context(), c)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	c.ID = id
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(c)
+}
+
+// DeleteFixtureConstraint handles DELETE /api/fixture-constraints/{id}
+func DeleteFixtureConstraint(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid constraint ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := league.DeleteFixtureConstraintContext(r.Context(), id); err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("Fixture constraint deleted successfully"))
+}
+
+// GetConstraintViolations handles GET /api/seasons/{id}/constraint-violations
+// Returns the fixture constraints the season's fixture does not satisfy.
+func GetConstraintViolations(w http.ResponseWriter, r *http.Request) {
+	seasonID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid season ID", http.StatusBadRequest)
+		return
+	}
+
+	violations, err := league.CheckFixtureConstraintsContext(r.Context(), seasonID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(violations)
+}
@@ -0,0 +1,83 @@
+package routes
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"go-football-league/internal/league"
+)
+
+// CreateCup handles POST /api/cups
+// Draws a new knockout cup and returns its bracket.
+func CreateCup(w http.ResponseWriter, r *http.Request) {
+	// Parse request body to extract the cup format
+	var req struct {
+		Name      string `json:"name"`
+		TeamIDs   []int  `json:"team_ids"`
+		Seeded    bool   `json:"seeded"`
+		TwoLegged bool   `json:"two_legged"`
+		AwayGoals bool   `json:"away_goals"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Failed to parse request body", http.StatusBadRequest)
+		return
+	}
+
+	cupID, err := league.CreateCupContext(r.Context(), league.CupOptions{
+		Name:      req.Name,
+		TeamIDs:   req.TeamIDs,
+		Seeded:    req.Seeded,
+		TwoLegged: req.TwoLegged,
+		AwayGoals: req.AwayGoals,
+	})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	writeCupBracket(w, r, cupID, http.StatusCreated)
+}
+
+// PlayCupRound handles POST /api/cups/{id}/play-round
+// Simulates the current round of a cup and returns the updated bracket.
+func PlayCupRound(w http.ResponseWriter, r *http.Request) {
+	cupID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid cup ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := league.PlayCupRoundContext(r.Context(), cupID); err != nil {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+
+	writeCupBracket(w, r, cupID, http.StatusOK)
+}
+
+// GetCupBracket handles GET /api/cups/{id}/bracket
+// Returns every round of the cup drawn so far with ties, legs and winners.
+func GetCupBracket(w http.ResponseWriter, r *http.Request) {
+	cupID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid cup ID", http.StatusBadRequest)
+		return
+	}
+
+	writeCupBracket(w, r, cupID, http.StatusOK)
+}
+
+// writeCupBracket loads a cup bracket and writes it as JSON with the given status code.
+func writeCupBracket(w http.ResponseWriter, r *http.Request, cupID, status int) {
+	bracket, err := league.GetCupBracketContext(r.Context(), cupID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(bracket)
+}
@@ -0,0 +1,100 @@
+package routes
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"go-football-league/internal/league"
+)
+
+// GetSeasonSuspensions handles GET /api/seasons/{id}/suspensions
+// Returns every suspension handed out during the season.
+func GetSeasonSuspensions(w http.ResponseWriter, r *http.Request) {
+	seasonID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid season ID", http.StatusBadRequest)
+		return
+	}
+
+	suspensions, err := league.GetSuspensionsContext(r.Context(), seasonID)
+	if err != nil {
+		http.Error(w, "Failed to fetch suspensions", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(suspensions)
+}
+
+// GetFairPlayTable handles GET /api/fair-play-table?week=
+// Returns the disciplinary standings for a given week.
+func GetFairPlayTable(w http.ResponseWriter, r *http.Request) {
+	week, err := strconv.Atoi(r.URL.Query().Get("week"))
+	if err != nil || !weekInSeason(r.Context(), week) {
+		http.Error(w, "Invalid or missing 'week' parameter", http.StatusBadRequest)
+		return
+	}
+
+	table, err := league.GenerateFairPlayTableContext(r.Context(), week)
+	if err != nil {
+		http.Error(w, "Failed to generate fair-play table", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(table)
+}
+
+// GetMatchCards handles GET /api/match/{id}/cards
+// Returns the cards shown in a match.
+func GetMatchCards(w http.ResponseWriter, r *http.Request) {
+	matchID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid match ID", http.StatusBadRequest)
+		return
+	}
+
+	cards, err := league.GetCardsByMatchContext(r.Context(), matchID)
+	if err != nil {
+		http.Error(w, "Failed to fetch cards", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(cards)
+}
+
+// AddMatchCard handles POST /api/match/{id}/cards
+// Records a card for a player and applies the suspension rules.
+func AddMatchCard(w http.ResponseWriter, r *http.Request) {
+	matchID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid match ID", http.StatusBadRequest)
+		return
+	}
+
+	// Parse request body to extract the card
+	var card struct {
+		PlayerID int    `json:"player_id"`
+		Card     string `json:"card"`
+		Minute   int    `json:"minute"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&card); err != nil {
+		http.Error(w, "Failed to parse request body", http.StatusBadRequest)
+		return
+	}
+	if card.Minute < 1 || card.Minute > 120 {
+		http.Error(w, "Minute must be between 1 and 120", http.StatusBadRequest)
+		return
+	}
+
+	if err := league.RecordCardContext(r.Context(), matchID, card.PlayerID, card.Card, card.Minute); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	w.Write([]byte("Card recorded successfully"))
+}
@@ -0,0 +1,179 @@
+package routes
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	models "go-football-league/internal/domain"
+	"go-football-league/internal/league"
+)
+
+// GetDivisions handles GET /api/divisions
+// Returns the league pyramid from the top tier down, with the teams of each division.
+func GetDivisions(w http.ResponseWriter, r *http.Request) {
+	divisions, err := league.GetDivisionsContext(r.Context())
+	if err != nil {
+		http.Error(w, "Failed to fetch divisions", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(divisions)
+}
+
+// CreateDivision handles POST /api/divisions
+// Adds a division to the pyramid.
+func CreateDivision(w http.ResponseWriter, r *http.Request) {
+	d, ok := decodeDivision(w, r)
+	if !ok {
+		return
+	}
+	id, err := league.CreateDivisionContext(r.Context(), d)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	d.ID = id
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(d)
+}
+
+// UpdateDivision handles PUT /api/divisions/{id}
+// Changes the tier and the promotion, relegation and playoff places of a division.
+func UpdateDivision(w http.ResponseWriter, r *http.Request) {
+	divisionID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid division ID", http.StatusBadRequest)
+		return
+	}
+	d, ok := decodeDivision(w, r)
+	if !ok {
+		return
+	}
+	d.ID = divisionID
+
+	if err := league.UpdateDivisionContext(r.Context(), d); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(d)
+}
+
+// decodeDivision parses the division settings in a request body.
+// It writes an error response and returns false if the body is invalid.
+func decodeDivision(w http.ResponseWriter, r *http.Request) (models.Division, bool) {
+	var req struct {
+		Name             string `json:"name"`
+		Tier             int    `json:"tier"`
+		PromotionPlaces  int    `json:"promotion_places"`
+		RelegationPlaces int    `json:"relegation_places"`
+		PlayoffPlaces    int    `json:"playoff_places"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Failed to parse request body", http.StatusBadRequest)
+		return models.Division{}, false
+	}
+	return models.Division{
+		Name:             req.Name,
+		Tier:             req.Tier,
+		PromotionPlaces:  req.PromotionPlaces,
+		RelegationPlaces: req.RelegationPlaces,
+		PlayoffPlaces:    req.PlayoffPlaces,
+		Teams:            []models.Team{},
+	}, true
+}
+
+// GetDivisionTable handles GET /api/divisions/{id}/table?week= or ?date=
+// Returns the standings of a division in the current season for a given week or as of a date.
+func GetDivisionTable(w http.ResponseWriter, r *http.Request) {
+	divisionID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid division ID", http.StatusBadRequest)
+		return
+	}
+	seasonID, err := league.CurrentSeasonIDContext(r.Context())
+	if err != nil {
+		http.Error(w, "Failed to fetch current season", http.StatusInternalServerError)
+		return
+	}
+
+	var table []models.LeagueTableRow
+	if dateStr := r.URL.Query().Get("date"); dateStr != "" {
+		asOf, err := league.ParseAsOfContext(r.Context(), dateStr)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		table, err = league.GenerateDivisionTableAsOfContext(r.Context(), seasonID, divisionID, asOf)
+	} else {
+		week, err := strconv.Atoi(r.URL.Query().Get("week"))
+		if err != nil || !weekInSeason(r.Context(), week) {
+			http.Error(w, "Invalid or missing 'week' or 'date' parameter", http.StatusBadRequest)
+			return
+		}
+		table, err = league.GenerateDivisionTableContext(r.Context(), seasonID, divisionID, week)
+	}
+	if err != nil {
+		http.Error(w, "Failed to generate division table", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(table)
+}
+
+// CreateTeam handles POST /api/teams
+// Adds a team to a division. It plays from the next season's fixture onwards.
+func CreateTeam(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Name       string `json:"name"`
+		Power      int    `json:"power"`
+		DivisionID int    `json:"division_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Failed to parse request body", http.StatusBadRequest)
+		return
+	}
+
+	id, err := league.CreateTeamContext(r.Context(), req.Name, req.Power, req.DivisionID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(models.Team{ID: id, Name: req.Name, Power: req.Power, DivisionID: req.DivisionID})
+}
+
+// AssignTeamDivision handles PUT /api/teams/{id}/division
+// Moves a team to another division.
+func AssignTeamDivision(w http.ResponseWriter, r *http.Request) {
+	teamID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid team ID", http.StatusBadRequest)
+		return
+	}
+
+	var req struct {
+		DivisionID int `json:"division_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Failed to parse request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := league.AssignTeamDivisionContext(r.Context(), teamID, req.DivisionID); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("Team division updated successfully"))
+}
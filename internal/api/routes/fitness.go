@@ -0,0 +1,38 @@
+package routes
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"go-football-league/internal/league"
+)
+
+// GetTeamInjuries handles GET /api/teams/{id}/injuries?week=
+// Returns the injuries of a team in the current season. With a week, only players out in that week are listed.
+func GetTeamInjuries(w http.ResponseWriter, r *http.Request) {
+	teamID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid team ID", http.StatusBadRequest)
+		return
+	}
+
+	week := 0
+	if weekStr := r.URL.Query().Get("week"); weekStr != "" {
+		week, err = strconv.Atoi(weekStr)
+		if err != nil || week < 1 {
+			http.Error(w, "Invalid 'week' parameter", http.StatusBadRequest)
+			return
+		}
+	}
+
+	injuries, err := league.GetTeamInjuriesContext(r.Context(), teamID, week)
+	if err != nil {
+		http.Error(w, "Failed to fetch injuries", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(injuries)
+}
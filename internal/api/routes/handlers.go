@@ -1,15 +1,17 @@
 package routes
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"math"
 	"net/http"
-	"sort"
 	"strconv"
 
 	"github.com/gorilla/mux"
+	models "go-football-league/internal/domain"
 	"go-football-league/internal/league"
+	"go-football-league/internal/metrics"
 )
 
 // SetupRouter initializes and returns the main API router with all endpoints registered.
@@ -24,6 +26,89 @@ func SetupRouter() *mux.Router {
 	r.HandleFunc("/api/week-summary", GetWeekSummary).Methods("GET")
 	r.HandleFunc("/api/championship-predictions/{week}", GetChampionshipPredictions).Methods("GET")
 
+	// Match status
+	r.HandleFunc("/api/match/{id}", GetMatch).Methods("GET")
+	r.HandleFunc("/api/match/{id}/changes", GetMatchChanges).Methods("GET")
+	r.HandleFunc("/api/match/{id}/postpone", PostponeMatch).Methods("POST")
+	r.HandleFunc("/api/match/{id}/abandon", AbandonMatch).Methods("POST")
+	r.HandleFunc("/api/match/{id}/reschedule", RescheduleMatch).Methods("POST")
+	r.HandleFunc("/api/match/{id}/award", AwardMatch).Methods("POST")
+
+	// Discipline
+	r.HandleFunc("/api/seasons/{id}/suspensions", GetSeasonSuspensions).Methods("GET")
+	r.HandleFunc("/api/fair-play-table", GetFairPlayTable).Methods("GET")
+	r.HandleFunc("/api/match/{id}/cards", GetMatchCards).Methods("GET")
+	r.HandleFunc("/api/match/{id}/cards", AddMatchCard).Methods("POST")
+
+	// Fitness
+	r.HandleFunc("/api/teams/{id}/injuries", GetTeamInjuries).Methods("GET")
+
+	// Cups
+	r.HandleFunc("/api/cups", CreateCup).Methods("POST")
+	r.HandleFunc("/api/cups/{id}/play-round", PlayCupRound).Methods("POST")
+	r.HandleFunc("/api/cups/{id}/bracket", GetCupBracket).Methods("GET")
+
+	// Tournaments
+	r.HandleFunc("/api/tournaments", CreateTournament).Methods("POST")
+	r.HandleFunc("/api/tournaments/{id}/play", PlayTournament).Methods("POST")
+	r.HandleFunc("/api/tournaments/{id}", GetTournament).Methods("GET")
+
+	// Divisions and seasons
+	r.HandleFunc("/api/divisions", GetDivisions).Methods("GET")
+	r.HandleFunc("/api/divisions", CreateDivision).Methods("POST")
+	r.HandleFunc("/api/divisions/{id}", UpdateDivision).Methods("PUT")
+	r.HandleFunc("/api/divisions/{id}/table", GetDivisionTable).Methods("GET")
+	r.HandleFunc("/api/teams", CreateTeam).Methods("POST")
+	r.HandleFunc("/api/teams/{id}/division", AssignTeamDivision).Methods("PUT")
+	r.HandleFunc("/api/seasons", GetSeasons).Methods("GET")
+	r.HandleFunc("/api/seasons/rollover", RolloverSeason).Methods("POST")
+	r.HandleFunc("/api/seasons/{id}/standings", GetSeasonStandings).Methods("GET")
+	r.HandleFunc("/api/seasons/{id}/calendar", SetSeasonCalendar).Methods("PUT")
+	r.HandleFunc("/api/seasons/{id}/position-history", GetPositionHistory).Methods("GET")
+	r.HandleFunc("/api/all-time-table", GetAllTimeTable).Methods("GET")
+	r.HandleFunc("/api/teams/{id}/history", GetTeamHistory).Methods("GET")
+	r.HandleFunc("/api/teams/{a}/head-to-head/{b}", GetHeadToHead).Methods("GET")
+	r.HandleFunc("/api/seasons/{id}/records", GetSeasonRecords).Methods("GET")
+	r.HandleFunc("/api/teams/{id}/records", GetTeamRecords).Methods("GET")
+
+	// Calendar feeds
+	r.HandleFunc("/api/teams/{id}/fixtures.ics", GetTeamFixturesICS).Methods("GET")
+	r.HandleFunc("/api/seasons/{id}/fixtures.ics", GetSeasonFixturesICS).Methods("GET")
+
+	// Venues
+	r.HandleFunc("/api/venues", GetVenues).Methods("GET")
+	r.HandleFunc("/api/venues", CreateVenue).Methods("POST")
+	r.HandleFunc("/api/venues/{id}", GetVenue).Methods("GET")
+	r.HandleFunc("/api/venues/{id}", UpdateVenue).Methods("PUT")
+	r.HandleFunc("/api/venues/{id}", DeleteVenue).Methods("DELETE")
+	r.HandleFunc("/api/teams/{id}/venue", SetTeamVenue).Methods("PUT")
+	r.HandleFunc("/api/match/{id}/venue", SetMatchVenue).Methods("PUT")
+
+	// Fixture constraints
+	r.HandleFunc("/api/fixture-constraints", GetFixtureConstraints).Methods("GET")
+	r.HandleFunc("/api/fixture-constraints", CreateFixtureConstraint).Methods("POST")
+	r.HandleFunc("/api/fixture-constraints/{id}", DeleteFixtureConstraint).Methods("DELETE")
+	r.HandleFunc("/api/seasons/{id}/constraint-violations", GetConstraintViolations).Methods("GET")
+
+	// Playoffs
+	r.HandleFunc("/api/divisions/{id}/playoffs", GetPlayoffs).Methods("GET")
+	r.HandleFunc("/api/divisions/{id}/playoffs/play-round", PlayPlayoffRound).Methods("POST")
+
+	// Import
+	r.HandleFunc("/api/import", ImportData).Methods("POST")
+
+	// Export and restore
+	r.HandleFunc("/api/export", ExportData).Methods("GET")
+	r.HandleFunc("/api/restore", RestoreData).Methods("POST")
+
+	// Health and metrics
+	r.HandleFunc("/healthz", Healthz).Methods("GET")
+	r.HandleFunc("/readyz", Readyz).Methods("GET")
+	r.Handle("/metrics", metrics.Handler()).Methods("GET")
+
+	// Every matched request gets an ID for the log, then is counted, timed and logged by route
+	r.Use(identifyRequests, instrumentRequests)
+
 	return r
 }
 
@@ -38,19 +123,19 @@ func GetWeekMatches(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Generate match fixtures for the specified week
-	if err := league.GenerateWeeklyMatches(week); err != nil {
+	if err := league.GenerateWeeklyMatchesContext(r.Context(), week); err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
 	// Simulate match scores
-	if err := league.SimulateScores(week); err != nil {
+	if err := league.SimulateScoresContext(r.Context(), week); err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
 	// Fetch all matches for the given week
-	matches, err := league.GetMatchesByWeek(week)
+	matches, err := league.GetMatchesByWeekContext(r.Context(), week)
 	if err != nil {
 		http.Error(w, "Failed to retrieve matches", http.StatusInternalServerError)
 		return
@@ -61,18 +146,42 @@ func GetWeekMatches(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(matches)
 }
 
-// GetLeagueTable handles GET /api/league-table?week=
-// Returns the league standings for a given week.
+// GetLeagueTable handles GET /api/league-table?week= or ?date=, with optional &view=home|away|form&last=5
+// Returns the league standings for a given week, or as of a date (YYYY-MM-DD in the league's time zone, or RFC 3339).
+// The view limits the table to home or away matches, or to each team's last matches.
 func GetLeagueTable(w http.ResponseWriter, r *http.Request) {
+	opts, err := league.ParseTableOptions(r.URL.Query().Get("view"), r.URL.Query().Get("last"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var table []models.LeagueTableRow
+	if dateStr := r.URL.Query().Get("date"); dateStr != "" {
+		asOf, err := league.ParseAsOfContext(r.Context(), dateStr)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		table, err = league.GenerateLeagueTableViewAsOfContext(r.Context(), asOf, opts)
+		if err != nil {
+			http.Error(w, "Failed to generate league table", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(table)
+		return
+	}
+
 	weekStr := r.URL.Query().Get("week")
 	week, err := strconv.Atoi(weekStr)
-	if err != nil || week < 1 || week > 6 {
-		http.Error(w, "Invalid or missing 'week' parameter", http.StatusBadRequest)
+	if err != nil || !weekInSeason(r.Context(), week) {
+		http.Error(w, "Invalid or missing 'week' or 'date' parameter", http.StatusBadRequest)
 		return
 	}
 
 	// Generate the league standings
-	table, err := league.GenerateLeagueTable(week)
+	table, err = league.GenerateLeagueTableViewContext(r.Context(), week, opts)
 	if err != nil {
 		http.Error(w, "Failed to generate league table", http.StatusInternalServerError)
 		return
@@ -104,8 +213,8 @@ func UpdateMatchScore(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Apply the score update
-	if err := league.UpdateMatchResult(matchID, update.HomeGoals, update.AwayGoals); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	if err := league.UpdateMatchResultContext(r.Context(), matchID, update.HomeGoals, update.AwayGoals); err != nil {
+		http.Error(w, err.Error(), matchErrorStatus(err))
 		return
 	}
 
@@ -114,19 +223,27 @@ func UpdateMatchScore(w http.ResponseWriter, r *http.Request) {
 }
 
 // PlayAllWeeks handles GET /api/play-all-weeks
-// Simulates all league weeks (1 to 6) and returns the results for each week.
+// Simulates all weeks of the current season and returns the results for each week.
+// A cancelled request stops before the next week; the weeks already simulated stay played.
 func PlayAllWeeks(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	weeks, err := league.SeasonWeeksContext(ctx)
+	if err != nil {
+		http.Error(w, "Failed to read season fixture", http.StatusInternalServerError)
+		return
+	}
+
 	results := make(map[int]interface{})
-	for week := 1; week <= 6; week++ {
-		if err := league.GenerateWeeklyMatches(week); err != nil {
+	for week := 1; week <= weeks; week++ {
+		if err := league.GenerateWeeklyMatchesContext(ctx, week); err != nil {
 			http.Error(w, fmt.Sprintf("Week %d fixture error: %v", week, err), http.StatusInternalServerError)
 			return
 		}
-		if err := league.SimulateScores(week); err != nil {
+		if err := league.SimulateScoresContext(ctx, week); err != nil {
 			http.Error(w, fmt.Sprintf("Week %d simulation error: %v", week, err), http.StatusInternalServerError)
 			return
 		}
-		matches, err := league.GetMatchesByWeek(week)
+		matches, err := league.GetMatchesByWeekContext(ctx, week)
 		if err != nil {
 			http.Error(w, fmt.Sprintf("Week %d matches fetch error: %v", week, err), http.StatusInternalServerError)
 			return
@@ -149,7 +266,7 @@ func GetChampionshipPredictions(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	predictions, err := generateChampionshipPredictions(week)
+	predictions, err := generateChampionshipPredictions(r.Context(), week)
 	if err != nil {
 		http.Error(w, "Failed to compute predictions", http.StatusInternalServerError)
 		return
@@ -161,54 +278,30 @@ func GetChampionshipPredictions(w http.ResponseWriter, r *http.Request) {
 }
 
 // generateChampionshipPredictions computes winning probability of each team
-// based on their points in the standings as of the given week.
-// Returns nil if the simulation is called before week 4.
-func generateChampionshipPredictions(week int) ([]map[string]interface{}, error) {
-	if week < 4 {
-		// Not enough data to predict before week 4
+// from the standings as of the given week.
+// Returns an empty list before the first prediction week.
+func generateChampionshipPredictions(ctx context.Context, week int) ([]map[string]interface{}, error) {
+	if week < league.PredictionFromWeek() {
+		// Not enough data to predict yet
 		return []map[string]interface{}{}, nil
 	}
 
 	// Fetch league table
-	table, err := league.GenerateLeagueTable(week)
+	table, err := league.GenerateLeagueTableContext(ctx, week)
 	if err != nil {
 		return nil, err
 	}
-
-	// Calculate total points to determine percentage weights
-	totalPoints := 0
-	for _, t := range table {
-		totalPoints += t.Points
-	}
-
-	// Store team and their winning chance
-	type Prediction struct {
-		Team string
-		Rate float64
-	}
-	var preds []Prediction
-	for _, t := range table {
-		var rate float64
-		if totalPoints > 0 {
-			rate = (float64(t.Points) / float64(totalPoints)) * 100
-		}
-		preds = append(preds, Prediction{
-			Team: t.TeamName,
-			Rate: math.Round(rate),
-		})
+	preds, err := league.ChampionshipPredictionsContext(ctx, week, table)
+	if err != nil {
+		return nil, err
 	}
 
-	// Sort by probability descending
-	sort.Slice(preds, func(i, j int) bool {
-		return preds[i].Rate > preds[j].Rate
-	})
-
 	// Format response
 	var response []map[string]interface{}
 	for _, p := range preds {
 		response = append(response, map[string]interface{}{
-			"team":   p.Team,
-			"chance": int(p.Rate),
+			"team":   p.TeamName,
+			"chance": int(math.Round(p.Chance)),
 		})
 	}
 	return response, nil
@@ -219,23 +312,23 @@ func generateChampionshipPredictions(week int) ([]map[string]interface{}, error)
 func GetWeekSummary(w http.ResponseWriter, r *http.Request) {
 	weekStr := r.URL.Query().Get("week")
 	week, err := strconv.Atoi(weekStr)
-	if err != nil || week < 1 || week > 6 {
+	if err != nil || !weekInSeason(r.Context(), week) {
 		http.Error(w, "Invalid week", http.StatusBadRequest)
 		return
 	}
 
 	// Fetch all required data
-	matches, err := league.GetMatchesByWeek(week)
+	matches, err := league.GetMatchesByWeekContext(r.Context(), week)
 	if err != nil {
 		http.Error(w, "Failed to fetch matches", http.StatusInternalServerError)
 		return
 	}
-	table, err := league.GenerateLeagueTable(week)
+	table, err := league.GenerateLeagueTableContext(r.Context(), week)
 	if err != nil {
 		http.Error(w, "Failed to fetch league table", http.StatusInternalServerError)
 		return
 	}
-	predictions, err := generateChampionshipPredictions(week)
+	predictions, err := generateChampionshipPredictions(r.Context(), week)
 	if err != nil {
 		http.Error(w, "Failed to fetch predictions", http.StatusInternalServerError)
 		return
@@ -251,3 +344,9 @@ func GetWeekSummary(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(response)
 }
+
+// weekInSeason reports whether week is a match week of the current season.
+func weekInSeason(ctx context.Context, week int) bool {
+	weeks, err := league.SeasonWeeksContext(ctx)
+	return err == nil && week >= 1 && week <= weeks
+}
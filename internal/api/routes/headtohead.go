@@ -0,0 +1,34 @@
+package routes
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"go-football-league/internal/league"
+)
+
+// GetHeadToHead handles GET /api/teams/{a}/head-to-head/{b}
+// Returns every meeting between two teams in all seasons and competitions, with team a's record against team b.
+func GetHeadToHead(w http.ResponseWriter, r *http.Request) {
+	teamID, err := strconv.Atoi(mux.Vars(r)["a"])
+	if err != nil {
+		http.Error(w, "Invalid team ID", http.StatusBadRequest)
+		return
+	}
+	opponentID, err := strconv.Atoi(mux.Vars(r)["b"])
+	if err != nil {
+		http.Error(w, "Invalid opponent team ID", http.StatusBadRequest)
+		return
+	}
+
+	h2h, err := league.GetHeadToHeadContext(r.Context(), teamID, opponentID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(h2h)
+}
@@ -0,0 +1,46 @@
+package routes
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"go-football-league/internal/league"
+)
+
+// maxImportSize limits the size of an uploaded import file.
+const maxImportSize = 10 << 20
+
+// ImportData handles POST /api/import?kind=&format=&dry_run=
+// The body is the CSV or JSON file. Without a format, a text/csv content type means CSV
+// and anything else JSON. Rows with errors reject the whole file with 422 and the report.
+func ImportData(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	format := query.Get("format")
+	if format == "" {
+		format = league.FormatJSON
+		if strings.HasPrefix(r.Header.Get("Content-Type"), "text/csv") {
+			format = league.FormatCSV
+		}
+	}
+	opts := league.ImportOptions{
+		Kind:   query.Get("kind"),
+		Format: format,
+		DryRun: query.Get("dry_run") == "true" || query.Get("dry_run") == "1",
+	}
+
+	report, err := league.ImportContext(r.Context(), http.MaxBytesReader(w, r.Body, maxImportSize), opts)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	switch {
+	case len(report.Errors) > 0:
+		w.WriteHeader(http.StatusUnprocessableEntity)
+	case !report.DryRun:
+		w.WriteHeader(http.StatusCreated)
+	}
+	json.NewEncoder(w).Encode(report)
+}
@@ -0,0 +1,156 @@
+package routes
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"go-football-league/internal/league"
+)
+
+// GetMatch handles GET /api/match/{id}
+// Returns a single match with its status and kickoff.
+func GetMatch(w http.ResponseWriter, r *http.Request) {
+	matchID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid match ID", http.StatusBadRequest)
+		return
+	}
+	writeMatch(w, r, matchID)
+}
+
+// GetMatchChanges handles GET /api/match/{id}/changes
+// Returns the audit log of the match's results, oldest first.
+func GetMatchChanges(w http.ResponseWriter, r *http.Request) {
+	matchID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid match ID", http.StatusBadRequest)
+		return
+	}
+
+	changes, err := league.GetResultChangesContext(r.Context(), matchID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(changes)
+}
+
+// PostponeMatch handles POST /api/match/{id}/postpone
+// Postpones a scheduled match until it is rescheduled.
+func PostponeMatch(w http.ResponseWriter, r *http.Request) {
+	matchID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid match ID", http.StatusBadRequest)
+		return
+	}
+	if err := league.PostponeMatchContext(r.Context(), matchID); err != nil {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	writeMatch(w, r, matchID)
+}
+
+// AbandonMatch handles POST /api/match/{id}/abandon
+// Abandons a match and clears its result so that it can be replayed.
+func AbandonMatch(w http.ResponseWriter, r *http.Request) {
+	matchID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid match ID", http.StatusBadRequest)
+		return
+	}
+	if err := league.AbandonMatchContext(r.Context(), matchID); err != nil {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	writeMatch(w, r, matchID)
+}
+
+// RescheduleMatch handles POST /api/match/{id}/reschedule
+// Moves a match that has not been played to a new week and/or kickoff (RFC 3339).
+func RescheduleMatch(w http.ResponseWriter, r *http.Request) {
+	matchID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid match ID", http.StatusBadRequest)
+		return
+	}
+
+	var req struct {
+		Week    int    `json:"week"`
+		Kickoff string `json:"kickoff"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Failed to parse request body", http.StatusBadRequest)
+		return
+	}
+	var kickoff time.Time
+	if req.Kickoff != "" {
+		if kickoff, err = time.Parse(time.RFC3339, req.Kickoff); err != nil {
+			http.Error(w, "Kickoff must be an RFC 3339 timestamp", http.StatusBadRequest)
+			return
+		}
+	}
+
+	if err := league.RescheduleMatchContext(r.Context(), matchID, req.Week, kickoff); err != nil {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	writeMatch(w, r, matchID)
+}
+
+// AwardMatch handles POST /api/match/{id}/award
+// Records an awarded result (3-0) in favour of the given team.
+func AwardMatch(w http.ResponseWriter, r *http.Request) {
+	matchID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid match ID", http.StatusBadRequest)
+		return
+	}
+
+	var req struct {
+		WinnerTeamID int `json:"winner_team_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Failed to parse request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := league.AwardMatchContext(r.Context(), matchID, req.WinnerTeamID); err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, league.ErrMatchNotFound) || errors.Is(err, league.ErrMatchStatus) {
+			status = matchErrorStatus(err)
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+	writeMatch(w, r, matchID)
+}
+
+// writeMatch encodes a match as the JSON response.
+func writeMatch(w http.ResponseWriter, r *http.Request, matchID int) {
+	match, err := league.GetMatchContext(r.Context(), matchID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(match)
+}
+
+// matchErrorStatus returns the HTTP status for an error changing a match.
+func matchErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, league.ErrMatchNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, league.ErrMatchStatus):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
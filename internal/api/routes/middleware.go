@@ -0,0 +1,100 @@
+package routes
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"go-football-league/internal/logging"
+	"go-football-league/internal/metrics"
+)
+
+// requestIDHeader carries the ID of a request from a client or proxy, and back in the response.
+const requestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength bounds the length of request IDs taken from clients.
+const maxRequestIDLength = 64
+
+var (
+	httpRequests = metrics.NewCounterVec("league_http_requests_total",
+		"HTTP requests served, by route, method and status code.", "route", "method", "code")
+	httpDuration = metrics.NewHistogramVec("league_http_request_duration_seconds",
+		"Time taken to serve HTTP requests, by route and method.", metrics.DefaultBuckets, "route", "method")
+)
+
+// identifyRequests gives every request an ID, kept in its context so that everything logged
+// while serving it carries the ID, and returned in the X-Request-ID response header. An ID
+// sent by the client or a proxy in that header is kept when it is short and printable.
+func identifyRequests(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(requestIDHeader)
+		if !validRequestID(id) {
+			id = newRequestID()
+		}
+		w.Header().Set(requestIDHeader, id)
+		next.ServeHTTP(w, r.WithContext(logging.WithRequestID(r.Context(), id)))
+	})
+}
+
+// validRequestID reports whether a request ID from a client is safe to log: letters, digits,
+// dots, dashes and underscores only.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, c := range id {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9', c == '.', c == '-', c == '_':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// newRequestID returns 16 random hexadecimal digits.
+func newRequestID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// instrumentRequests counts, times and logs every request by its route template, such as
+// /api/matches/{week}, so that the metrics do not grow with every week or ID requested.
+func instrumentRequests(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+
+		route := "unknown"
+		if current := mux.CurrentRoute(r); current != nil {
+			if template, err := current.GetPathTemplate(); err == nil {
+				route = template
+			}
+		}
+		elapsed := time.Since(start)
+		httpRequests.With(route, r.Method, strconv.Itoa(rec.status)).Inc()
+		httpDuration.With(route, r.Method).Observe(elapsed.Seconds())
+		slog.InfoContext(r.Context(), "Request served",
+			"method", r.Method, "path", r.URL.Path, "route", route, "status", rec.status, "duration", elapsed)
+	})
+}
+
+// statusRecorder remembers the status code a handler writes.
+type statusRecorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	if !r.wroteHeader {
+		r.status, r.wroteHeader = status, true
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
@@ -0,0 +1,51 @@
+package routes
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"go-football-league/internal/league"
+)
+
+// GetPlayoffs handles GET /api/divisions/{id}/playoffs
+// Returns the seeding, bracket and promoted team of a division's playoffs in the current season.
+func GetPlayoffs(w http.ResponseWriter, r *http.Request) {
+	divisionID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid division ID", http.StatusBadRequest)
+		return
+	}
+
+	writePlayoffs(w, r, divisionID)
+}
+
+// PlayPlayoffRound handles POST /api/divisions/{id}/playoffs/play-round
+// Draws the playoffs from the final table if needed, plays the next round and returns the updated playoffs.
+func PlayPlayoffRound(w http.ResponseWriter, r *http.Request) {
+	divisionID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid division ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := league.PlayPlayoffRoundContext(r.Context(), divisionID); err != nil {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+
+	writePlayoffs(w, r, divisionID)
+}
+
+// writePlayoffs encodes a division's playoffs as the JSON response.
+func writePlayoffs(w http.ResponseWriter, r *http.Request, divisionID int) {
+	playoffs, err := league.GetPlayoffsContext(r.Context(), divisionID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(playoffs)
+}
@@ -0,0 +1,161 @@
+package routes
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	models "go-football-league/internal/domain"
+	"go-football-league/internal/league"
+)
+
+// GetSeasons handles GET /api/seasons
+// Returns all seasons, the oldest first.
+func GetSeasons(w http.ResponseWriter, r *http.Request) {
+	seasons, err := league.GetSeasonsContext(r.Context())
+	if err != nil {
+		http.Error(w, "Failed to fetch seasons", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(seasons)
+}
+
+// RolloverSeason handles POST /api/seasons/rollover
+// Closes the finished current season, archives its standings, applies promotion and
+// relegation, regresses ratings and starts the next season.
+func RolloverSeason(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Name       string  `json:"name"`
+		StartDate  string  `json:"start_date"`
+		Regression float64 `json:"regression"`
+		NewTeams   []struct {
+			Name       string `json:"name"`
+			Power      int    `json:"power"`
+			DivisionID int    `json:"division_id"`
+		} `json:"new_teams"`
+		Withdrawn []int `json:"withdrawn_team_ids"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Failed to parse request body", http.StatusBadRequest)
+		return
+	}
+
+	opts := league.SeasonOptions{
+		Name:       req.Name,
+		StartDate:  req.StartDate,
+		Regression: req.Regression,
+		Withdrawn:  req.Withdrawn,
+	}
+	for _, t := range req.NewTeams {
+		opts.NewTeams = append(opts.NewTeams, models.Team{Name: t.Name, Power: t.Power, DivisionID: t.DivisionID})
+	}
+	result, err := league.RolloverSeasonContext(r.Context(), opts)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(result)
+}
+
+// SetSeasonCalendar handles PUT /api/seasons/{id}/calendar
+// Changes the start date and time zone of a season and reschedules its unplayed matches.
+func SetSeasonCalendar(w http.ResponseWriter, r *http.Request) {
+	seasonID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid season ID", http.StatusBadRequest)
+		return
+	}
+
+	var req struct {
+		StartDate string `json:"start_date"`
+		Timezone  string `json:"timezone"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Failed to parse request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := league.SetSeasonCalendarContext(r.Context(), seasonID, req.StartDate, req.Timezone); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("Season calendar updated successfully"))
+}
+
+// GetSeasonStandings handles GET /api/seasons/{id}/standings
+// Returns the archived final standings of a closed season.
+func GetSeasonStandings(w http.ResponseWriter, r *http.Request) {
+	seasonID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid season ID", http.StatusBadRequest)
+		return
+	}
+
+	standings, err := league.GetSeasonStandingsContext(r.Context(), seasonID)
+	if err != nil {
+		http.Error(w, "Failed to fetch season standings", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(standings)
+}
+
+// GetAllTimeTable handles GET /api/all-time-table
+// Returns the combined standings of all closed seasons.
+func GetAllTimeTable(w http.ResponseWriter, r *http.Request) {
+	table, err := league.GetAllTimeTableContext(r.Context())
+	if err != nil {
+		http.Error(w, "Failed to generate all-time table", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(table)
+}
+
+// GetTeamHistory handles GET /api/teams/{id}/history
+// Returns a team's final division, position and record in every closed season.
+func GetTeamHistory(w http.ResponseWriter, r *http.Request) {
+	teamID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid team ID", http.StatusBadRequest)
+		return
+	}
+
+	history, err := league.GetTeamHistoryContext(r.Context(), teamID)
+	if err != nil {
+		http.Error(w, "Failed to fetch team history", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(history)
+}
+
+// GetPositionHistory handles GET /api/seasons/{id}/position-history
+// Returns every team's position and points after each played week of a season, for charts.
+func GetPositionHistory(w http.ResponseWriter, r *http.Request) {
+	seasonID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid season ID", http.StatusBadRequest)
+		return
+	}
+
+	history, err := league.GetPositionHistoryContext(r.Context(), seasonID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(history)
+}
@@ -0,0 +1,53 @@
+package routes
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"go-football-league/internal/config"
+)
+
+// NewServer returns an HTTP server for the API router, listening on the configured address
+// with the configured timeouts.
+func NewServer(cfg config.ServerConfig) *http.Server {
+	return &http.Server{
+		Addr:         cfg.Addr,
+		Handler:      SetupRouter(),
+		ReadTimeout:  cfg.ReadTimeout,
+		WriteTimeout: cfg.WriteTimeout,
+		IdleTimeout:  cfg.IdleTimeout,
+	}
+}
+
+// ListenAndServe serves the API until ctx is cancelled, typically by SIGINT or SIGTERM.
+// It then stops accepting connections and waits for the requests in flight, such as running
+// simulations, to finish. Requests still running after the shutdown timeout have their
+// connections closed, which cancels their contexts.
+func ListenAndServe(ctx context.Context, cfg config.ServerConfig) error {
+	server := NewServer(cfg)
+	errs := make(chan error, 1)
+	go func() {
+		errs <- server.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errs:
+		return err
+	case <-ctx.Done():
+	}
+
+	slog.Info("Shutting down, waiting for requests in flight", "timeout", cfg.ShutdownTimeout)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		server.Close()
+		return fmt.Errorf("Failed to finish requests in flight: %v", err)
+	}
+	if err := <-errs; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package routes
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"go-football-league/internal/league"
+)
+
+// CreateTournament handles POST /api/tournaments
+// Draws the groups of a new tournament and returns its overview.
+func CreateTournament(w http.ResponseWriter, r *http.Request) {
+	// Parse request body to extract the tournament format
+	var req struct {
+		Name            string `json:"name"`
+		TeamIDs         []int  `json:"team_ids"`
+		Groups          int    `json:"groups"`
+		AdvancePerGroup int    `json:"advance_per_group"`
+		BestThirds      int    `json:"best_thirds"`
+		HomeAndAway     bool   `json:"home_and_away"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Failed to parse request body", http.StatusBadRequest)
+		return
+	}
+
+	tournamentID, err := league.CreateTournamentContext(r.Context(), league.TournamentOptions{
+		Name:            req.Name,
+		TeamIDs:         req.TeamIDs,
+		Groups:          req.Groups,
+		AdvancePerGroup: req.AdvancePerGroup,
+		BestThirds:      req.BestThirds,
+		HomeAndAway:     req.HomeAndAway,
+	})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	writeTournament(w, r, tournamentID, http.StatusCreated)
+}
+
+// PlayTournament handles POST /api/tournaments/{id}/play
+// Plays the next group matchday or knockout round and returns the updated overview.
+func PlayTournament(w http.ResponseWriter, r *http.Request) {
+	tournamentID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid tournament ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := league.PlayTournamentContext(r.Context(), tournamentID); err != nil {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+
+	writeTournament(w, r, tournamentID, http.StatusOK)
+}
+
+// GetTournament handles GET /api/tournaments/{id}
+// Returns the group tables, qualified teams and knockout bracket of a tournament.
+func GetTournament(w http.ResponseWriter, r *http.Request) {
+	tournamentID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid tournament ID", http.StatusBadRequest)
+		return
+	}
+
+	writeTournament(w, r, tournamentID, http.StatusOK)
+}
+
+// writeTournament loads a tournament overview and writes it as JSON with the given status code.
+func writeTournament(w http.ResponseWriter, r *http.Request, tournamentID, status int) {
+	overview, err := league.GetTournamentContext(r.Context(), tournamentID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(overview)
+}
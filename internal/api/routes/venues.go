@@ -0,0 +1,165 @@
+package routes
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	models "go-football-league/internal/domain"
+	"go-football-league/internal/league"
+)
+
+// GetVenues handles GET /api/venues
+func GetVenues(w http.ResponseWriter, r *http.Request) {
+	venues, err := league.GetVenuesContext(r.Context())
+	if err != nil {
+		http.Error(w, "Failed to fetch venues", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(venues)
+}
+
+// GetVenue handles GET /api/venues/{id}
+func GetVenue(w http.ResponseWriter, r *http.Request) {
+	venueID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid venue ID", http.StatusBadRequest)
+		return
+	}
+
+	venue, err := league.GetVenueContext(r.Context(), venueID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(venue)
+}
+
+// CreateVenue handles POST /api/venues
+func CreateVenue(w http.ResponseWriter, r *http.Request) {
+	v, ok := decodeVenue(w, r)
+	if !ok {
+		return
+	}
+	id, err := league.CreateVenueContext(r.Context(), v)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	v.ID = id
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(v)
+}
+
+// UpdateVenue handles PUT /api/venues/{id}
+func UpdateVenue(w http.ResponseWriter, r *http.Request) {
+	venueID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid venue ID", http.StatusBadRequest)
+		return
+	}
+	v, ok := decodeVenue(w, r)
+	if !ok {
+		return
+	}
+	v.ID = venueID
+
+	if err := league.UpdateVenueContext(r.Context(), v); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+// DeleteVenue handles DELETE /api/venues/{id}
+// Venues still used by a team or match cannot be deleted.
+func DeleteVenue(w http.ResponseWriter, r *http.Request) {
+	venueID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid venue ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := league.DeleteVenueContext(r.Context(), venueID); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("Venue deleted successfully"))
+}
+
+// decodeVenue parses the venue details in a request body.
+// It writes an error response and returns false if the body is invalid.
+func decodeVenue(w http.ResponseWriter, r *http.Request) (models.Venue, bool) {
+	var req struct {
+		Name     string `json:"name"`
+		City     string `json:"city"`
+		Capacity int    `json:"capacity"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Failed to parse request body", http.StatusBadRequest)
+		return models.Venue{}, false
+	}
+	return models.Venue{Name: req.Name, City: req.City, Capacity: req.Capacity}, true
+}
+
+// SetTeamVenue handles PUT /api/teams/{id}/venue
+// Sets a team's home ground; a venue_id of 0 clears it.
+func SetTeamVenue(w http.ResponseWriter, r *http.Request) {
+	teamID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid team ID", http.StatusBadRequest)
+		return
+	}
+
+	var req struct {
+		VenueID int `json:"venue_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Failed to parse request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := league.SetTeamVenueContext(r.Context(), teamID, req.VenueID); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("Team venue updated successfully"))
+}
+
+// SetMatchVenue handles PUT /api/match/{id}/venue
+// Moves a match to another venue (venue_id 0 for the home team's ground), optionally on neutral ground.
+func SetMatchVenue(w http.ResponseWriter, r *http.Request) {
+	matchID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid match ID", http.StatusBadRequest)
+		return
+	}
+
+	var req struct {
+		VenueID int  `json:"venue_id"`
+		Neutral bool `json:"neutral"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Failed to parse request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := league.SetMatchVenueContext(r.Context(), matchID, req.VenueID, req.Neutral); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	writeMatch(w, r, matchID)
+}
@@ -0,0 +1,329 @@
+// Package cli implements the go-football-league command line: one subcommand per task,
+// each with its own flags, so the league can be driven from scripts as well as by hand.
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"go-football-league/internal/config"
+	"go-football-league/internal/league"
+	storage "go-football-league/internal/repository"
+)
+
+const programName = "go-football-league"
+
+// Exit codes returned by Run.
+const (
+	ExitOK      = 0 // The command succeeded
+	ExitFailure = 1 // The command ran but failed, e.g. a database error or an unknown match
+	ExitUsage   = 2 // The command line was invalid: unknown command, bad flag or missing argument
+	ExitConfig  = 3 // The configuration file, environment or global flags were invalid
+)
+
+// globalFlagKeys maps the flags given before the command to the settings they override.
+var globalFlagKeys = map[string]string{
+	"dsn":        "storage.dsn",
+	"seed":       "simulation.seed",
+	"log-level":  "log.level",
+	"log-format": "log.format",
+	"addr":       "server.addr", // Only taken by the server binary, see RunServer
+}
+
+// settings is the configuration of the running command, loaded by Run before the command runs.
+var settings = config.Default()
+
+// command is a subcommand of the CLI.
+type command struct {
+	name    string
+	args    string // Arguments shown in the usage text
+	summary string
+	run     func(args []string) error
+}
+
+// commands lists the subcommands in the order they are shown in the usage text. It is
+// filled in by init because the help command refers back to it.
+var commands []command
+
+func init() {
+	commands = []command{
+		{"init", "[--fixtures]", "create the database and apply the schema", runInit},
+		{"fixtures", "generate", "create the fixture list of the current season", runFixtures},
+		{"simulate", "--week N | --all", "simulate one week or every remaining week", runSimulate},
+		{"table", "[--week N] [--view V] [--last N] [--format F]", "print the league table", runTable},
+		{"matches", "[--week N] [--format F]", "print the results or fixtures of a week", runMatches},
+		{"predict", "[--week N] [--format F]", "print the championship predictions after a week", runPredict},
+		{"result", "set <match> <home>-<away>", "record the result of a match", runResult},
+		{"import", "--kind K [--format F] [--dry-run] <file>", "import teams, fixtures or results from CSV or JSON", runImport},
+		{"export", "[--season ID] [--output FILE]", "write the database or one season to a versioned JSON file", runExport},
+		{"restore", "<file>", "recreate an exported database in an empty store", runRestore},
+		{"reset", "--yes", "delete the database and start again from the initial data", runReset},
+		{"serve", "[--addr ADDR]", "start the HTTP API", runServe},
+		{"play", "[--interactive]", "play the whole season week by week", runPlay},
+		{"dashboard", "", "open the full-screen match-day dashboard", runDashboard},
+		{"tournament", "--name NAME [--groups N] [--advance N] [--best-thirds N]", "play a group stage plus knockout tournament", runTournament},
+		{"rollover", "--name NAME [--regression R]", "close the finished season and start a new one", runRollover},
+		{"chart", "", "print the week-by-week positions as an ASCII chart", runChart},
+		{"head-to-head", "<team> <opponent>", "print the record of two teams against each other", runHeadToHead},
+		{"help", "[command]", "show this help or the flags of a command", runHelp},
+	}
+}
+
+// usageError reports an invalid command line. An empty message means the flag package
+// has already explained the problem.
+type usageError struct {
+	msg string
+}
+
+func (e usageError) Error() string {
+	return e.msg
+}
+
+// usagef returns a usageError with a formatted message.
+func usagef(format string, args ...interface{}) error {
+	return usageError{msg: fmt.Sprintf(format, args...)}
+}
+
+// configError reports settings that fail validation once a command's own flags have been
+// applied, such as a serve --addr that is not host:port.
+type configError struct {
+	err error
+}
+
+func (e configError) Error() string {
+	return e.err.Error()
+}
+
+// Run executes the subcommand named by the first argument and returns the exit code.
+// Global flags may come before the command.
+func Run(args []string) int {
+	global := newGlobalFlagSet()
+	global.SetOutput(os.Stderr)
+	if err := global.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			printUsage(os.Stdout)
+			return ExitOK
+		}
+		fmt.Fprintln(os.Stderr)
+		printUsage(os.Stderr)
+		return ExitUsage
+	}
+	args = global.Args()
+	if len(args) == 0 {
+		printUsage(os.Stderr)
+		return ExitUsage
+	}
+	name := args[0]
+	if name == "-h" || name == "-help" || name == "--help" {
+		printUsage(os.Stdout)
+		return ExitOK
+	}
+
+	cmd, ok := findCommand(name)
+	if !ok {
+		fmt.Fprintf(os.Stderr, "%s: unknown command %q\n\n", programName, name)
+		printUsage(os.Stderr)
+		return ExitUsage
+	}
+
+	// Help works even when the configuration is broken, so it can explain the flags
+	if cmd.name != "help" {
+		if err := loadSettings(global); err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", programName, err)
+			return ExitConfig
+		}
+	}
+
+	return exitCode(cmd, cmd.run(args[1:]))
+}
+
+// RunServer is the entry point of the server binary. It takes the global flags of Run
+// plus --addr, loads the configuration the same way, runs the serve command and returns
+// the exit code.
+func RunServer(args []string) int {
+	fs := newGlobalFlagSet()
+	fs.String("addr", "", "address to listen on, overriding server.addr")
+	fs.SetOutput(os.Stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: server [flags]\n\nStart the HTTP API.\n\nFlags:\n")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return ExitOK
+		}
+		return ExitUsage
+	}
+	if fs.NArg() > 0 {
+		fmt.Fprintf(os.Stderr, "server: unexpected argument %q\n", fs.Arg(0))
+		return ExitUsage
+	}
+	if err := loadSettings(fs); err != nil {
+		fmt.Fprintf(os.Stderr, "server: %v\n", err)
+		return ExitConfig
+	}
+	cmd, _ := findCommand("serve")
+	return exitCode(cmd, cmd.run(nil))
+}
+
+// exitCode closes the database once a command has run and returns the exit code of its
+// error, explaining the error on stderr.
+func exitCode(cmd command, err error) int {
+	if cerr := storage.Close(); err == nil && cerr != nil {
+		err = fmt.Errorf("Failed to close the database: %v", cerr)
+	}
+	var usage usageError
+	switch {
+	case err == nil, errors.Is(err, flag.ErrHelp):
+		return ExitOK
+	case errors.As(err, &usage):
+		if usage.msg != "" {
+			fmt.Fprintf(os.Stderr, "%s %s: %s\n", programName, cmd.name, usage.msg)
+			fmt.Fprintf(os.Stderr, "Usage: %s %s %s\n", programName, cmd.name, cmd.args)
+		}
+		return ExitUsage
+	case errors.As(err, new(configError)):
+		fmt.Fprintf(os.Stderr, "%s %s: %v\n", programName, cmd.name, err)
+		return ExitConfig
+	default:
+		fmt.Fprintf(os.Stderr, "%s %s: %v\n", programName, cmd.name, err)
+		return ExitFailure
+	}
+}
+
+// newGlobalFlagSet returns the flags accepted before the command.
+func newGlobalFlagSet() *flag.FlagSet {
+	fs := flag.NewFlagSet(programName, flag.ContinueOnError)
+	fs.Usage = func() {}
+	fs.String("config", "", "configuration file (default: $"+config.FileEnv+", or "+config.DefaultFile+" if it exists)")
+	fs.String("dsn", "", "SQLite database file or file: URI, overriding storage.dsn")
+	fs.Int64("seed", 0, "random seed, overriding simulation.seed; 0 picks a new one every run")
+	fs.String("log-level", "", "least severe messages logged to stderr: debug, info, warn or error, overriding log.level")
+	fs.String("log-format", "", "format of the log on stderr: text or json, overriding log.format")
+	return fs
+}
+
+// loadSettings reads the configuration file and environment, applies the global flags
+// that were given, validates the result and puts it into effect.
+func loadSettings(global *flag.FlagSet) error {
+	c, err := config.Load(global.Lookup("config").Value.String())
+	if err != nil {
+		return err
+	}
+	global.Visit(func(f *flag.Flag) {
+		if key, ok := globalFlagKeys[f.Name]; ok && err == nil {
+			if err = c.Set(key, f.Value.String()); err != nil {
+				err = fmt.Errorf("Invalid flag --%s: %v", f.Name, err)
+			}
+		}
+	})
+	if err != nil {
+		return err
+	}
+	if err := c.Validate(); err != nil {
+		return err
+	}
+	c.Apply()
+	settings = c
+	return nil
+}
+
+// findCommand looks up a subcommand by name.
+func findCommand(name string) (command, bool) {
+	for _, c := range commands {
+		if c.name == name {
+			return c, true
+		}
+	}
+	return command{}, false
+}
+
+// printUsage writes the list of subcommands and the exit codes.
+func printUsage(w io.Writer) {
+	fmt.Fprintf(w, "Usage: %s [global flags] <command> [flags] [arguments]\n\nCommands:\n", programName)
+	for _, c := range commands {
+		fmt.Fprintf(w, "  %-13s %s\n", c.name, c.summary)
+	}
+	fmt.Fprintln(w, "\nGlobal flags:")
+	global := newGlobalFlagSet()
+	global.SetOutput(w)
+	global.PrintDefaults()
+	fmt.Fprintf(w, "\nRun '%s help <command>' for the flags of a command.\n", programName)
+	fmt.Fprintf(w, "Exit codes: %d success, %d failure, %d invalid command line, %d invalid configuration.\n",
+		ExitOK, ExitFailure, ExitUsage, ExitConfig)
+}
+
+// newFlagSet returns the flag set of a subcommand. Parse errors are returned rather than
+// ending the program, and -h prints the subcommand's usage.
+func newFlagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	fs.Usage = func() {
+		cmd, _ := findCommand(name)
+		fmt.Fprintf(fs.Output(), "Usage: %s %s %s\n\n%s.\n", programName, name, cmd.args, upperFirst(cmd.summary))
+		hasFlags := false
+		fs.VisitAll(func(*flag.Flag) { hasFlags = true })
+		if hasFlags {
+			fmt.Fprintln(fs.Output(), "\nFlags:")
+			fs.PrintDefaults()
+		}
+	}
+	return fs
+}
+
+// formatFlag adds the --format flag of a command that prints a report.
+func formatFlag(fs *flag.FlagSet) *string {
+	return fs.String("format", league.FormatTable, "output format: "+strings.Join(league.Formats, ", "))
+}
+
+// newRenderer returns the renderer of a --format value; an unknown format is a usage error.
+func newRenderer(format string) (league.Renderer, error) {
+	renderer, err := league.NewRenderer(format)
+	if err != nil {
+		return nil, usageError{msg: err.Error()}
+	}
+	return renderer, nil
+}
+
+// parseFlags parses the arguments of a subcommand and checks that it was given exactly
+// the expected number of positional arguments.
+func parseFlags(fs *flag.FlagSet, args []string, positional int) error {
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return err
+		}
+		return usageError{}
+	}
+	if fs.NArg() != positional {
+		if fs.NArg() > positional {
+			return usagef("unexpected argument %q", fs.Arg(positional))
+		}
+		return usagef("expected %d argument(s), got %d", positional, fs.NArg())
+	}
+	return nil
+}
+
+// runHelp prints the general usage, or the usage of one subcommand.
+func runHelp(args []string) error {
+	if len(args) == 0 {
+		printUsage(os.Stdout)
+		return nil
+	}
+	cmd, ok := findCommand(args[0])
+	if !ok || cmd.name == "help" {
+		return usagef("unknown command %q", args[0])
+	}
+	return cmd.run([]string{"-h"})
+}
+
+// upperFirst capitalizes the first letter of a summary for use as a sentence.
+func upperFirst(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
@@ -0,0 +1,640 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"go-football-league/internal/api/routes"
+	"go-football-league/internal/league"
+	storage "go-football-league/internal/repository"
+	"go-football-league/internal/tui"
+)
+
+// runInit creates the database and applies the schema, optionally creating the fixture.
+func runInit(args []string) error {
+	fs := newFlagSet("init")
+	fixtures := fs.Bool("fixtures", false, "also create the fixture list of the current season")
+	if err := parseFlags(fs, args, 0); err != nil {
+		return err
+	}
+
+	storage.Connect()
+	if *fixtures {
+		return createFixture()
+	}
+	return nil
+}
+
+// runFixtures handles "fixtures generate".
+func runFixtures(args []string) error {
+	fs := newFlagSet("fixtures")
+	if err := parseFlags(fs, args, 1); err != nil {
+		return err
+	}
+	if fs.Arg(0) != "generate" {
+		return usagef("unknown action %q", fs.Arg(0))
+	}
+
+	storage.Connect()
+	return createFixture()
+}
+
+// runSimulate simulates a single week, or every week not played yet. The playoffs are
+// played once the last week has been simulated.
+func runSimulate(args []string) error {
+	fs := newFlagSet("simulate")
+	week := fs.Int("week", 0, "week to simulate")
+	all := fs.Bool("all", false, "simulate every remaining week of the season")
+	if err := parseFlags(fs, args, 0); err != nil {
+		return err
+	}
+	if (*week != 0) == *all {
+		return usagef("exactly one of --week or --all is required")
+	}
+
+	storage.Connect()
+	weeks, err := fixtureWeeks()
+	if err != nil {
+		return err
+	}
+
+	first, last := 1, weeks
+	if !*all {
+		if *week < 1 || *week > weeks {
+			return usagef("week must be between 1 and %d", weeks)
+		}
+		first, last = *week, *week
+	}
+	for w := first; w <= last; w++ {
+		if err := league.PlayWeek(w); err != nil {
+			return err
+		}
+		fmt.Println()
+		if err := league.PrintMatchesOfWeek(os.Stdout, w); err != nil {
+			return err
+		}
+	}
+
+	if last == weeks {
+		return playPlayoffs()
+	}
+	return nil
+}
+
+// runTable prints the top division table after a week, by default the last week played.
+func runTable(args []string) error {
+	fs := newFlagSet("table")
+	week := fs.Int("week", 0, "week to show the table after (default: the last week played)")
+	view := fs.String("view", "", "table view: overall, home, away or form")
+	last := fs.String("last", "", "number of matches counted by the form view")
+	format := formatFlag(fs)
+	if err := parseFlags(fs, args, 0); err != nil {
+		return err
+	}
+	opts, err := league.ParseTableOptions(*view, *last)
+	if err != nil {
+		return usageError{msg: err.Error()}
+	}
+	renderer, err := newRenderer(*format)
+	if err != nil {
+		return err
+	}
+
+	storage.Connect()
+	w, err := weekOrLastPlayed(*week)
+	if err != nil {
+		return err
+	}
+	table, err := league.GenerateLeagueTableView(w, opts)
+	if err != nil {
+		return fmt.Errorf("Failed to generate league table: %v", err)
+	}
+	return renderer.Render(os.Stdout, league.LeagueTableReport(fmt.Sprintf("League Standings (After Week %d)", w), table))
+}
+
+// runPredict prints the championship predictions after a week, by default the last week played.
+func runPredict(args []string) error {
+	fs := newFlagSet("predict")
+	week := fs.Int("week", 0, "week to predict from, at least the first prediction week (default: the last week played)")
+	format := formatFlag(fs)
+	if err := parseFlags(fs, args, 0); err != nil {
+		return err
+	}
+	renderer, err := newRenderer(*format)
+	if err != nil {
+		return err
+	}
+
+	storage.Connect()
+	w, err := weekOrLastPlayed(*week)
+	if err != nil {
+		return err
+	}
+	if from := league.PredictionFromWeek(); w < from {
+		return usagef("predictions need at least %d weeks, got week %d", from, w)
+	}
+	table, err := league.GenerateLeagueTable(w)
+	if err != nil {
+		return fmt.Errorf("Failed to generate league table: %v", err)
+	}
+	report, err := league.PredictionsReport(w, table)
+	if err != nil {
+		return fmt.Errorf("Failed to predict the championship: %v", err)
+	}
+	return renderer.Render(os.Stdout, report)
+}
+
+// runMatches prints the results or fixtures of a week, by default the last week played.
+func runMatches(args []string) error {
+	fs := newFlagSet("matches")
+	week := fs.Int("week", 0, "week to show (default: the last week played)")
+	format := formatFlag(fs)
+	if err := parseFlags(fs, args, 0); err != nil {
+		return err
+	}
+	renderer, err := newRenderer(*format)
+	if err != nil {
+		return err
+	}
+
+	storage.Connect()
+	w, err := weekOrLastPlayed(*week)
+	if err != nil {
+		return err
+	}
+	matches, err := league.GetMatchesByWeek(w)
+	if err != nil {
+		return fmt.Errorf("Failed to load matches: %v", err)
+	}
+	return renderer.Render(os.Stdout, league.MatchesReport(fmt.Sprintf("Match results for week %d", w), matches))
+}
+
+// runResult handles "result set <match> <home>-<away>".
+func runResult(args []string) error {
+	fs := newFlagSet("result")
+	if err := parseFlags(fs, args, 3); err != nil {
+		return err
+	}
+	if fs.Arg(0) != "set" {
+		return usagef("unknown action %q", fs.Arg(0))
+	}
+	matchID, err := strconv.Atoi(fs.Arg(1))
+	if err != nil || matchID < 1 {
+		return usagef("invalid match ID %q", fs.Arg(1))
+	}
+	homeGoals, awayGoals, err := league.ParseScore(fs.Arg(2))
+	if err != nil {
+		return usageError{msg: err.Error()}
+	}
+
+	storage.Connect()
+	match, err := league.GetMatch(matchID)
+	if err != nil {
+		return err
+	}
+	if err := league.UpdateMatchResult(matchID, homeGoals, awayGoals); err != nil {
+		return err
+	}
+	fmt.Printf("Match %d: %s %d-%d %s\n", matchID, match.HomeTeamName, homeGoals, awayGoals, match.AwayTeamName)
+	return nil
+}
+
+// runImport loads teams, fixtures or results from a file, or standard input for "-".
+// Row errors are listed on standard error and nothing is stored.
+func runImport(args []string) error {
+	fs := newFlagSet("import")
+	kind := fs.String("kind", "", "what the file holds: teams, fixtures or results")
+	format := fs.String("format", "", "file format: csv or json (default: from the file extension)")
+	dryRun := fs.Bool("dry-run", false, "validate the file without storing anything")
+	if err := parseFlags(fs, args, 1); err != nil {
+		return err
+	}
+	path := fs.Arg(0)
+	if *format == "" {
+		*format = strings.TrimPrefix(strings.ToLower(filepath.Ext(path)), ".")
+	}
+	if *kind == "" {
+		return usagef("--kind is required")
+	}
+	if *format != league.FormatCSV && *format != league.FormatJSON {
+		return usagef("unknown file format %q, use --format csv or json", *format)
+	}
+
+	in := os.Stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		in = f
+	}
+
+	storage.Connect()
+	report, err := league.Import(in, league.ImportOptions{Kind: *kind, Format: *format, DryRun: *dryRun})
+	if err != nil {
+		return err
+	}
+	for _, e := range report.Errors {
+		if e.Field != "" {
+			fmt.Fprintf(os.Stderr, "row %d, %s: %s\n", e.Row, e.Field, e.Message)
+		} else {
+			fmt.Fprintf(os.Stderr, "row %d: %s\n", e.Row, e.Message)
+		}
+	}
+	if len(report.Errors) > 0 {
+		return fmt.Errorf("%d error(s) in %d row(s), nothing was imported", len(report.Errors), report.Rows)
+	}
+	verb := "Imported"
+	if report.DryRun {
+		verb = "Dry run: would import"
+	}
+	fmt.Printf("%s %d row(s) of %s: %d created, %d updated\n", verb, report.Rows, report.Kind, report.Created, report.Updated)
+	return nil
+}
+
+// runExport writes the whole database, or one season, as versioned JSON to standard
+// output or a file.
+func runExport(args []string) error {
+	fs := newFlagSet("export")
+	seasonID := fs.Int("season", 0, "export only this season (default: the whole database)")
+	output := fs.String("output", "", "file to write (default: standard output)")
+	if err := parseFlags(fs, args, 0); err != nil {
+		return err
+	}
+
+	storage.Connect()
+	export, err := league.Export(*seasonID)
+	if err != nil {
+		return err
+	}
+
+	out := os.Stdout
+	if *output != "" {
+		f, err := os.Create(*output)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		out = f
+	}
+	enc := json.NewEncoder(out)
+	enc.SetIndent("", "  ")
+	return enc.Encode(export)
+}
+
+// runRestore loads an export file, or standard input for "-", into an empty store.
+func runRestore(args []string) error {
+	fs := newFlagSet("restore")
+	if err := parseFlags(fs, args, 1); err != nil {
+		return err
+	}
+
+	in := os.Stdin
+	if path := fs.Arg(0); path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		in = f
+	}
+	export, err := league.ReadExport(in)
+	if err != nil {
+		return err
+	}
+
+	storage.Connect()
+	if err := league.Restore(export); err != nil {
+		return err
+	}
+	fmt.Printf("Restored export version %d from %s\n", export.Version, export.ExportedAt)
+	return nil
+}
+
+// runReset deletes the database and recreates it from the schema and its initial data.
+func runReset(args []string) error {
+	fs := newFlagSet("reset")
+	yes := fs.Bool("yes", false, "confirm that all seasons, results and settings are deleted")
+	if err := parseFlags(fs, args, 0); err != nil {
+		return err
+	}
+	if !*yes {
+		return usagef("reset deletes %s, pass --yes to confirm", storage.File())
+	}
+
+	if err := storage.Reset(); err != nil {
+		return err
+	}
+	storage.Connect()
+	return nil
+}
+
+// runServe starts the HTTP API and serves until it fails or receives SIGINT or SIGTERM,
+// then finishes the requests in flight. Run closes the database afterwards.
+func runServe(args []string) error {
+	fs := newFlagSet("serve")
+	addr := fs.String("addr", settings.Server.Addr, "address to listen on")
+	if err := parseFlags(fs, args, 0); err != nil {
+		return err
+	}
+	settings.Server.Addr = *addr
+	if err := settings.Validate(); err != nil {
+		return configError{err}
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	storage.Connect()
+	slog.Info("Server is running", "addr", *addr)
+	return routes.ListenAndServe(ctx, settings.Server)
+}
+
+// runPlay creates the fixture and plays the season week by week, printing the results,
+// the standings of every division and the predictions, then the playoffs. With
+// --interactive it waits for Enter between weeks.
+func runPlay(args []string) error {
+	fs := newFlagSet("play")
+	interactive := fs.Bool("interactive", false, "pause for Enter after every week")
+	if err := parseFlags(fs, args, 0); err != nil {
+		return err
+	}
+
+	storage.Connect()
+	if err := createFixture(); err != nil {
+		return err
+	}
+	weeks, err := fixtureWeeks()
+	if err != nil {
+		return err
+	}
+
+	stdin := bufio.NewReader(os.Stdin)
+	for week := 1; week <= weeks; week++ {
+		fmt.Printf("===== WEEK %d =====\n\n", week)
+
+		if err := league.GenerateWeeklyMatches(week); err != nil {
+			return fmt.Errorf("Failed to generate matches for week %d: %v", week, err)
+		}
+		if err := league.SimulateScores(week); err != nil {
+			return fmt.Errorf("Failed to simulate scores for week %d: %v", week, err)
+		}
+
+		if err := league.PrintMatchesOfWeek(os.Stdout, week); err != nil {
+			return err
+		}
+
+		fmt.Printf("\nLeague Standings (After Week %d):\n", week)
+		table, err := league.GenerateLeagueTable(week)
+		if err != nil {
+			return fmt.Errorf("Failed to generate league table: %v", err)
+		}
+		if err := league.PrintLeagueTableRows(os.Stdout, table); err != nil {
+			return err
+		}
+		if err := printLowerDivisions(week); err != nil {
+			return err
+		}
+
+		// Predictions only make sense once the league has reached the first prediction week
+		if week >= league.PredictionFromWeek() {
+			fmt.Println()
+			if err := league.PrintChampionshipPredictions(os.Stdout, week, table); err != nil {
+				return err
+			}
+		}
+
+		if week == weeks {
+			if err := playPlayoffs(); err != nil {
+				return err
+			}
+		}
+
+		if *interactive && week < weeks {
+			fmt.Print("\nPress Enter to continue to the next week...")
+			stdin.ReadBytes('\n')
+			fmt.Println()
+		}
+	}
+	return nil
+}
+
+// runDashboard opens the match-day dashboard, creating the fixture first if needed.
+func runDashboard(args []string) error {
+	fs := newFlagSet("dashboard")
+	if err := parseFlags(fs, args, 0); err != nil {
+		return err
+	}
+
+	storage.Connect()
+	if err := createFixture(); err != nil {
+		return err
+	}
+	return tui.Run()
+}
+
+// runTournament draws a tournament with all teams and plays it to the end,
+// printing the groups and the knockout bracket after every matchday or round.
+func runTournament(args []string) error {
+	fs := newFlagSet("tournament")
+	name := fs.String("name", "", "name of the tournament")
+	groups := fs.Int("groups", 2, "number of tournament groups")
+	advance := fs.Int("advance", 1, "teams per group that reach the knockout stage")
+	bestThirds := fs.Int("best-thirds", 0, "best teams placed just below the qualifying places that also advance")
+	if err := parseFlags(fs, args, 0); err != nil {
+		return err
+	}
+	if *name == "" {
+		return usagef("--name is required")
+	}
+
+	storage.Connect()
+	tournamentID, err := league.CreateTournament(league.TournamentOptions{
+		Name:            *name,
+		Groups:          *groups,
+		AdvancePerGroup: *advance,
+		BestThirds:      *bestThirds,
+	})
+	if err != nil {
+		return fmt.Errorf("Failed to create tournament: %v", err)
+	}
+
+	for {
+		if err := league.PlayTournament(tournamentID); err != nil {
+			return fmt.Errorf("Failed to play tournament: %v", err)
+		}
+		overview, err := league.GetTournament(tournamentID)
+		if err != nil {
+			return fmt.Errorf("Failed to load tournament: %v", err)
+		}
+		league.PrintTournament(os.Stdout, overview)
+		if overview.Stage == league.StageFinished {
+			return nil
+		}
+	}
+}
+
+// runRollover closes the current season, prints the new ratings and creates the
+// fixture of the new season.
+func runRollover(args []string) error {
+	fs := newFlagSet("rollover")
+	name := fs.String("name", "", "name of the new season")
+	regression := fs.Float64("regression", 0, "share (0 to 1) of each team's gap to the average rating lost at the rollover")
+	if err := parseFlags(fs, args, 0); err != nil {
+		return err
+	}
+	if *name == "" {
+		return usagef("--name is required")
+	}
+
+	storage.Connect()
+	result, err := league.RolloverSeason(league.SeasonOptions{Name: *name, Regression: *regression})
+	if err != nil {
+		return fmt.Errorf("Failed to start new season: %v", err)
+	}
+	fmt.Printf("Season %s started with %d team movement(s).\n", result.Season.Name, len(result.Movements))
+	for _, m := range result.Movements {
+		fmt.Printf("  %s %s\n", m.TeamName, m.Reason)
+	}
+	for _, t := range result.Ratings {
+		fmt.Printf("  %-15s %3d\n", t.Name, t.Power)
+	}
+	return nil
+}
+
+// runChart prints the position chart of every division in the current season.
+func runChart(args []string) error {
+	fs := newFlagSet("chart")
+	if err := parseFlags(fs, args, 0); err != nil {
+		return err
+	}
+
+	storage.Connect()
+	seasonID, err := league.CurrentSeasonID()
+	if err != nil {
+		return fmt.Errorf("Failed to load season: %v", err)
+	}
+	history, err := league.GetPositionHistory(seasonID)
+	if err != nil {
+		return fmt.Errorf("Failed to load position history: %v", err)
+	}
+	league.PrintPositionChart(os.Stdout, history)
+	return nil
+}
+
+// runHeadToHead prints the head-to-head record of two teams given by ID.
+func runHeadToHead(args []string) error {
+	fs := newFlagSet("head-to-head")
+	if err := parseFlags(fs, args, 2); err != nil {
+		return err
+	}
+	teamID, err1 := strconv.Atoi(fs.Arg(0))
+	opponentID, err2 := strconv.Atoi(fs.Arg(1))
+	if err1 != nil || err2 != nil {
+		return usagef("teams must be given as two team IDs, e.g. 2 1")
+	}
+
+	storage.Connect()
+	h2h, err := league.GetHeadToHead(teamID, opponentID)
+	if err != nil {
+		return fmt.Errorf("Failed to load head-to-head record: %v", err)
+	}
+	league.PrintHeadToHead(os.Stdout, h2h)
+	return nil
+}
+
+// createFixture creates the fixture of the current season unless it already exists.
+func createFixture() error {
+	if err := league.CreateFixture(); err != nil {
+		return fmt.Errorf("Failed to create fixture: %v", err)
+	}
+	return nil
+}
+
+// fixtureWeeks returns the number of weeks in the current season's fixture, failing
+// when the fixture has not been created yet.
+func fixtureWeeks() (int, error) {
+	weeks, err := league.SeasonWeeks()
+	if err != nil {
+		return 0, fmt.Errorf("Failed to read season fixture: %v", err)
+	}
+	if weeks == 0 {
+		return 0, errors.New("No fixture yet, run 'fixtures generate' first")
+	}
+	return weeks, nil
+}
+
+// weekOrLastPlayed checks a week given on the command line, defaulting to the last
+// week with a result when it is zero.
+func weekOrLastPlayed(week int) (int, error) {
+	weeks, err := fixtureWeeks()
+	if err != nil {
+		return 0, err
+	}
+	if week == 0 {
+		if week, err = league.LastPlayedWeek(); err != nil {
+			return 0, fmt.Errorf("Failed to find the last week played: %v", err)
+		}
+		return week, nil
+	}
+	if week < 1 || week > weeks {
+		return 0, usagef("week must be between 1 and %d", weeks)
+	}
+	return week, nil
+}
+
+// printLowerDivisions prints the standings of every division below the top tier.
+func printLowerDivisions(week int) error {
+	divisions, err := league.GetDivisions()
+	if err != nil {
+		return fmt.Errorf("Failed to load divisions: %v", err)
+	}
+	seasonID, err := league.CurrentSeasonID()
+	if err != nil {
+		return fmt.Errorf("Failed to load season: %v", err)
+	}
+	for _, d := range divisions[min(1, len(divisions)):] {
+		table, err := league.GenerateDivisionTable(seasonID, d.ID, week)
+		if err != nil {
+			return fmt.Errorf("Failed to generate %s table: %v", d.Name, err)
+		}
+		fmt.Printf("\n%s Standings (After Week %d):\n", d.Name, week)
+		if err := league.PrintLeagueTableRows(os.Stdout, table); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// playPlayoffs plays and prints the playoffs of every division with playoff places.
+func playPlayoffs() error {
+	divisions, err := league.GetDivisions()
+	if err != nil {
+		return fmt.Errorf("Failed to load divisions: %v", err)
+	}
+	for _, d := range divisions {
+		if d.PlayoffPlaces == 0 {
+			continue
+		}
+		if err := league.PlayPlayoffs(d.ID); err != nil {
+			return fmt.Errorf("Failed to play %s playoffs: %v", d.Name, err)
+		}
+		playoffs, err := league.GetPlayoffs(d.ID)
+		if err != nil {
+			return fmt.Errorf("Failed to load %s playoffs: %v", d.Name, err)
+		}
+		league.PrintPlayoffs(os.Stdout, playoffs)
+	}
+	return nil
+}
@@ -0,0 +1,319 @@
+// Package config holds the settings of the league: where the database lives, how the HTTP
+// server listens, how it logs, and the rules of the simulation. Settings start from defaults, are
+// overridden by a TOML file, then by LEAGUE_* environment variables, and finally by
+// command line flags, and are validated before anything runs.
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"go-football-league/internal/league"
+	"go-football-league/internal/logging"
+	storage "go-football-league/internal/repository"
+)
+
+// DefaultFile is the configuration file read when none is named and it exists.
+const DefaultFile = "league.toml"
+
+// FileEnv names the environment variable holding the path of the configuration file.
+const FileEnv = "LEAGUE_CONFIG"
+
+// envPrefix starts the environment variable of every setting, e.g. LEAGUE_SERVER_ADDR.
+const envPrefix = "LEAGUE_"
+
+// maxIterations bounds the simulated seasons per prediction so a request stays fast enough.
+const maxIterations = 1000000
+
+// Config is the complete configuration of the league.
+type Config struct {
+	Storage     StorageConfig
+	Server      ServerConfig
+	Simulation  SimulationConfig
+	Points      PointsConfig
+	Predictions PredictionsConfig
+	Log         LogConfig
+}
+
+// StorageConfig locates the database and its schema.
+type StorageConfig struct {
+	DSN    string // SQLite file path or file: URI
+	Schema string // Schema applied on every start
+}
+
+// ServerConfig is how the HTTP API listens.
+type ServerConfig struct {
+	Addr            string // host:port, the host may be empty
+	ReadTimeout     time.Duration
+	WriteTimeout    time.Duration
+	IdleTimeout     time.Duration
+	ShutdownTimeout time.Duration // How long in-flight requests may run on after a shutdown signal
+}
+
+// SimulationConfig selects how scores are simulated.
+type SimulationConfig struct {
+	Model string // One of league.Models
+	Seed  int64  // Zero picks a new seed every run
+}
+
+// PointsConfig is the points a team gets for each result.
+type PointsConfig struct {
+	Win  int
+	Draw int
+	Loss int
+}
+
+// PredictionsConfig controls the championship predictions.
+type PredictionsConfig struct {
+	FromWeek   int // First week predictions are made for
+	Iterations int // Seasons simulated per prediction; zero shares the title by points
+}
+
+// LogConfig selects what is logged and how.
+type LogConfig struct {
+	Level  string // One of logging.Levels
+	Format string // One of logging.Formats
+}
+
+// Default returns the configuration used when nothing is overridden.
+func Default() Config {
+	rules := league.DefaultSettings()
+	return Config{
+		Storage: StorageConfig{DSN: storage.DSN, Schema: storage.SchemaPath},
+		Server: ServerConfig{
+			Addr:            ":8080",
+			ReadTimeout:     10 * time.Second,
+			WriteTimeout:    60 * time.Second,
+			IdleTimeout:     120 * time.Second,
+			ShutdownTimeout: 30 * time.Second,
+		},
+		Simulation:  SimulationConfig{Model: rules.Model, Seed: rules.Seed},
+		Points:      PointsConfig{Win: rules.WinPoints, Draw: rules.DrawPoints, Loss: rules.LossPoints},
+		Predictions: PredictionsConfig{FromWeek: rules.PredictionFromWeek, Iterations: rules.PredictionIterations},
+		Log:         LogConfig{Level: "info", Format: logging.FormatText},
+	}
+}
+
+// Load returns the defaults overridden by the configuration file and then by the
+// environment. An empty path reads the file named by LEAGUE_CONFIG or, when that is not
+// set either, league.toml if it exists. The result is not validated.
+func Load(path string) (Config, error) {
+	c := Default()
+	if path == "" {
+		path = os.Getenv(FileEnv)
+	}
+	if path == "" {
+		if _, err := os.Stat(DefaultFile); err == nil {
+			path = DefaultFile
+		}
+	}
+	if path != "" {
+		if err := c.readFile(path); err != nil {
+			return c, err
+		}
+	}
+	if err := c.readEnv(); err != nil {
+		return c, err
+	}
+	return c, nil
+}
+
+// field is one setting, known by its key in files such as "server.addr".
+type field struct {
+	key string
+	set func(value string) error
+}
+
+// fields lists the settings of c in the order they are documented.
+func (c *Config) fields() []field {
+	return []field{
+		{"storage.dsn", stringValue(&c.Storage.DSN)},
+		{"storage.schema", stringValue(&c.Storage.Schema)},
+		{"server.addr", stringValue(&c.Server.Addr)},
+		{"server.read_timeout", durationValue(&c.Server.ReadTimeout)},
+		{"server.write_timeout", durationValue(&c.Server.WriteTimeout)},
+		{"server.idle_timeout", durationValue(&c.Server.IdleTimeout)},
+		{"server.shutdown_timeout", durationValue(&c.Server.ShutdownTimeout)},
+		{"simulation.model", stringValue(&c.Simulation.Model)},
+		{"simulation.seed", int64Value(&c.Simulation.Seed)},
+		{"points.win", intValue(&c.Points.Win)},
+		{"points.draw", intValue(&c.Points.Draw)},
+		{"points.loss", intValue(&c.Points.Loss)},
+		{"predictions.from_week", intValue(&c.Predictions.FromWeek)},
+		{"predictions.iterations", intValue(&c.Predictions.Iterations)},
+		{"log.level", stringValue(&c.Log.Level)},
+		{"log.format", stringValue(&c.Log.Format)},
+	}
+}
+
+// Set changes the setting with the given key, e.g. "server.addr".
+func (c *Config) Set(key, value string) error {
+	for _, f := range c.fields() {
+		if f.key == key {
+			if err := f.set(value); err != nil {
+				return fmt.Errorf("%s: %v", key, err)
+			}
+			return nil
+		}
+	}
+	return fmt.Errorf("Unknown setting %q", key)
+}
+
+// EnvName returns the environment variable overriding a setting, e.g. LEAGUE_SERVER_ADDR.
+func EnvName(key string) string {
+	return envPrefix + strings.ToUpper(strings.ReplaceAll(key, ".", "_"))
+}
+
+// readEnv applies the LEAGUE_* environment variables that are set.
+func (c *Config) readEnv() error {
+	for _, f := range c.fields() {
+		name := EnvName(f.key)
+		if value, ok := os.LookupEnv(name); ok {
+			if err := f.set(value); err != nil {
+				return fmt.Errorf("Invalid environment variable %s: %v", name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// Validate checks every setting and reports all the invalid ones at once.
+func (c Config) Validate() error {
+	var problems []string
+	problem := func(key, format string, args ...interface{}) {
+		problems = append(problems, key+": "+fmt.Sprintf(format, args...))
+	}
+
+	if c.Storage.DSN == "" {
+		problem("storage.dsn", "must not be empty")
+	}
+	if c.Storage.Schema == "" {
+		problem("storage.schema", "must not be empty")
+	} else if _, err := os.Stat(c.Storage.Schema); err != nil {
+		problem("storage.schema", "cannot read %s: %v", c.Storage.Schema, errors.Unwrap(err))
+	}
+
+	if _, port, err := net.SplitHostPort(c.Server.Addr); err != nil {
+		problem("server.addr", "%q is not host:port", c.Server.Addr)
+	} else if n, err := strconv.Atoi(port); err != nil || n < 0 || n > 65535 {
+		problem("server.addr", "port %q must be a number from 0 to 65535", port)
+	}
+	for _, t := range []struct {
+		key   string
+		value time.Duration
+	}{
+		{"server.read_timeout", c.Server.ReadTimeout},
+		{"server.write_timeout", c.Server.WriteTimeout},
+		{"server.idle_timeout", c.Server.IdleTimeout},
+		{"server.shutdown_timeout", c.Server.ShutdownTimeout},
+	} {
+		if t.value <= 0 {
+			problem(t.key, "must be a positive duration such as 30s, got %v", t.value)
+		}
+	}
+
+	known := false
+	for _, m := range league.Models {
+		known = known || c.Simulation.Model == m
+	}
+	if !known {
+		problem("simulation.model", "%q is not one of %s", c.Simulation.Model, strings.Join(league.Models, ", "))
+	}
+
+	switch {
+	case c.Points.Loss < 0:
+		problem("points.loss", "must not be negative, got %d", c.Points.Loss)
+	case c.Points.Draw < c.Points.Loss:
+		problem("points.draw", "must be at least the points for a loss (%d), got %d", c.Points.Loss, c.Points.Draw)
+	case c.Points.Win <= c.Points.Draw:
+		problem("points.win", "must be more than the points for a draw (%d), got %d", c.Points.Draw, c.Points.Win)
+	}
+
+	if c.Predictions.FromWeek < 1 {
+		problem("predictions.from_week", "must be 1 or more, got %d", c.Predictions.FromWeek)
+	}
+	if c.Predictions.Iterations < 0 || c.Predictions.Iterations > maxIterations {
+		problem("predictions.iterations", "must be from 0 to %d, got %d", maxIterations, c.Predictions.Iterations)
+	}
+
+	if _, err := logging.ParseLevel(c.Log.Level); err != nil {
+		problem("log.level", "%v", err)
+	}
+	if _, err := logging.New(io.Discard, c.Log.Format, slog.LevelInfo); err != nil {
+		problem("log.format", "%v", err)
+	}
+
+	if len(problems) > 0 {
+		return errors.New("Invalid configuration:\n  " + strings.Join(problems, "\n  "))
+	}
+	return nil
+}
+
+// Apply puts a validated configuration into effect: the logger, the storage location and
+// the rules of the simulation. It must be called before the database is opened.
+func (c Config) Apply() {
+	level, _ := logging.ParseLevel(c.Log.Level)
+	logging.Setup(c.Log.Format, level)
+	storage.DSN = c.Storage.DSN
+	storage.SchemaPath = c.Storage.Schema
+	league.Configure(league.Settings{
+		Model:                c.Simulation.Model,
+		Seed:                 c.Simulation.Seed,
+		WinPoints:            c.Points.Win,
+		DrawPoints:           c.Points.Draw,
+		LossPoints:           c.Points.Loss,
+		PredictionFromWeek:   c.Predictions.FromWeek,
+		PredictionIterations: c.Predictions.Iterations,
+	})
+}
+
+// stringValue sets a string setting.
+func stringValue(p *string) func(string) error {
+	return func(value string) error {
+		*p = value
+		return nil
+	}
+}
+
+// intValue sets a whole-number setting.
+func intValue(p *int) func(string) error {
+	return func(value string) error {
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("%q is not a whole number", value)
+		}
+		*p = n
+		return nil
+	}
+}
+
+// int64Value sets a 64-bit whole-number setting.
+func int64Value(p *int64) func(string) error {
+	return func(value string) error {
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return fmt.Errorf("%q is not a whole number", value)
+		}
+		*p = n
+		return nil
+	}
+}
+
+// durationValue sets a duration setting written like 30s or 2m.
+func durationValue(p *time.Duration) func(string) error {
+	return func(value string) error {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("%q is not a duration such as 30s or 2m", value)
+		}
+		*p = d
+		return nil
+	}
+}
@@ -0,0 +1,169 @@
+package config
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// readFile applies a configuration file. It is TOML, restricted to what the settings need:
+// [section] headers, each given once, followed by key = value lines, with # comments and
+// blank lines between. Values are basic "strings", literal 'strings' or integers:
+//
+//	[server]
+//	addr = ":9090"
+//	read_timeout = "5s"   # durations are strings
+//
+//	[points]
+//	win = 3
+//
+// Anything else TOML allows, such as arrays, inline tables, multi-line strings, dotted or
+// quoted keys, floats and booleans, is rejected with the line it is on rather than guessed at.
+// Files that do not end in .toml, such as YAML files, are not read.
+func (c *Config) readFile(path string) error {
+	if ext := filepath.Ext(path); !strings.EqualFold(ext, ".toml") {
+		return fmt.Errorf("Configuration file %s must be TOML and end in .toml", path)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("Failed to read configuration: %v", err)
+	}
+	defer f.Close()
+
+	section := ""
+	seen := map[string]bool{} // Sections and settings already given
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		if err := c.readLine(scanner.Text(), &section, seen); err != nil {
+			return fmt.Errorf("%s:%d: %v", path, line, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("Failed to read configuration: %v", err)
+	}
+	return nil
+}
+
+// bareKey matches the section and key names of the configuration file.
+var bareKey = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// integer matches a TOML decimal integer: an optional sign, no leading zeros, and
+// underscores only between digits.
+var integer = regexp.MustCompile(`^[+-]?(0|[1-9](_?[0-9])*)$`)
+
+// readLine applies one line of a configuration file: a [section] header, a key = value
+// setting, or a blank or comment line. section is the current section, and seen records
+// the sections and settings given so far, so neither can be repeated.
+func (c *Config) readLine(text string, section *string, seen map[string]bool) error {
+	text = strings.TrimSpace(text)
+	switch {
+	case text == "" || strings.HasPrefix(text, "#"):
+		return nil
+	case strings.HasPrefix(text, "[["):
+		return fmt.Errorf("Arrays of tables are not supported: %q", text)
+	case strings.HasPrefix(text, "["):
+		end := strings.IndexByte(text, ']')
+		if end < 0 || strings.TrimSpace(stripComment(text[end+1:])) != "" {
+			return fmt.Errorf("Malformed section header %q", text)
+		}
+		name := strings.TrimSpace(text[1:end])
+		if !bareKey.MatchString(name) {
+			return fmt.Errorf("Section name %q must be a bare name such as [server]", name)
+		}
+		if seen[name] {
+			return fmt.Errorf("Section [%s] is given twice", name)
+		}
+		seen[name] = true
+		*section = name
+		return nil
+	}
+
+	key, raw, found := strings.Cut(text, "=")
+	if !found {
+		return fmt.Errorf("Expected key = value, got %q", text)
+	}
+	key = strings.TrimSpace(key)
+	if !bareKey.MatchString(key) {
+		return fmt.Errorf("Key %q must be a bare name such as addr", key)
+	}
+	if *section == "" {
+		return fmt.Errorf("Setting %q is outside of a [section]", key)
+	}
+	name := *section + "." + key
+	if seen[name] {
+		return fmt.Errorf("Setting %s is given twice", name)
+	}
+	seen[name] = true
+
+	value, err := scalar(raw)
+	if err != nil {
+		return fmt.Errorf("%s: %v", name, err)
+	}
+	return c.Set(name, value)
+}
+
+// scalar returns the value of a setting: the contents of a basic or literal string, or an
+// integer without its underscores, ignoring a trailing comment.
+func scalar(raw string) (string, error) {
+	raw = strings.TrimSpace(raw)
+	switch {
+	case strings.HasPrefix(raw, `"""`) || strings.HasPrefix(raw, "'''"):
+		return "", fmt.Errorf("Multi-line strings are not supported")
+	case strings.HasPrefix(raw, `"`) || strings.HasPrefix(raw, "'"):
+		return quoted(raw)
+	case strings.HasPrefix(raw, "[") || strings.HasPrefix(raw, "{"):
+		return "", fmt.Errorf("Arrays and inline tables are not supported")
+	}
+	value := strings.TrimSpace(stripComment(raw))
+	if value == "" {
+		return "", fmt.Errorf("Missing value")
+	}
+	if !integer.MatchString(value) {
+		return "", fmt.Errorf("Value %s must be a quoted string or an integer", value)
+	}
+	return strings.ReplaceAll(value, "_", ""), nil
+}
+
+// quoted returns the contents of a string that starts raw, which may only be followed by
+// a comment. Basic strings take the TOML escapes; literal strings are taken as they are.
+func quoted(raw string) (string, error) {
+	quote, end := raw[0], -1
+	for i := 1; i < len(raw); i++ {
+		if quote == '"' && raw[i] == '\\' {
+			if i+1 == len(raw) || !strings.ContainsRune(`btnfr"\uU`, rune(raw[i+1])) {
+				return "", fmt.Errorf("Invalid escape sequence in %s", raw)
+			}
+			i++
+		} else if raw[i] == quote {
+			end = i
+			break
+		}
+	}
+	if end < 0 {
+		return "", fmt.Errorf("Unterminated string %s", raw)
+	}
+	if rest := raw[end+1:]; strings.TrimSpace(stripComment(rest)) != "" {
+		return "", fmt.Errorf("Unexpected text after string: %q", strings.TrimSpace(rest))
+	}
+	if quote == '\'' {
+		return raw[1:end], nil
+	}
+	value, err := strconv.Unquote(raw[:end+1])
+	if err != nil {
+		return "", fmt.Errorf("Invalid string %s", raw[:end+1])
+	}
+	return value, nil
+}
+
+// stripComment removes a # comment from the end of unquoted text.
+func stripComment(text string) string {
+	if i := strings.IndexByte(text, '#'); i >= 0 {
+		return text[:i]
+	}
+	return text
+}
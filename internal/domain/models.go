@@ -3,11 +3,23 @@ package models
 // Package models defines the data structures used across the football league simulation.
 // It includes core types such as teams, matches, league standings, and prediction models.
 
+import "time"
+
 // Team represents a football team with a unique ID, name, and power rating used for match simulations.
 type Team struct {
-	ID    int
-	Name  string
-	Power int
+	ID         int
+	Name       string
+	Power      int
+	DivisionID int
+	VenueID    int // Home ground; zero if not set
+}
+
+// Venue is a ground matches are played at.
+type Venue struct {
+	ID       int
+	Name     string
+	City     string
+	Capacity int
 }
 
 // Match represents a football match between two teams during a specific week.
@@ -21,6 +33,26 @@ type Match struct {
 	AwayGoals    int
 	HomeTeamName string
 	AwayTeamName string
+	Kickoff      time.Time // Scheduled kickoff in the league's time zone
+	Status       string    // scheduled, postponed, abandoned, played or awarded
+	OriginalWeek int       // Week the match was first scheduled in; zero unless it has been moved
+	Venue        *Venue    // The match's own venue, otherwise the home team's ground; nil if neither is set
+	Neutral      bool      // Played on neutral ground, without home advantage
+}
+
+// ResultChange is an entry of the audit log of match results: a change of a match's score
+// or status. Goals are nil while a match has no result.
+type ResultChange struct {
+	ID           int
+	MatchID      int
+	Revision     int // Revision of the match after the change
+	OldStatus    string
+	OldHomeGoals *int
+	OldAwayGoals *int
+	NewStatus    string
+	NewHomeGoals *int
+	NewAwayGoals *int
+	ChangedAt    string // UTC, e.g. 2025-08-16T14:05:00Z
 }
 
 // LeagueTableRow represents the position and performance statistics of a team in the league standings.
@@ -35,6 +67,7 @@ type LeagueTableRow struct {
 	GoalsFor     int 
 	GoalsAgainst int 
 	GoalDiff     int 
+	Form         string // Results of the last matches counted, oldest first, e.g. "WWDLW"
 }
 
 // Prediction represents a team's probability of winning the league championship based on current standings.
@@ -43,3 +76,382 @@ type Prediction struct {
 	TeamName string  
 	Chance   float64 
 }
+
+
+// Player represents a squad member of a team who can be selected for matches.
+type Player struct {
+	ID          int
+	TeamID      int
+	Name        string
+	SquadNumber int
+	Position    string
+}
+
+// Card represents a yellow ("Y") or red ("R") card shown to a player during a match.
+type Card struct {
+	ID         int
+	MatchID    int
+	PlayerID   int
+	PlayerName string
+	TeamID     int
+	Card       string
+	Minute     int
+}
+
+// Suspension represents a ban that keeps a player out of the given number of league matches
+// their team plays after the triggering match, the first of them from StartWeek on at the
+// earliest. Served counts the matches sat out so far.
+type Suspension struct {
+	ID         int
+	SeasonID   int
+	PlayerID   int
+	PlayerName string
+	TeamID     int
+	TeamName   string
+	MatchID    int
+	Reason     string
+	StartWeek  int
+	Matches    int
+	Served     int
+}
+
+// FairPlayRow represents a team's disciplinary record. Fewer points means a cleaner record.
+type FairPlayRow struct {
+	TeamID      int
+	TeamName    string
+	YellowCards int
+	RedCards    int
+	Points      int
+}
+
+// Injury represents a player being unavailable for WeeksOut weeks starting from StartWeek.
+type Injury struct {
+	ID          int
+	SeasonID    int
+	PlayerID    int
+	PlayerName  string
+	TeamID      int
+	MatchID     int
+	Description string
+	StartWeek   int
+	WeeksOut    int
+}
+
+// Cup represents a knockout cup competition and its format.
+type Cup struct {
+	ID           int
+	SeasonID     int
+	Name         string
+	Seeded       bool
+	TwoLegged    bool
+	AwayGoals    bool
+	WinnerTeamID int
+	WinnerName   string
+}
+
+// CupLeg represents one match of a cup tie. Goals include extra time; penalties are nil without a shootout.
+type CupLeg struct {
+	Leg           int
+	HomeTeamID    int
+	HomeTeamName  string
+	AwayTeamID    int
+	AwayTeamName  string
+	HomeGoals     int
+	AwayGoals     int
+	ExtraTime     bool
+	HomePenalties *int
+	AwayPenalties *int
+}
+
+// CupTie represents a pairing in a knockout round. A tie without an away team is a bye.
+type CupTie struct {
+	ID           int
+	Round        int
+	Slot         int
+	HomeTeamID   int
+	HomeTeamName string
+	AwayTeamID   int
+	AwayTeamName string
+	WinnerTeamID int
+	DecidedBy    string
+	Legs         []CupLeg
+}
+
+// CupRound groups the ties played in the same knockout round.
+type CupRound struct {
+	Round int
+	Name  string
+	Ties  []CupTie
+}
+
+// CupBracket represents a cup together with every round drawn so far.
+type CupBracket struct {
+	Cup
+	TotalRounds int
+	Rounds      []CupRound
+}
+
+// Tournament represents a group stage followed by a knockout bracket.
+type Tournament struct {
+	ID              int
+	SeasonID        int
+	Name            string
+	GroupCount      int
+	AdvancePerGroup int
+	BestThirds      int // Best teams placed just below the qualifying places that also advance
+	HomeAndAway     bool
+	CupID           int // Knockout stage; zero until the group stage is complete
+}
+
+// TournamentMatch represents a group stage match. Goals are nil until the match has been played.
+type TournamentMatch struct {
+	ID           int
+	Group        string
+	Matchday     int
+	HomeTeamID   int
+	HomeTeamName string
+	AwayTeamID   int
+	AwayTeamName string
+	HomeGoals    *int
+	AwayGoals    *int
+}
+
+// TournamentGroup holds the standings and matches of one group.
+type TournamentGroup struct {
+	Name    string
+	Table   []LeagueTableRow
+	Matches []TournamentMatch
+}
+
+// TournamentOverview represents the full state of a tournament: groups, qualified teams and knockout bracket.
+type TournamentOverview struct {
+	Tournament
+	Stage     string
+	Groups    []TournamentGroup
+	Qualified []LeagueTableRow
+	Knockout  *CupBracket
+}
+
+// Season represents one edition of the league competitions.
+type Season struct {
+	ID        int
+	Name      string
+	StartDate string // Friday of the first match week (YYYY-MM-DD)
+	Timezone  string // IANA time zone kickoffs are shown in
+}
+
+// Division represents one tier of the league pyramid and its promotion and relegation rules.
+type Division struct {
+	ID               int
+	Name             string
+	Tier             int
+	PromotionPlaces  int
+	RelegationPlaces int
+	PlayoffPlaces    int // Teams below the promotion places playing off for one more promotion place
+	Teams            []Team
+}
+
+// TeamMovement records a team moving between divisions at the end of a season.
+type TeamMovement struct {
+	TeamID         int
+	TeamName       string
+	FromDivisionID int
+	ToDivisionID   int
+	Reason         string
+}
+
+// SeasonRollover summarizes the start of a new season.
+type SeasonRollover struct {
+	Season    Season
+	Movements []TeamMovement
+	Ratings   []Team // Power ratings the teams start the new season with
+}
+
+// SeasonStanding is a team's archived final position in a closed season.
+type SeasonStanding struct {
+	SeasonID     int
+	SeasonName   string
+	DivisionID   int
+	DivisionName string
+	Tier         int
+	Position     int
+	Power        int
+	LeagueTableRow
+	Movement string
+}
+
+// AllTimeRow represents a team's combined record over all closed seasons.
+type AllTimeRow struct {
+	TeamID       int
+	TeamName     string
+	Seasons      int
+	Titles       int // Seasons finished top of the highest tier
+	Played       int
+	Wins         int
+	Draws        int
+	Losses       int
+	GoalsFor     int
+	GoalsAgainst int
+	GoalDiff     int
+	Points       int
+}
+
+// Playoff represents the end-of-season playoffs for a division's last promotion place.
+type Playoff struct {
+	ID               int
+	SeasonID         int
+	DivisionID       int
+	DivisionName     string
+	Seeds            []LeagueTableRow // Final league positions of the playoff teams, best first
+	Bracket          CupBracket
+	PromotedTeamID   int // Zero until the final has been played
+	PromotedTeamName string
+}
+
+// FixtureConstraint is a rule the fixture scheduler tries to satisfy.
+type FixtureConstraint struct {
+	ID            int
+	Kind          string // shared_stadium, derby or unavailable
+	TeamID        int
+	TeamName      string
+	OtherTeamID   int // Second team of a shared stadium or derby
+	OtherTeamName string
+	Week          int    // Week of a derby
+	Date          string // Date (YYYY-MM-DD) a team is unavailable
+}
+
+// ConstraintViolation describes a fixture constraint the schedule does not satisfy.
+type ConstraintViolation struct {
+	Kind         string // shared_stadium, derby, unavailable or consecutive_venue
+	Hard         bool   // Hard constraints cannot be played as scheduled; soft ones are preferences
+	ConstraintID int    // Zero for built-in rules: consecutive home or away matches and teams sharing a home venue
+	TeamID       int
+	Week         int
+	Message      string
+}
+
+// WeekPosition is a team's league position and points after a week.
+type WeekPosition struct {
+	Week     int
+	Position int
+	Points   int
+}
+
+// PositionHistory is a team's league position and points after every played week of a season.
+type PositionHistory struct {
+	TeamID       int
+	TeamName     string
+	DivisionID   int
+	DivisionName string
+	Weeks        []WeekPosition
+}
+
+// Meeting is a match between two teams in any competition.
+type Meeting struct {
+	SeasonID     int
+	SeasonName   string
+	Competition  string    // Division, cup or tournament name
+	Stage        string    // Week, cup round and leg, or tournament group and matchday
+	Kickoff      time.Time // Zero for cup and tournament matches, which have no date
+	HomeTeamID   int
+	HomeTeamName string
+	AwayTeamID   int
+	AwayTeamName string
+	HomeGoals    int // Including extra time
+	AwayGoals    int
+	ExtraTime    bool
+	Penalties    string // Shootout score such as "4-3", home side first; empty without a shootout
+}
+
+// HeadToHead is the record of a team against one opponent over all seasons and competitions.
+// Wins, draws, losses and goals are from the team's point of view; a match decided on penalties counts as a draw.
+type HeadToHead struct {
+	TeamID             int
+	TeamName           string
+	OpponentID         int
+	OpponentName       string
+	Played             int
+	Wins               int
+	Draws              int
+	Losses             int
+	GoalsFor           int
+	GoalsAgainst       int
+	BiggestWin         *Meeting // The team's widest winning margin against the opponent
+	OpponentBiggestWin *Meeting
+	CurrentStreak      string    // Current run of the team's results, such as "W3"; empty before the first meeting
+	Meetings           []Meeting // Oldest first
+}
+
+// Streak is a run of consecutive matches of a team, such as wins or games without scoring.
+type Streak struct {
+	TeamID    int
+	TeamName  string
+	Length    int // Zero if the team never had such a run
+	FirstWeek int
+	LastWeek  int
+	Ongoing   bool // The run was still going after the team's last match
+}
+
+// TeamRecords are a team's records in one season. Matches point to the record-setting game;
+// the earliest one counts when several are equal.
+type TeamRecords struct {
+	SeasonID              int
+	SeasonName            string
+	TeamID                int
+	TeamName              string
+	Played                int
+	LongestWinningStreak  Streak
+	LongestUnbeatenStreak Streak
+	LongestScorelessRun   Streak // Consecutive matches without scoring
+	BiggestWin            *Match
+	BiggestLoss           *Match
+	MostGoals             *Match // Highest-scoring match the team played in
+	FirstResult           *Match
+	LastResult            *Match
+}
+
+// LeagueRecords are the records of a whole season over all teams and divisions.
+type LeagueRecords struct {
+	BiggestWin            *Match
+	HighestScoring        *Match
+	LongestWinningStreak  Streak
+	LongestUnbeatenStreak Streak
+	LongestScorelessRun   Streak
+}
+
+// SeasonRecords are the league-wide and per-team records of a season.
+type SeasonRecords struct {
+	SeasonID   int
+	SeasonName string
+	League     LeagueRecords
+	Teams      []TeamRecords
+}
+
+// ImportError is a problem with one row of an imported file. Rows are numbered from 1:
+// the header is row 1 of a CSV file, and the first object is row 1 of a JSON file.
+type ImportError struct {
+	Row     int
+	Field   string // Column the problem is in; empty if it concerns the whole row
+	Message string
+}
+
+// ImportReport is the outcome of an import. Nothing is stored when it has errors or is a dry run.
+type ImportReport struct {
+	Kind    string // teams, fixtures or results
+	Format  string // csv or json
+	DryRun  bool
+	Rows    int
+	Created int
+	Updated int // Existing matches whose result was set
+	Errors  []ImportError
+}
+
+// Export is a versioned snapshot of the league data. Each table is a list of rows keyed by
+// column name, so the file does not depend on the storage engine it came from.
+type Export struct {
+	Format     string // Always "go-football-league"
+	Version    int
+	ExportedAt string // RFC 3339, UTC
+	SeasonID   int    // Season the export was limited to; zero for the whole database
+	Tables     map[string][]map[string]interface{}
+}
@@ -0,0 +1,165 @@
+package league
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	models "go-football-league/internal/domain"
+	storage "go-football-league/internal/repository"
+)
+
+// finalStandings builds the archive rows of a finished season from the final table of
+// every division, marking the teams that go up or down.
+func finalStandings(ctx context.Context, seasonID int, movements []models.TeamMovement) ([]models.SeasonStanding, error) {
+	weeks, err := seasonWeeks(ctx, seasonID)
+	if err != nil {
+		return nil, err
+	}
+	divisions, err := GetDivisionsContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	moved := make(map[int]string, len(movements))
+	for _, m := range movements {
+		moved[m.TeamID] = m.Reason
+	}
+	power := make(map[int]int)
+	for _, d := range divisions {
+		for _, t := range d.Teams {
+			power[t.ID] = t.Power
+		}
+	}
+
+	var standings []models.SeasonStanding
+	for _, d := range divisions {
+		table, err := divisionTable(ctx, seasonID, d.ID, weeks, "", TableOptions{})
+		if err != nil {
+			return nil, err
+		}
+		for i, row := range table {
+			standings = append(standings, models.SeasonStanding{
+				SeasonID:       seasonID,
+				DivisionID:     d.ID,
+				DivisionName:   d.Name,
+				Tier:           d.Tier,
+				Position:       i + 1,
+				Power:          power[row.TeamID],
+				LeagueTableRow: row,
+				Movement:       moved[row.TeamID],
+			})
+		}
+	}
+	return standings, nil
+}
+
+// archiveStandings freezes the final standings of a season in the archive table.
+func archiveStandings(ctx context.Context, tx *sql.Tx, standings []models.SeasonStanding) error {
+	for _, s := range standings {
+		var movement interface{}
+		if s.Movement != "" {
+			movement = s.Movement
+		}
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO season_standings (season_id, division_id, division_name, tier, position,
+			                              team_id, team_name, power, played, wins, draws, losses,
+			                              goals_for, goals_against, points, movement)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		`, s.SeasonID, s.DivisionID, s.DivisionName, s.Tier, s.Position,
+			s.TeamID, s.TeamName, s.Power, s.Played, s.Wins, s.Draws, s.Losses,
+			s.GoalsFor, s.GoalsAgainst, s.Points, movement)
+		if err != nil {
+			return fmt.Errorf("Failed to archive standings of %s: %v", s.TeamName, err)
+		}
+	}
+	return nil
+}
+
+// GetSeasonStandings returns the archived final standings of a closed season, by tier and position.
+func GetSeasonStandings(seasonID int) ([]models.SeasonStanding, error) {
+	return GetSeasonStandingsContext(context.Background(), seasonID)
+}
+
+// GetSeasonStandingsContext is GetSeasonStandings with a context that cancels the query.
+func GetSeasonStandingsContext(ctx context.Context, seasonID int) ([]models.SeasonStanding, error) {
+	return queryStandings(ctx, "ss.season_id = ?", seasonID)
+}
+
+// GetTeamHistory returns a team's archived final standing in every closed season, the oldest first.
+func GetTeamHistory(teamID int) ([]models.SeasonStanding, error) {
+	return GetTeamHistoryContext(context.Background(), teamID)
+}
+
+// GetTeamHistoryContext is GetTeamHistory with a context that cancels the query.
+func GetTeamHistoryContext(ctx context.Context, teamID int) ([]models.SeasonStanding, error) {
+	return queryStandings(ctx, "ss.team_id = ?", teamID)
+}
+
+// queryStandings reads archived standings matching a filter on the season_standings table (alias ss).
+func queryStandings(ctx context.Context, filter string, arg interface{}) ([]models.SeasonStanding, error) {
+	rows, err := storage.DB.QueryContext(ctx, `
+		SELECT ss.season_id, s.name, ss.division_id, ss.division_name, ss.tier, ss.position,
+		       ss.team_id, ss.team_name, ss.power, ss.played, ss.wins, ss.draws, ss.losses,
+		       ss.goals_for, ss.goals_against, ss.points, COALESCE(ss.movement, '')
+		FROM season_standings ss
+		JOIN seasons s ON ss.season_id = s.id
+		WHERE `+filter+`
+		ORDER BY ss.season_id, ss.tier, ss.position
+	`, arg)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	standings := []models.SeasonStanding{}
+	for rows.Next() {
+		var s models.SeasonStanding
+		err := rows.Scan(&s.SeasonID, &s.SeasonName, &s.DivisionID, &s.DivisionName, &s.Tier, &s.Position,
+			&s.TeamID, &s.TeamName, &s.Power, &s.Played, &s.Wins, &s.Draws, &s.Losses,
+			&s.GoalsFor, &s.GoalsAgainst, &s.Points, &s.Movement)
+		if err != nil {
+			return nil, err
+		}
+		s.GoalDiff = s.GoalsFor - s.GoalsAgainst
+		standings = append(standings, s)
+	}
+	return standings, rows.Err()
+}
+
+// GetAllTimeTable combines the archived standings of all closed seasons into one table,
+// sorted by points, goal difference and goals scored.
+func GetAllTimeTable() ([]models.AllTimeRow, error) {
+	return GetAllTimeTableContext(context.Background())
+}
+
+// GetAllTimeTableContext is GetAllTimeTable with a context that cancels the query.
+func GetAllTimeTableContext(ctx context.Context) ([]models.AllTimeRow, error) {
+	rows, err := storage.DB.QueryContext(ctx, `
+		SELECT ss.team_id, t.name, COUNT(*),
+		       SUM(CASE WHEN ss.position = 1 AND ss.tier = (SELECT MIN(tier) FROM divisions) THEN 1 ELSE 0 END),
+		       SUM(ss.played), SUM(ss.wins), SUM(ss.draws), SUM(ss.losses),
+		       SUM(ss.goals_for), SUM(ss.goals_against), SUM(ss.points)
+		FROM season_standings ss
+		JOIN teams t ON ss.team_id = t.id
+		GROUP BY ss.team_id, t.name
+		ORDER BY SUM(ss.points) DESC, SUM(ss.goals_for) - SUM(ss.goals_against) DESC, SUM(ss.goals_for) DESC
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	table := []models.AllTimeRow{}
+	for rows.Next() {
+		var r models.AllTimeRow
+		err := rows.Scan(&r.TeamID, &r.TeamName, &r.Seasons, &r.Titles,
+			&r.Played, &r.Wins, &r.Draws, &r.Losses, &r.GoalsFor, &r.GoalsAgainst, &r.Points)
+		if err != nil {
+			return nil, err
+		}
+		r.GoalDiff = r.GoalsFor - r.GoalsAgainst
+		table = append(table, r)
+	}
+	return table, rows.Err()
+}
@@ -0,0 +1,270 @@
+package league
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"time"
+
+	models "go-football-league/internal/domain"
+	storage "go-football-league/internal/repository"
+)
+
+const (
+	exportFormat  = "go-football-league" // Identifies export files
+	exportVersion = 1                    // Bumped when the layout of the file or of a table changes
+)
+
+// exportTable is a table included in exports. Tables are listed so that every table comes
+// after the tables it refers to, which is the order they are restored in. seasonFilter
+// selects the rows of one season (the ? is the season ID); tables without one are
+// reference data and exported in full, unless they only go into whole-database exports.
+type exportTable struct {
+	name         string
+	seasonFilter string
+	wholeOnly    bool // Rows that belong to no particular season, such as predictions
+}
+
+var exportTables = []exportTable{
+	{name: "seasons"},
+	{name: "divisions"},
+	{name: "venues"},
+	{name: "teams"},
+	{name: "players"},
+	{name: "matches", seasonFilter: "season_id = ?"},
+	{name: "result_changes", seasonFilter: "match_id IN (SELECT id FROM matches WHERE season_id = ?)"},
+	{name: "championship_predictions", wholeOnly: true},
+	{name: "match_lineups", seasonFilter: "match_id IN (SELECT id FROM matches WHERE season_id = ?)"},
+	{name: "match_cards", seasonFilter: "match_id IN (SELECT id FROM matches WHERE season_id = ?)"},
+	{name: "suspensions", seasonFilter: "season_id = ?"},
+	{name: "injuries", seasonFilter: "season_id = ?"},
+	{name: "cups", seasonFilter: "season_id = ?"},
+	{name: "cup_ties", seasonFilter: "cup_id IN (SELECT id FROM cups WHERE season_id = ?)"},
+	{name: "cup_legs", seasonFilter: "tie_id IN (SELECT t.id FROM cup_ties t JOIN cups c ON t.cup_id = c.id WHERE c.season_id = ?)"},
+	{name: "tournaments", seasonFilter: "season_id = ?"},
+	{name: "tournament_teams", seasonFilter: "tournament_id IN (SELECT id FROM tournaments WHERE season_id = ?)"},
+	{name: "tournament_matches", seasonFilter: "tournament_id IN (SELECT id FROM tournaments WHERE season_id = ?)"},
+	{name: "playoffs", seasonFilter: "season_id = ?"},
+	{name: "season_standings", seasonFilter: "season_id = ?"},
+	{name: "fixture_constraints"},
+}
+
+// Export returns a snapshot of the whole database, or of one season when seasonID is not
+// zero. A season export holds the season's matches with the audit log of their results,
+// competitions, discipline and archived standings plus the reference data they need:
+// seasons, divisions, venues, teams with their ratings, players and fixture constraints.
+// Championship predictions have no season and are only in whole-database exports. Rows
+// are copied column by column, including the revision and update time of every match.
+func Export(seasonID int) (models.Export, error) {
+	return ExportContext(context.Background(), seasonID)
+}
+
+// ExportContext is Export with a context that cancels the queries.
+func ExportContext(ctx context.Context, seasonID int) (models.Export, error) {
+	export := models.Export{
+		Format:     exportFormat,
+		Version:    exportVersion,
+		ExportedAt: time.Now().UTC().Format(time.RFC3339),
+		SeasonID:   seasonID,
+		Tables:     map[string][]map[string]interface{}{},
+	}
+	if seasonID != 0 {
+		if _, err := getSeason(ctx, seasonID); err != nil {
+			return export, err
+		}
+	}
+
+	for _, t := range exportTables {
+		if seasonID != 0 && t.wholeOnly {
+			continue
+		}
+		query := "SELECT * FROM " + t.name
+		var args []interface{}
+		if seasonID != 0 && t.seasonFilter != "" {
+			query += " WHERE " + t.seasonFilter
+			args = append(args, seasonID)
+		}
+		rows, err := exportRows(ctx, storage.DB, query+" ORDER BY rowid", args...)
+		if err != nil {
+			return export, fmt.Errorf("Failed to export %s: %v", t.name, err)
+		}
+		export.Tables[t.name] = rows
+	}
+	return export, nil
+}
+
+// exportRows reads the rows of a query as maps from column name to value.
+func exportRows(ctx context.Context, q storage.Querier, query string, args ...interface{}) ([]map[string]interface{}, error) {
+	rows, err := q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	result := []map[string]interface{}{}
+	for rows.Next() {
+		values := make([]interface{}, len(columns))
+		pointers := make([]interface{}, len(columns))
+		for i := range values {
+			pointers[i] = &values[i]
+		}
+		if err := rows.Scan(pointers...); err != nil {
+			return nil, err
+		}
+		row := make(map[string]interface{}, len(columns))
+		for i, c := range columns {
+			if b, ok := values[i].([]byte); ok {
+				values[i] = string(b)
+			}
+			row[c] = values[i]
+		}
+		result = append(result, row)
+	}
+	return result, rows.Err()
+}
+
+// ReadExport decodes an export file and checks that this version of the league can restore it.
+func ReadExport(r io.Reader) (models.Export, error) {
+	var export models.Export
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+	if err := dec.Decode(&export); err != nil {
+		return export, fmt.Errorf("Failed to read export: %v", err)
+	}
+	if export.Format != exportFormat {
+		return export, errors.New("The file is not a go-football-league export")
+	}
+	if export.Version != exportVersion {
+		return export, fmt.Errorf("Export version %d is not supported; this version restores version %d", export.Version, exportVersion)
+	}
+	return export, nil
+}
+
+// Restore recreates the data of an export in an empty store: one whose tables hold exactly
+// the initial data of the schema, so no added or edited team, player, venue or constraint is
+// lost. The initial data is replaced by the export in a single transaction.
+func Restore(export models.Export) error {
+	return RestoreContext(context.Background(), export)
+}
+
+// RestoreContext is Restore with a context that cancels the queries and, before it commits, the restore.
+func RestoreContext(ctx context.Context, export models.Export) error {
+	for name := range export.Tables {
+		if !isExportTable(name) {
+			return fmt.Errorf("Unknown table %q in export", name)
+		}
+	}
+	columns := map[string]map[string]bool{}
+	for _, t := range exportTables {
+		c, err := tableColumns(ctx, t.name)
+		if err != nil {
+			return err
+		}
+		columns[t.name] = c
+	}
+
+	if err := checkInitialData(ctx); err != nil {
+		return err
+	}
+
+	tx, err := storage.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for i := len(exportTables) - 1; i >= 0; i-- {
+		if _, err := tx.ExecContext(ctx, "DELETE FROM "+exportTables[i].name); err != nil {
+			return fmt.Errorf("Failed to clear %s: %v", exportTables[i].name, err)
+		}
+	}
+	for _, t := range exportTables {
+		for i, row := range export.Tables[t.name] {
+			names := make([]string, 0, len(row))
+			values := make([]interface{}, 0, len(row))
+			for name, value := range row {
+				if !columns[t.name][name] {
+					return fmt.Errorf("Unknown column %q in %s row %d", name, t.name, i+1)
+				}
+				if n, ok := value.(json.Number); ok {
+					if v, err := n.Int64(); err == nil {
+						value = v
+					} else if v, err := n.Float64(); err == nil {
+						value = v
+					}
+				}
+				names = append(names, name)
+				values = append(values, value)
+			}
+			query := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)",
+				t.name, strings.Join(names, ", "), strings.TrimSuffix(strings.Repeat("?, ", len(names)), ", "))
+			if _, err := tx.ExecContext(ctx, query, values...); err != nil {
+				return fmt.Errorf("Failed to restore %s row %d: %v", t.name, i+1, err)
+			}
+		}
+	}
+	return tx.Commit()
+}
+
+// checkInitialData returns an error unless every exported table holds the same rows as a
+// new database, which restore can replace without losing anything.
+func checkInitialData(ctx context.Context) error {
+	seed, err := storage.SeedDB()
+	if err != nil {
+		return err
+	}
+	defer seed.Close()
+
+	for _, t := range exportTables {
+		query := "SELECT * FROM " + t.name + " ORDER BY rowid"
+		want, err := exportRows(ctx, seed, query)
+		if err != nil {
+			return fmt.Errorf("Failed to read the initial %s: %v", t.name, err)
+		}
+		got, err := exportRows(ctx, storage.DB, query)
+		if err != nil {
+			return fmt.Errorf("Failed to read %s: %v", t.name, err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			return fmt.Errorf("The %s differ from the initial data; restore needs an empty store, reset it first", strings.ReplaceAll(t.name, "_", " "))
+		}
+	}
+	return nil
+}
+
+// tableColumns returns the column names of a table.
+func tableColumns(ctx context.Context, table string) (map[string]bool, error) {
+	rows, err := storage.DB.QueryContext(ctx, "SELECT name FROM pragma_table_info(?)", table)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	columns := map[string]bool{}
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		columns[name] = true
+	}
+	return columns, rows.Err()
+}
+
+// isExportTable reports whether a table is part of exports.
+func isExportTable(name string) bool {
+	for _, t := range exportTables {
+		if t.name == name {
+			return true
+		}
+	}
+	return false
+}
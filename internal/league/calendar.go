@@ -0,0 +1,264 @@
+package league
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+	_ "time/tzdata" // Time zones work on hosts without a zoneinfo database
+
+	storage "go-football-league/internal/repository"
+)
+
+const (
+	dateLayout     = "2006-01-02"
+	kickoffLayout  = "2006-01-02T15:04:05Z" // Kickoffs are stored in UTC with this layout
+	offseasonWeeks = 10                     // Weeks between the last match week of a season and the next season's start
+
+	// nowUTC is an SQL expression giving the current time in the stored kickoff layout
+	nowUTC = "strftime('%Y-%m-%dT%H:%M:%SZ', 'now')"
+)
+
+// kickoffSlot is a kickoff time within a match week, which runs from Friday to Monday.
+type kickoffSlot struct {
+	Day    int // Days after the Friday of the match week
+	Hour   int // Local time in the league's time zone
+	Minute int
+}
+
+// matchweekSlots are the kickoff slots a match week is spread across, in order of use.
+var matchweekSlots = []kickoffSlot{
+	{Day: 1, Hour: 15, Minute: 0},  // Saturday 15:00
+	{Day: 1, Hour: 12, Minute: 30}, // Saturday 12:30
+	{Day: 1, Hour: 17, Minute: 30}, // Saturday 17:30
+	{Day: 2, Hour: 14, Minute: 0},  // Sunday 14:00
+	{Day: 0, Hour: 20, Minute: 0},  // Friday 20:00
+	{Day: 2, Hour: 16, Minute: 30}, // Sunday 16:30
+	{Day: 3, Hour: 20, Minute: 0},  // Monday 20:00
+}
+
+// seasonCalendar returns the start date and time zone of a season.
+// The start date is midnight of the first match week's Friday in the league's time zone.
+func seasonCalendar(ctx context.Context, seasonID int) (time.Time, *time.Location, error) {
+	return querySeasonCalendar(ctx, storage.DB, seasonID)
+}
+
+// querySeasonCalendar is seasonCalendar on a database or transaction.
+func querySeasonCalendar(ctx context.Context, q storage.Querier, seasonID int) (time.Time, *time.Location, error) {
+	var startDate, timezone string
+	err := q.QueryRowContext(ctx, "SELECT start_date, timezone FROM seasons WHERE id = ?", seasonID).Scan(&startDate, &timezone)
+	if err == sql.ErrNoRows {
+		return time.Time{}, nil, fmt.Errorf("Season %d does not exist", seasonID)
+	}
+	if err != nil {
+		return time.Time{}, nil, err
+	}
+
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		return time.Time{}, nil, fmt.Errorf("Invalid time zone %q: %v", timezone, err)
+	}
+	start, err := time.ParseInLocation(dateLayout, startDate, loc)
+	if err != nil {
+		return time.Time{}, nil, fmt.Errorf("Invalid season start date %q: %v", startDate, err)
+	}
+	return start, loc, nil
+}
+
+// LeagueLocation returns the time zone of the current season.
+func LeagueLocation() (*time.Location, error) {
+	return leagueLocation(context.Background())
+}
+
+// leagueLocation is LeagueLocation with a context that cancels the queries.
+func leagueLocation(ctx context.Context) (*time.Location, error) {
+	seasonID, err := CurrentSeasonIDContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	_, loc, err := seasonCalendar(ctx, seasonID)
+	return loc, err
+}
+
+// slotKickoff returns the kickoff of a slot in the given week of a season starting on start.
+func slotKickoff(start time.Time, week int, slot kickoffSlot) time.Time {
+	day := start.AddDate(0, 0, (week-1)*daysPerWeek+slot.Day)
+	return time.Date(day.Year(), day.Month(), day.Day(), slot.Hour, slot.Minute, 0, 0, start.Location())
+}
+
+// kickoffWeek returns the week of a season starting on start that a kickoff falls in, each
+// week running for daysPerWeek days from its Friday. A kickoff before the season gives 0.
+func kickoffWeek(start, kickoff time.Time) int {
+	local := kickoff.In(start.Location())
+	day := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, time.UTC)
+	first := time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, time.UTC)
+	days := int(day.Sub(first).Hours() / 24)
+	if days < 0 {
+		return 0
+	}
+	return days/daysPerWeek + 1
+}
+
+// formatKickoff converts a kickoff to the UTC layout stored in the database.
+func formatKickoff(t time.Time) string {
+	return t.UTC().Format(kickoffLayout)
+}
+
+// parseKickoff reads a stored kickoff and renders it in the given time zone.
+// A missing kickoff gives the zero time.
+func parseKickoff(s sql.NullString, loc *time.Location) (time.Time, error) {
+	if !s.Valid {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse(kickoffLayout, s.String)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return t.In(loc), nil
+}
+
+// scheduleSeason gives every scheduled (not yet played or postponed) match of a season a kickoff time. The matches of
+// a week are spread over the Friday to Monday slots, top division first. Kickoffs given by hand are kept.
+func scheduleSeason(ctx context.Context, q storage.Querier, seasonID int) error {
+	start, _, err := querySeasonCalendar(ctx, q, seasonID)
+	if err != nil {
+		return err
+	}
+
+	rows, err := q.QueryContext(ctx, `
+		SELECT m.id, m.week
+		FROM matches m
+		JOIN divisions d ON m.division_id = d.id
+		WHERE m.season_id = ? AND m.status = 'scheduled' AND m.kickoff_set = 0
+		ORDER BY m.week, d.tier, m.id
+	`, seasonID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	type fixture struct {
+		ID   int
+		Week int
+	}
+	var fixtures []fixture
+	for rows.Next() {
+		var f fixture
+		if err := rows.Scan(&f.ID, &f.Week); err != nil {
+			return err
+		}
+		fixtures = append(fixtures, f)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	slot := 0
+	for i, f := range fixtures {
+		if i == 0 || fixtures[i-1].Week != f.Week {
+			slot = 0
+		}
+		kickoff := slotKickoff(start, f.Week, matchweekSlots[slot%len(matchweekSlots)])
+		// Moving an already scheduled match counts as a new revision for calendar subscribers
+		_, err := q.ExecContext(ctx, `
+			UPDATE matches
+			SET revision = revision + CASE WHEN kickoff IS NULL OR kickoff = ?1 THEN 0 ELSE 1 END,
+			    updated_at = CASE WHEN kickoff IS NULL OR kickoff = ?1 THEN updated_at ELSE `+nowUTC+` END,
+			    kickoff = ?1
+			WHERE id = ?2
+		`, formatKickoff(kickoff), f.ID)
+		if err != nil {
+			return fmt.Errorf("Failed to schedule match %d: %v", f.ID, err)
+		}
+		slot++
+	}
+	return nil
+}
+
+// SetSeasonCalendar changes the start date (YYYY-MM-DD, the Friday of the first match week)
+// and time zone of a season and reschedules its unplayed matches, except for the kickoffs
+// given by hand.
+func SetSeasonCalendar(seasonID int, startDate, timezone string) error {
+	return SetSeasonCalendarContext(context.Background(), seasonID, startDate, timezone)
+}
+
+// SetSeasonCalendarContext is SetSeasonCalendar with a context that cancels the queries.
+// The calendar and the new kickoffs are stored in one transaction.
+func SetSeasonCalendarContext(ctx context.Context, seasonID int, startDate, timezone string) error {
+	if _, err := time.LoadLocation(timezone); err != nil || timezone == "" {
+		return fmt.Errorf("Invalid time zone %q", timezone)
+	}
+	start, err := time.Parse(dateLayout, startDate)
+	if err != nil {
+		return errors.New("Start date must be formatted as YYYY-MM-DD")
+	}
+	if start.Weekday() != time.Friday {
+		return errors.New("Start date must be a Friday")
+	}
+
+	tx, err := storage.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, `
+		UPDATE seasons SET start_date = ?, timezone = ? WHERE id = ?
+	`, startDate, timezone, seasonID)
+	if err != nil {
+		return fmt.Errorf("Failed to update season calendar: %v", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("Season %d does not exist", seasonID)
+	}
+	if err := scheduleSeason(ctx, tx, seasonID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// nextSeasonStart returns the start date of the season following the given one:
+// the Friday offseasonWeeks weeks after its last match week.
+func nextSeasonStart(ctx context.Context, seasonID int) (string, error) {
+	start, _, err := seasonCalendar(ctx, seasonID)
+	if err != nil {
+		return "", err
+	}
+	weeks, err := seasonWeeks(ctx, seasonID)
+	if err != nil {
+		return "", err
+	}
+	return start.AddDate(0, 0, (weeks+offseasonWeeks)*daysPerWeek).Format(dateLayout), nil
+}
+
+// weekAsOf returns the last week of a season with a match kicking off at or before asOf.
+func weekAsOf(ctx context.Context, seasonID int, asOf time.Time) (int, error) {
+	var week int
+	err := storage.DB.QueryRowContext(ctx, `
+		SELECT COALESCE(MAX(week), 0) FROM matches WHERE season_id = ? AND kickoff <= ?
+	`, seasonID, formatKickoff(asOf)).Scan(&week)
+	return week, err
+}
+
+// ParseAsOf reads a standings cut-off: either a date (YYYY-MM-DD), which covers the whole
+// day in the league's time zone, or an RFC 3339 timestamp.
+func ParseAsOf(value string) (time.Time, error) {
+	return ParseAsOfContext(context.Background(), value)
+}
+
+// ParseAsOfContext is ParseAsOf with a context that cancels the query for the league's time zone.
+func ParseAsOfContext(ctx context.Context, value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	loc, err := leagueLocation(ctx)
+	if err != nil {
+		return time.Time{}, err
+	}
+	day, err := time.ParseInLocation(dateLayout, value, loc)
+	if err != nil {
+		return time.Time{}, errors.New("Date must be formatted as YYYY-MM-DD or RFC 3339")
+	}
+	return day.AddDate(0, 0, 1).Add(-time.Second), nil
+}
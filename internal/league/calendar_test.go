@@ -0,0 +1,36 @@
+package league
+
+import (
+	"testing"
+	"time"
+)
+
+func TestKickoffWeek(t *testing.T) {
+	london, err := time.LoadLocation("Europe/London")
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Date(2025, 8, 15, 0, 0, 0, 0, london) // A Friday
+
+	tests := []struct {
+		name    string
+		kickoff time.Time
+		want    int
+	}{
+		{name: "first slot of the season", kickoff: slotKickoff(start, 1, matchweekSlots[0]), want: 1},
+		{name: "Friday evening", kickoff: time.Date(2025, 8, 15, 20, 0, 0, 0, london), want: 1},
+		{name: "Thursday before the next week", kickoff: time.Date(2025, 8, 21, 23, 59, 0, 0, london), want: 1},
+		{name: "next Friday", kickoff: time.Date(2025, 8, 22, 0, 0, 0, 0, london), want: 2},
+		{name: "Monday slot", kickoff: slotKickoff(start, 7, matchweekSlots[6]), want: 7},
+		{name: "day in UTC differs from the league's", kickoff: time.Date(2025, 8, 21, 23, 30, 0, 0, time.UTC), want: 2},
+		{name: "across the clock change", kickoff: slotKickoff(start, 12, matchweekSlots[0]), want: 12},
+		{name: "before the season", kickoff: time.Date(2025, 8, 14, 20, 0, 0, 0, london), want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := kickoffWeek(start, tt.kickoff); got != tt.want {
+				t.Errorf("got week %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,390 @@
+package league
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	models "go-football-league/internal/domain"
+	storage "go-football-league/internal/repository"
+)
+
+// Fixture constraint kinds.
+const (
+	ConstraintSharedStadium    = "shared_stadium"
+	ConstraintDerby            = "derby"
+	ConstraintUnavailable      = "unavailable"
+	ConstraintConsecutiveVenue = "consecutive_venue" // Built-in, reported but not stored
+)
+
+const (
+	maxConsecutiveVenue = 2   // Most home or away matches in a row a team may play
+	hardConstraintCost  = 100 // Cost of breaking a hard constraint; every soft one costs 1
+	lastMatchDay        = 3   // Match weeks run from Friday (day 0) to Monday (day 3)
+)
+
+// scheduleRule is a stored constraint with the match week it applies to in a given season.
+type scheduleRule struct {
+	models.FixtureConstraint
+	week int // Derby week, or the week containing an unavailable date (zero when it falls outside match days)
+}
+
+// GetFixtureConstraints returns all fixture constraints with team names.
+func GetFixtureConstraints() ([]models.FixtureConstraint, error) {
+	return GetFixtureConstraintsContext(context.Background())
+}
+
+// GetFixtureConstraintsContext is GetFixtureConstraints with a context that cancels the query.
+func GetFixtureConstraintsContext(ctx context.Context) ([]models.FixtureConstraint, error) {
+	return fixtureConstraints(ctx, storage.DB)
+}
+
+// fixtureConstraints is GetFixtureConstraints on a database or transaction.
+func fixtureConstraints(ctx context.Context, q storage.Querier) ([]models.FixtureConstraint, error) {
+	rows, err := q.QueryContext(ctx, `
+		SELECT c.id, c.kind, c.team_id, t.name, COALESCE(c.other_team_id, 0), COALESCE(o.name, ''),
+		       COALESCE(c.week, 0), COALESCE(c.date, '')
+		FROM fixture_constraints c
+		JOIN teams t ON c.team_id = t.id
+		LEFT JOIN teams o ON c.other_team_id = o.id
+		ORDER BY c.id
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	constraints := []models.FixtureConstraint{}
+	for rows.Next() {
+		var c models.FixtureConstraint
+		err := rows.Scan(&c.ID, &c.Kind, &c.TeamID, &c.TeamName, &c.OtherTeamID, &c.OtherTeamName, &c.Week, &c.Date)
+		if err != nil {
+			return nil, err
+		}
+		constraints = append(constraints, c)
+	}
+	return constraints, rows.Err()
+}
+
+// CreateFixtureConstraint stores a constraint for the scheduler and returns its ID.
+// It applies to fixtures generated from then on; existing fixtures are not changed.
+func CreateFixtureConstraint(c models.FixtureConstraint) (int, error) {
+	return CreateFixtureConstraintContext(context.Background(), c)
+}
+
+// CreateFixtureConstraintContext is CreateFixtureConstraint with a context that cancels the queries.
+func CreateFixtureConstraintContext(ctx context.Context, c models.FixtureConstraint) (int, error) {
+	if err := validateFixtureConstraint(ctx, c); err != nil {
+		return 0, err
+	}
+
+	var otherTeamID, week, date interface{}
+	if c.OtherTeamID != 0 {
+		otherTeamID = c.OtherTeamID
+	}
+	if c.Week != 0 {
+		week = c.Week
+	}
+	if c.Date != "" {
+		date = c.Date
+	}
+	res, err := storage.DB.ExecContext(ctx, `
+		INSERT INTO fixture_constraints (kind, team_id, other_team_id, week, date)
+		VALUES (?, ?, ?, ?, ?)
+	`, c.Kind, c.TeamID, otherTeamID, week, date)
+	if err != nil {
+		return 0, fmt.Errorf("Failed to create fixture constraint: %v", err)
+	}
+	id, err := res.LastInsertId()
+	return int(id), err
+}
+
+// validateFixtureConstraint checks that a constraint has the fields its kind needs.
+func validateFixtureConstraint(ctx context.Context, c models.FixtureConstraint) error {
+	if err := checkTeam(ctx, c.TeamID); err != nil {
+		return err
+	}
+	switch c.Kind {
+	case ConstraintSharedStadium, ConstraintDerby:
+		if c.OtherTeamID == 0 || c.OtherTeamID == c.TeamID {
+			return fmt.Errorf("A %s constraint needs two different teams", c.Kind)
+		}
+		if err := checkTeam(ctx, c.OtherTeamID); err != nil {
+			return err
+		}
+		if c.Kind == ConstraintDerby && c.Week < 1 {
+			return errors.New("A derby constraint needs a week of 1 or more")
+		}
+	case ConstraintUnavailable:
+		if _, err := time.Parse(dateLayout, c.Date); err != nil {
+			return errors.New("An unavailable constraint needs a date formatted as YYYY-MM-DD")
+		}
+	default:
+		return fmt.Errorf("Unknown constraint kind %q; use %s, %s or %s", c.Kind,
+			ConstraintSharedStadium, ConstraintDerby, ConstraintUnavailable)
+	}
+	return nil
+}
+
+// checkTeam returns an error if a team does not exist.
+func checkTeam(ctx context.Context, teamID int) error {
+	var exists int
+	err := storage.DB.QueryRowContext(ctx, "SELECT 1 FROM teams WHERE id = ?", teamID).Scan(&exists)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("Team %d does not exist", teamID)
+	}
+	return err
+}
+
+// DeleteFixtureConstraint removes a constraint.
+func DeleteFixtureConstraint(id int) error {
+	return DeleteFixtureConstraintContext(context.Background(), id)
+}
+
+// DeleteFixtureConstraintContext is DeleteFixtureConstraint with a context that cancels the delete.
+func DeleteFixtureConstraintContext(ctx context.Context, id int) error {
+	res, err := storage.DB.ExecContext(ctx, "DELETE FROM fixture_constraints WHERE id = ?", id)
+	if err != nil {
+		return fmt.Errorf("Failed to delete fixture constraint: %v", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("Fixture constraint %d does not exist", id)
+	}
+	return nil
+}
+
+// CheckFixtureConstraints returns the constraints the stored fixture of a season does not
+// satisfy, taking postponed and rescheduled matches into account.
+func CheckFixtureConstraints(seasonID int) ([]models.ConstraintViolation, error) {
+	return CheckFixtureConstraintsContext(context.Background(), seasonID)
+}
+
+// CheckFixtureConstraintsContext is CheckFixtureConstraints with a context that cancels the queries.
+func CheckFixtureConstraintsContext(ctx context.Context, seasonID int) ([]models.ConstraintViolation, error) {
+	rules, err := loadScheduleRules(ctx, storage.DB, seasonID)
+	if err != nil {
+		return nil, err
+	}
+	names, err := teamNames(ctx, storage.DB)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := storage.DB.QueryContext(ctx, `
+		SELECT week, home_team_id, away_team_id FROM matches WHERE season_id = ? AND status <> 'postponed'
+	`, seasonID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var matches []fixtureMatch
+	for rows.Next() {
+		var m fixtureMatch
+		if err := rows.Scan(&m.Week, &m.Home, &m.Away); err != nil {
+			return nil, err
+		}
+		matches = append(matches, m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	_, violations := evaluateSchedule(matches, rules, names, true)
+	if violations == nil {
+		violations = []models.ConstraintViolation{}
+	}
+	return violations, nil
+}
+
+// loadScheduleRules reads the stored constraints and works out the week each applies to in a season.
+// Teams with the same home venue are added as sharing a stadium.
+func loadScheduleRules(ctx context.Context, q storage.Querier, seasonID int) ([]scheduleRule, error) {
+	constraints, err := fixtureConstraints(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	start, _, err := querySeasonCalendar(ctx, q, seasonID)
+	if err != nil {
+		return nil, err
+	}
+	// Count days between calendar dates in UTC so daylight saving changes do not matter
+	firstFriday, _ := time.Parse(dateLayout, start.Format(dateLayout))
+
+	rules := make([]scheduleRule, 0, len(constraints))
+	for _, c := range constraints {
+		rule := scheduleRule{FixtureConstraint: c, week: c.Week}
+		if c.Kind == ConstraintUnavailable {
+			rule.week = 0
+			if date, err := time.Parse(dateLayout, c.Date); err == nil {
+				days := int(date.Sub(firstFriday).Hours() / 24)
+				if days >= 0 && days%daysPerWeek <= lastMatchDay {
+					rule.week = days/daysPerWeek + 1
+				}
+			}
+		}
+		rules = append(rules, rule)
+	}
+
+	// Active teams with the same home venue share a stadium
+	rows, err := q.QueryContext(ctx, `
+		SELECT a.id, b.id
+		FROM teams a
+		JOIN teams b ON a.venue_id = b.venue_id AND a.id < b.id
+		WHERE a.active = 1 AND b.active = 1
+		ORDER BY a.id, b.id
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var rule scheduleRule
+		rule.Kind = ConstraintSharedStadium
+		if err := rows.Scan(&rule.TeamID, &rule.OtherTeamID); err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+	return rules, rows.Err()
+}
+
+// teamNames maps the ID of every team to its name.
+func teamNames(ctx context.Context, q storage.Querier) (map[int]string, error) {
+	rows, err := q.QueryContext(ctx, "SELECT id, name FROM teams")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	names := make(map[int]string)
+	for rows.Next() {
+		var id int
+		var name string
+		if err := rows.Scan(&id, &name); err != nil {
+			return nil, err
+		}
+		names[id] = name
+	}
+	return names, rows.Err()
+}
+
+// evaluateSchedule scores a fixture list against the constraints: every broken hard constraint
+// costs hardConstraintCost and every soft one 1, so a cost of zero satisfies everything.
+// Violations are only described when report is set, which keeps the scheduler's search fast.
+func evaluateSchedule(matches []fixtureMatch, rules []scheduleRule, names map[int]string, report bool) (int, []models.ConstraintViolation) {
+	type pair struct{ a, b int }
+	meet := func(a, b int) pair {
+		if a > b {
+			a, b = b, a
+		}
+		return pair{a, b}
+	}
+
+	atHome := make(map[int]map[int]bool) // Team, week: playing at home
+	meetings := make(map[pair][]int)     // Weeks two teams meet in
+	for _, m := range matches {
+		if atHome[m.Home] == nil {
+			atHome[m.Home] = make(map[int]bool)
+		}
+		if atHome[m.Away] == nil {
+			atHome[m.Away] = make(map[int]bool)
+		}
+		atHome[m.Home][m.Week] = true
+		atHome[m.Away][m.Week] = false
+		p := meet(m.Home, m.Away)
+		meetings[p] = append(meetings[p], m.Week)
+	}
+
+	cost := 0
+	var violations []models.ConstraintViolation
+	add := func(v models.ConstraintViolation, weight int) {
+		cost += weight
+		if report {
+			violations = append(violations, v)
+		}
+	}
+
+	for _, r := range rules {
+		switch r.Kind {
+		case ConstraintSharedStadium:
+			for _, week := range sortedWeeks(atHome[r.TeamID]) {
+				if atHome[r.TeamID][week] && atHome[r.OtherTeamID][week] {
+					add(models.ConstraintViolation{
+						Kind: r.Kind, Hard: true, ConstraintID: r.ID, TeamID: r.TeamID, Week: week,
+						Message: fmt.Sprintf("%s and %s both play at home in week %d", names[r.TeamID], names[r.OtherTeamID], week),
+					}, hardConstraintCost)
+				}
+			}
+		case ConstraintDerby:
+			found := false
+			for _, week := range meetings[meet(r.TeamID, r.OtherTeamID)] {
+				found = found || week == r.week
+			}
+			if !found {
+				add(models.ConstraintViolation{
+					Kind: r.Kind, ConstraintID: r.ID, TeamID: r.TeamID, Week: r.week,
+					Message: fmt.Sprintf("%s vs %s is not played in week %d", names[r.TeamID], names[r.OtherTeamID], r.week),
+				}, 1)
+			}
+		case ConstraintUnavailable:
+			if _, plays := atHome[r.TeamID][r.week]; plays && r.week != 0 {
+				add(models.ConstraintViolation{
+					Kind: r.Kind, Hard: true, ConstraintID: r.ID, TeamID: r.TeamID, Week: r.week,
+					Message: fmt.Sprintf("%s plays in week %d but is unavailable on %s", names[r.TeamID], r.week, r.Date),
+				}, hardConstraintCost)
+			}
+		}
+	}
+
+	// Limit runs of home or away matches; weeks without a match do not break a run. The
+	// round-robin fixture always meets the limit, so only other constraints can force a break
+	teams := make([]int, 0, len(atHome))
+	for teamID := range atHome {
+		teams = append(teams, teamID)
+	}
+	sort.Ints(teams)
+	for _, teamID := range teams {
+		weeks := sortedWeeks(atHome[teamID])
+		run := 0
+		for i, week := range weeks {
+			if i > 0 && atHome[teamID][week] == atHome[teamID][weeks[i-1]] {
+				run++
+			} else {
+				run = 1
+			}
+			if run > maxConsecutiveVenue {
+				venue := "away"
+				if atHome[teamID][week] {
+					venue = "home"
+				}
+				add(models.ConstraintViolation{
+					Kind: ConstraintConsecutiveVenue, Hard: true, TeamID: teamID, Week: week,
+					Message: fmt.Sprintf("%s plays %d %s matches in a row up to week %d", names[teamID], run, venue, week),
+				}, hardConstraintCost)
+			}
+		}
+	}
+	return cost, violations
+}
+
+// sortedWeeks returns the weeks a team plays in, in order.
+func sortedWeeks(atHome map[int]bool) []int {
+	weeks := make([]int, 0, len(atHome))
+	for week := range atHome {
+		weeks = append(weeks, week)
+	}
+	sort.Ints(weeks)
+	return weeks
+}
+
+// describeViolation renders a violation as a single line, marking the hard ones.
+func describeViolation(v models.ConstraintViolation) string {
+	if v.Hard {
+		return "[hard] " + v.Message
+	}
+	return "[soft] " + v.Message
+}
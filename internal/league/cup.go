@@ -0,0 +1,499 @@
+package league
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+
+	models "go-football-league/internal/domain"
+	storage "go-football-league/internal/repository"
+)
+
+// CupOptions configures a knockout cup competition.
+type CupOptions struct {
+	Name      string
+	TeamIDs   []int // Teams taking part; all teams when empty
+	Seeded    bool  // Seed the draw by team power instead of drawing at random
+	TwoLegged bool  // Play every tie over two legs
+	AwayGoals bool  // Break level aggregates with away goals (two-legged ties only)
+}
+
+// CreateCup draws a knockout bracket for a new cup in the current season and returns its ID.
+// When the number of teams is not a power of two, the top seeds (or the first teams
+// drawn) receive a bye into the second round.
+func CreateCup(opts CupOptions) (int, error) {
+	return CreateCupContext(context.Background(), opts)
+}
+
+// CreateCupContext is CreateCup with a context that cancels the queries.
+func CreateCupContext(ctx context.Context, opts CupOptions) (int, error) {
+	if strings.TrimSpace(opts.Name) == "" {
+		return 0, errors.New("Cup name is required")
+	}
+
+	teams, err := loadContenders(ctx, storage.DB, opts.TeamIDs)
+	if err != nil {
+		return 0, err
+	}
+	if len(teams) < 2 {
+		return 0, errors.New("A cup needs at least 2 teams")
+	}
+
+	// Order the teams by seed: strongest first, or a random draw
+	if opts.Seeded {
+		sort.SliceStable(teams, func(i, j int) bool {
+			return teams[i].Power > teams[j].Power
+		})
+	} else {
+		random.Shuffle(len(teams), func(i, j int) {
+			teams[i], teams[j] = teams[j], teams[i]
+		})
+	}
+
+	seasonID, err := CurrentSeasonIDContext(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	tx, err := storage.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	cupID, err := createCup(ctx, tx, seasonID, opts, teams)
+	if err != nil {
+		return 0, err
+	}
+	return cupID, tx.Commit()
+}
+
+// createCup stores a cup and its first round on a database or transaction. Teams must be
+// ordered by seed.
+func createCup(ctx context.Context, q storage.Querier, seasonID int, opts CupOptions, seeds []contender) (int, error) {
+	res, err := q.ExecContext(ctx, `
+		INSERT INTO cups (season_id, name, seeded, two_legged, away_goals)
+		VALUES (?, ?, ?, ?, ?)
+	`, seasonID, opts.Name, opts.Seeded, opts.TwoLegged, opts.AwayGoals && opts.TwoLegged)
+	if err != nil {
+		return 0, fmt.Errorf("Failed to create cup: %v", err)
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+	cupID := int(id)
+
+	// Pair the seeds so that the top seeds can only meet in the later rounds
+	positions := seedPositions(bracketSize(len(seeds)))
+	for slot := 0; slot < len(positions)/2; slot++ {
+		home, away := positions[2*slot], positions[2*slot+1]
+		if away > len(seeds) {
+			// No opponent: the higher seed goes through on a bye
+			_, err = q.ExecContext(ctx, `
+				INSERT INTO cup_ties (cup_id, round, slot, home_team_id, away_team_id, winner_team_id, decided_by)
+				VALUES (?, 1, ?, ?, NULL, ?, ?)
+			`, cupID, slot, seeds[home-1].ID, seeds[home-1].ID, DecidedByBye)
+		} else {
+			_, err = q.ExecContext(ctx, `
+				INSERT INTO cup_ties (cup_id, round, slot, home_team_id, away_team_id)
+				VALUES (?, 1, ?, ?, ?)
+			`, cupID, slot, seeds[home-1].ID, seeds[away-1].ID)
+		}
+		if err != nil {
+			return 0, fmt.Errorf("Failed to create cup tie: %v", err)
+		}
+	}
+
+	slog.InfoContext(ctx, "Cup drawn", "cup", cupID, "name", opts.Name, "teams", len(seeds))
+	return cupID, nil
+}
+
+// loadContenders reads the power rating of the given teams, or of all active teams when none are given.
+func loadContenders(ctx context.Context, q storage.Querier, teamIDs []int) ([]contender, error) {
+	rows, err := q.QueryContext(ctx, "SELECT id, power, active FROM teams ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	wanted := make(map[int]bool, len(teamIDs))
+	for _, id := range teamIDs {
+		wanted[id] = true
+	}
+
+	var teams []contender
+	for rows.Next() {
+		var t contender
+		var active bool
+		if err := rows.Scan(&t.ID, &t.Power, &active); err != nil {
+			return nil, err
+		}
+		if (len(teamIDs) == 0 && active) || wanted[t.ID] {
+			teams = append(teams, t)
+			delete(wanted, t.ID)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	for id := range wanted {
+		return nil, fmt.Errorf("Team %d does not exist", id)
+	}
+
+	// Keep the caller's order so it can be used as a seeding
+	if len(teamIDs) > 0 {
+		byID := make(map[int]contender, len(teams))
+		for _, t := range teams {
+			byID[t.ID] = t
+		}
+		teams = teams[:0]
+		for _, id := range teamIDs {
+			if t, ok := byID[id]; ok {
+				teams = append(teams, t)
+				delete(byID, id)
+			}
+		}
+	}
+	return teams, nil
+}
+
+// bracketSize returns the smallest power of two that fits the given number of teams.
+func bracketSize(teams int) int {
+	size := 1
+	for size < teams {
+		size *= 2
+	}
+	return size
+}
+
+// seedPositions returns the seeds of a bracket of the given size in draw order.
+// Consecutive pairs meet in the first round, e.g. 1-8, 4-5, 2-7, 3-6 for eight teams.
+func seedPositions(size int) []int {
+	positions := []int{1}
+	for len(positions) < size {
+		n := len(positions) * 2
+		next := make([]int, 0, n)
+		for _, seed := range positions {
+			next = append(next, seed, n+1-seed)
+		}
+		positions = next
+	}
+	return positions
+}
+
+// roundName returns the usual name of a knockout round with the given number of ties.
+func roundName(ties int) string {
+	switch ties {
+	case 1:
+		return "Final"
+	case 2:
+		return "Semi-finals"
+	case 4:
+		return "Quarter-finals"
+	default:
+		return fmt.Sprintf("Round of %d", ties*2)
+	}
+}
+
+// PlayCupRound simulates every undecided tie of the cup's current round and draws
+// the next round from the winners. The cup winner is recorded after the final.
+func PlayCupRound(cupID int) error {
+	return PlayCupRoundContext(context.Background(), cupID)
+}
+
+// PlayCupRoundContext is PlayCupRound with a context. A cancelled context stops the round
+// before its first tie is played, never halfway through. The legs, winners and next round
+// are stored in one transaction.
+func PlayCupRoundContext(ctx context.Context, cupID int) error {
+	var twoLegged, awayGoals bool
+	var winner sql.NullInt64
+	err := storage.DB.QueryRowContext(ctx, `
+		SELECT two_legged, away_goals, winner_team_id FROM cups WHERE id = ?
+	`, cupID).Scan(&twoLegged, &awayGoals, &winner)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("Cup %d does not exist", cupID)
+	}
+	if err != nil {
+		return err
+	}
+	if winner.Valid {
+		return fmt.Errorf("Cup %d has already been completed", cupID)
+	}
+
+	var round int
+	err = storage.DB.QueryRowContext(ctx, "SELECT MAX(round) FROM cup_ties WHERE cup_id = ?", cupID).Scan(&round)
+	if err != nil {
+		return err
+	}
+
+	// A single-match final is played on neutral ground
+	var ties int
+	err = storage.DB.QueryRowContext(ctx, "SELECT COUNT(*) FROM cup_ties WHERE cup_id = ? AND round = ?", cupID, round).Scan(&ties)
+	if err != nil {
+		return err
+	}
+
+	// Only the read of the ties is cancelled, so a round that has begun is committed in full
+	tx, err := storage.DB.BeginTx(context.WithoutCancel(ctx), nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	rules := tieRules{TwoLegged: twoLegged, AwayGoals: awayGoals, Neutral: ties == 1}
+	if err := playKnockoutRound(ctx, tx, cupID, round, rules); err != nil {
+		return err
+	}
+
+	// The round has been played, so the winners go through even when ctx is cancelled
+	if err := advanceCup(context.WithoutCancel(ctx), tx, cupID, round); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// playKnockoutRound simulates the undecided ties of a cup round and stores their legs on a
+// database or transaction. Once the ties have been read the round is played to the end even
+// when ctx is cancelled.
+func playKnockoutRound(ctx context.Context, q storage.Querier, cupID, round int, rules tieRules) error {
+	rows, err := q.QueryContext(ctx, `
+		SELECT ct.id, ht.id, ht.power, at.id, at.power
+		FROM cup_ties ct
+		JOIN teams ht ON ct.home_team_id = ht.id
+		JOIN teams at ON ct.away_team_id = at.id
+		WHERE ct.cup_id = ? AND ct.round = ? AND ct.winner_team_id IS NULL
+		ORDER BY ct.slot
+	`, cupID, round)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	type tie struct {
+		ID   int
+		Home contender
+		Away contender
+	}
+	var ties []tie
+	for rows.Next() {
+		var t tie
+		if err := rows.Scan(&t.ID, &t.Home.ID, &t.Home.Power, &t.Away.ID, &t.Away.Power); err != nil {
+			return err
+		}
+		ties = append(ties, t)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	// From here on the context only carries the request ID into the log
+	ctx = context.WithoutCancel(ctx)
+
+	for _, t := range ties {
+		first, second := t.Home, t.Away
+		if rules.TwoLegged && rules.SeedLast {
+			first, second = second, first
+		}
+		legs, winner, decidedBy := playTie(first, second, rules)
+		for i, leg := range legs {
+			if err := saveCupLeg(ctx, q, t.ID, i+1, leg); err != nil {
+				return err
+			}
+			matchesSimulated.With(competitionCup).Inc()
+		}
+		_, err := q.ExecContext(ctx, `
+			UPDATE cup_ties SET winner_team_id = ?, decided_by = ? WHERE id = ?
+		`, winner, decidedBy, t.ID)
+		if err != nil {
+			return fmt.Errorf("Failed to update cup tie %d: %v", t.ID, err)
+		}
+		slog.DebugContext(ctx, "Cup tie decided", "tie", t.ID, "decided_by", decidedBy, "winner", winner)
+	}
+	if len(ties) > 0 {
+		simulationsRun.With(competitionCup).Inc()
+	}
+	return nil
+}
+
+// saveCupLeg stores one played leg of a cup tie on a database or transaction.
+func saveCupLeg(ctx context.Context, q storage.Querier, tieID, leg int, result legResult) error {
+	var homePens, awayPens interface{}
+	if result.Shootout {
+		homePens, awayPens = result.HomePens, result.AwayPens
+	}
+	_, err := q.ExecContext(ctx, `
+		INSERT INTO cup_legs (tie_id, leg, home_team_id, away_team_id, home_goals, away_goals,
+		                      extra_time, home_penalties, away_penalties)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, tieID, leg, result.HomeID, result.AwayID, result.HomeGoals, result.AwayGoals,
+		result.ExtraTime, homePens, awayPens)
+	if err != nil {
+		return fmt.Errorf("Failed to save leg %d of cup tie %d: %v", leg, tieID, err)
+	}
+	return nil
+}
+
+// advanceCup draws the next round from the winners of a completed round, or records the cup
+// winner when the final has been played, on a database or transaction.
+func advanceCup(ctx context.Context, q storage.Querier, cupID, round int) error {
+	rows, err := q.QueryContext(ctx, `
+		SELECT slot, winner_team_id FROM cup_ties
+		WHERE cup_id = ? AND round = ?
+		ORDER BY slot
+	`, cupID, round)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var winners []int
+	for rows.Next() {
+		var slot int
+		var winner sql.NullInt64
+		if err := rows.Scan(&slot, &winner); err != nil {
+			return err
+		}
+		if !winner.Valid {
+			return fmt.Errorf("Round %d of cup %d is not finished", round, cupID)
+		}
+		winners = append(winners, int(winner.Int64))
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	if len(winners) == 1 {
+		_, err := q.ExecContext(ctx, "UPDATE cups SET winner_team_id = ? WHERE id = ?", winners[0], cupID)
+		return err
+	}
+
+	// Winners of neighbouring ties meet in the next round
+	for slot := 0; slot < len(winners)/2; slot++ {
+		_, err := q.ExecContext(ctx, `
+			INSERT INTO cup_ties (cup_id, round, slot, home_team_id, away_team_id)
+			VALUES (?, ?, ?, ?, ?)
+		`, cupID, round+1, slot, winners[2*slot], winners[2*slot+1])
+		if err != nil {
+			return fmt.Errorf("Failed to draw round %d of cup %d: %v", round+1, cupID, err)
+		}
+	}
+	return nil
+}
+
+// GetCupBracket returns a cup with all of its drawn rounds, ties and legs.
+func GetCupBracket(cupID int) (models.CupBracket, error) {
+	return GetCupBracketContext(context.Background(), cupID)
+}
+
+// GetCupBracketContext is GetCupBracket with a context that cancels the queries.
+func GetCupBracketContext(ctx context.Context, cupID int) (models.CupBracket, error) {
+	var bracket models.CupBracket
+	var winnerID sql.NullInt64
+	var winnerName sql.NullString
+	err := storage.DB.QueryRowContext(ctx, `
+		SELECT c.id, c.season_id, c.name, c.seeded, c.two_legged, c.away_goals, c.winner_team_id, t.name
+		FROM cups c
+		LEFT JOIN teams t ON c.winner_team_id = t.id
+		WHERE c.id = ?
+	`, cupID).Scan(&bracket.ID, &bracket.SeasonID, &bracket.Name, &bracket.Seeded,
+		&bracket.TwoLegged, &bracket.AwayGoals, &winnerID, &winnerName)
+	if err == sql.ErrNoRows {
+		return bracket, fmt.Errorf("Cup %d does not exist", cupID)
+	}
+	if err != nil {
+		return bracket, err
+	}
+	bracket.WinnerTeamID = int(winnerID.Int64)
+	bracket.WinnerName = winnerName.String
+
+	ties, err := loadCupTies(ctx, cupID)
+	if err != nil {
+		return bracket, err
+	}
+
+	// Group the ties into rounds
+	for _, tie := range ties {
+		if len(bracket.Rounds) < tie.Round {
+			bracket.Rounds = append(bracket.Rounds, models.CupRound{Round: tie.Round})
+		}
+		r := &bracket.Rounds[tie.Round-1]
+		r.Ties = append(r.Ties, tie)
+	}
+	for i := range bracket.Rounds {
+		bracket.Rounds[i].Name = roundName(len(bracket.Rounds[i].Ties))
+	}
+	if len(bracket.Rounds) > 0 {
+		for teams := len(bracket.Rounds[0].Ties) * 2; teams > 1; teams /= 2 {
+			bracket.TotalRounds++
+		}
+	}
+	return bracket, nil
+}
+
+// loadCupTies reads the ties of a cup ordered by round and slot, including their legs.
+func loadCupTies(ctx context.Context, cupID int) ([]models.CupTie, error) {
+	rows, err := storage.DB.QueryContext(ctx, `
+		SELECT ct.id, ct.round, ct.slot, ct.home_team_id, ht.name,
+		       COALESCE(ct.away_team_id, 0), COALESCE(at.name, ''),
+		       COALESCE(ct.winner_team_id, 0), COALESCE(ct.decided_by, '')
+		FROM cup_ties ct
+		JOIN teams ht ON ct.home_team_id = ht.id
+		LEFT JOIN teams at ON ct.away_team_id = at.id
+		WHERE ct.cup_id = ?
+		ORDER BY ct.round, ct.slot
+	`, cupID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ties []models.CupTie
+	index := make(map[int]int)
+	for rows.Next() {
+		var t models.CupTie
+		err := rows.Scan(&t.ID, &t.Round, &t.Slot, &t.HomeTeamID, &t.HomeTeamName,
+			&t.AwayTeamID, &t.AwayTeamName, &t.WinnerTeamID, &t.DecidedBy)
+		if err != nil {
+			return nil, err
+		}
+		t.Legs = []models.CupLeg{}
+		index[t.ID] = len(ties)
+		ties = append(ties, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	legRows, err := storage.DB.QueryContext(ctx, `
+		SELECT l.tie_id, l.leg, l.home_team_id, ht.name, l.away_team_id, at.name,
+		       l.home_goals, l.away_goals, l.extra_time, l.home_penalties, l.away_penalties
+		FROM cup_legs l
+		JOIN cup_ties ct ON l.tie_id = ct.id
+		JOIN teams ht ON l.home_team_id = ht.id
+		JOIN teams at ON l.away_team_id = at.id
+		WHERE ct.cup_id = ?
+		ORDER BY l.tie_id, l.leg
+	`, cupID)
+	if err != nil {
+		return nil, err
+	}
+	defer legRows.Close()
+
+	for legRows.Next() {
+		var tieID int
+		var l models.CupLeg
+		err := legRows.Scan(&tieID, &l.Leg, &l.HomeTeamID, &l.HomeTeamName, &l.AwayTeamID, &l.AwayTeamName,
+			&l.HomeGoals, &l.AwayGoals, &l.ExtraTime, &l.HomePenalties, &l.AwayPenalties)
+		if err != nil {
+			return nil, err
+		}
+		t := &ties[index[tieID]]
+		t.Legs = append(t.Legs, l)
+	}
+	return ties, legRows.Err()
+}
@@ -0,0 +1,266 @@
+package league
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+
+	models "go-football-league/internal/domain"
+	storage "go-football-league/internal/repository"
+)
+
+// Disciplinary rules applied whenever a card is recorded.
+const (
+	YellowCardThreshold = 5 // Every 5th yellow card of a season triggers a ban
+	AccumulationBan     = 1 // League matches of the team missed after reaching the yellow card threshold
+	StraightRedBan      = 1 // League matches of the team missed after a red card
+
+	yellowCardChance = 0.08  // Per player, per simulated match
+	redCardChance    = 0.005 // Per player, per simulated match
+
+	yellowCardPoints = 1 // Fair-play points per yellow card
+	redCardPoints    = 3 // Fair-play points per red card
+)
+
+// simulateCards randomly books players who took part in a match and records the resulting cards.
+func simulateCards(ctx context.Context, matchID int, players []int) error {
+	for _, playerID := range players {
+		roll := random.Float64()
+		card := ""
+		if roll < redCardChance {
+			card = "R"
+		} else if roll < redCardChance+yellowCardChance {
+			card = "Y"
+		}
+		if card == "" {
+			continue
+		}
+		if err := RecordCardContext(ctx, matchID, playerID, card, random.Intn(90)+1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RecordCard stores a card shown to a player and applies the suspension rules.
+// A straight red card always results in a ban, and every YellowCardThreshold-th
+// yellow card of the season results in an accumulation ban.
+func RecordCard(matchID, playerID int, card string, minute int) error {
+	return RecordCardContext(context.Background(), matchID, playerID, card, minute)
+}
+
+// RecordCardContext is RecordCard with a context that cancels the queries. The card and
+// the ban it leads to are stored in one transaction.
+func RecordCardContext(ctx context.Context, matchID, playerID int, card string, minute int) error {
+	if card != "Y" && card != "R" {
+		return fmt.Errorf("Invalid card %q: expected Y or R", card)
+	}
+
+	tx, err := storage.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var seasonID, week, teamID int
+	err = tx.QueryRowContext(ctx, `
+		SELECT m.season_id, m.week, p.team_id
+		FROM matches m
+		JOIN players p ON p.id = ?
+		WHERE m.id = ? AND p.team_id IN (m.home_team_id, m.away_team_id)
+	`, playerID, matchID).Scan(&seasonID, &week, &teamID)
+	if err != nil {
+		return fmt.Errorf("Player %d is not part of match %d: %v", playerID, matchID, err)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO match_cards (match_id, player_id, team_id, card, minute)
+		VALUES (?, ?, ?, ?, ?)
+	`, matchID, playerID, teamID, card, minute)
+	if err != nil {
+		return fmt.Errorf("Failed to record card: %v", err)
+	}
+
+	if card == "R" {
+		if err := suspendPlayer(ctx, tx, seasonID, playerID, matchID, "Red card", week+1, StraightRedBan); err != nil {
+			return err
+		}
+		return tx.Commit()
+	}
+
+	// Count the season's yellow cards to check for accumulation
+	var yellows int
+	err = tx.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM match_cards c
+		JOIN matches m ON c.match_id = m.id
+		WHERE c.player_id = ? AND c.card = 'Y' AND m.season_id = ?
+	`, playerID, seasonID).Scan(&yellows)
+	if err != nil {
+		return err
+	}
+	if yellows%YellowCardThreshold == 0 {
+		reason := fmt.Sprintf("%d yellow cards", yellows)
+		if err := suspendPlayer(ctx, tx, seasonID, playerID, matchID, reason, week+1, AccumulationBan); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// suspendPlayer records a ban for the given number of matches starting from startWeek.
+// Matches of the player's team from that week on that already have a result count as
+// served; the serve_suspensions trigger counts the ones completed later.
+func suspendPlayer(ctx context.Context, q storage.Querier, seasonID, playerID, matchID int, reason string, startWeek, matches int) error {
+	_, err := q.ExecContext(ctx, `
+		INSERT INTO suspensions (season_id, player_id, match_id, reason, start_week, matches, served)
+		SELECT ?, ?, ?, ?, ?, ?, MIN(?, COUNT(*))
+		FROM matches m JOIN players p ON p.id = ?
+		WHERE m.season_id = ? AND m.status IN ('played', 'awarded')
+		  AND p.team_id IN (m.home_team_id, m.away_team_id) AND m.week >= ?
+	`, seasonID, playerID, matchID, reason, startWeek, matches, matches, playerID, seasonID, startWeek)
+	if err != nil {
+		return fmt.Errorf("Failed to suspend player %d: %v", playerID, err)
+	}
+	slog.InfoContext(ctx, "Player suspended", "player", playerID, "matches", matches, "reason", reason)
+	return nil
+}
+
+// GetSuspensions returns every suspension handed out in a season, ordered by the week it starts.
+func GetSuspensions(seasonID int) ([]models.Suspension, error) {
+	return GetSuspensionsContext(context.Background(), seasonID)
+}
+
+// GetSuspensionsContext is GetSuspensions with a context that cancels the query.
+func GetSuspensionsContext(ctx context.Context, seasonID int) ([]models.Suspension, error) {
+	rows, err := storage.DB.QueryContext(ctx, `
+		SELECT s.id, s.season_id, s.player_id, p.name, p.team_id, t.name,
+		       s.match_id, s.reason, s.start_week, s.matches, s.served
+		FROM suspensions s
+		JOIN players p ON s.player_id = p.id
+		JOIN teams t ON p.team_id = t.id
+		WHERE s.season_id = ?
+		ORDER BY s.start_week, s.id
+	`, seasonID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	suspensions := []models.Suspension{}
+	for rows.Next() {
+		var s models.Suspension
+		err := rows.Scan(&s.ID, &s.SeasonID, &s.PlayerID, &s.PlayerName, &s.TeamID, &s.TeamName,
+			&s.MatchID, &s.Reason, &s.StartWeek, &s.Matches, &s.Served)
+		if err != nil {
+			return nil, err
+		}
+		suspensions = append(suspensions, s)
+	}
+	return suspensions, rows.Err()
+}
+
+// GetCardsByMatch returns the cards shown in a match in the order they were given.
+func GetCardsByMatch(matchID int) ([]models.Card, error) {
+	return GetCardsByMatchContext(context.Background(), matchID)
+}
+
+// GetCardsByMatchContext is GetCardsByMatch with a context that cancels the query.
+func GetCardsByMatchContext(ctx context.Context, matchID int) ([]models.Card, error) {
+	rows, err := storage.DB.QueryContext(ctx, `
+		SELECT c.id, c.match_id, c.player_id, p.name, c.team_id, c.card, c.minute
+		FROM match_cards c
+		JOIN players p ON c.player_id = p.id
+		WHERE c.match_id = ?
+		ORDER BY c.minute, c.id
+	`, matchID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	cards := []models.Card{}
+	for rows.Next() {
+		var c models.Card
+		if err := rows.Scan(&c.ID, &c.MatchID, &c.PlayerID, &c.PlayerName, &c.TeamID, &c.Card, &c.Minute); err != nil {
+			return nil, err
+		}
+		cards = append(cards, c)
+	}
+	return cards, rows.Err()
+}
+
+// GenerateFairPlayTable computes the disciplinary standings of the current season up to the given week.
+// Yellow cards count one point and red cards three; the team with the fewest points tops the table.
+func GenerateFairPlayTable(upToWeek int) ([]models.FairPlayRow, error) {
+	return GenerateFairPlayTableContext(context.Background(), upToWeek)
+}
+
+// GenerateFairPlayTableContext is GenerateFairPlayTable with a context that cancels the queries.
+func GenerateFairPlayTableContext(ctx context.Context, upToWeek int) ([]models.FairPlayRow, error) {
+	seasonID, err := CurrentSeasonIDContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return seasonFairPlayTable(ctx, seasonID, upToWeek)
+}
+
+// seasonFairPlayTable computes the disciplinary standings of any season up to the given week.
+func seasonFairPlayTable(ctx context.Context, seasonID, upToWeek int) ([]models.FairPlayRow, error) {
+	// Every team that has played gets a row, even with a clean record
+	rows, err := storage.DB.QueryContext(ctx, `
+		SELECT t.id, t.name,
+		       COUNT(CASE WHEN c.card = 'Y' THEN 1 END),
+		       COUNT(CASE WHEN c.card = 'R' THEN 1 END)
+		FROM teams t
+		JOIN (
+		    SELECT id, home_team_id AS team_id FROM matches
+		    WHERE season_id = ? AND week <= ? AND home_goals IS NOT NULL
+		    UNION
+		    SELECT id, away_team_id FROM matches
+		    WHERE season_id = ? AND week <= ? AND home_goals IS NOT NULL
+		) m ON m.team_id = t.id
+		LEFT JOIN match_cards c ON c.match_id = m.id AND c.team_id = t.id
+		GROUP BY t.id, t.name
+	`, seasonID, upToWeek, seasonID, upToWeek)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var table []models.FairPlayRow
+	for rows.Next() {
+		var row models.FairPlayRow
+		if err := rows.Scan(&row.TeamID, &row.TeamName, &row.YellowCards, &row.RedCards); err != nil {
+			return nil, err
+		}
+		row.Points = row.YellowCards*yellowCardPoints + row.RedCards*redCardPoints
+		table = append(table, row)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// Sort the table: fewest points first, then by name for a stable order
+	sort.SliceStable(table, func(i, j int) bool {
+		if table[i].Points != table[j].Points {
+			return table[i].Points < table[j].Points
+		}
+		return table[i].TeamName < table[j].TeamName
+	})
+	return table, nil
+}
+
+// fairPlayPoints returns the fair-play points of each team in a season, keyed by team ID.
+// It is used as the final tie-breaker of the league table.
+func fairPlayPoints(ctx context.Context, seasonID, upToWeek int) (map[int]int, error) {
+	table, err := seasonFairPlayTable(ctx, seasonID, upToWeek)
+	if err != nil {
+		return nil, err
+	}
+	points := make(map[int]int, len(table))
+	for _, row := range table {
+		points[row.TeamID] = row.Points
+	}
+	return points, nil
+}
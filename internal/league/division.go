@@ -0,0 +1,256 @@
+package league
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	models "go-football-league/internal/domain"
+	storage "go-football-league/internal/repository"
+)
+
+// topDivisionID returns the ID of the division at the top of the pyramid.
+func topDivisionID(ctx context.Context) (int, error) {
+	var id int
+	err := storage.DB.QueryRowContext(ctx, "SELECT id FROM divisions ORDER BY tier LIMIT 1").Scan(&id)
+	if err == sql.ErrNoRows {
+		return 0, errors.New("No divisions have been created")
+	}
+	return id, err
+}
+
+// GetDivisions returns every division of the pyramid from the top tier down, including their teams.
+func GetDivisions() ([]models.Division, error) {
+	return GetDivisionsContext(context.Background())
+}
+
+// GetDivisionsContext is GetDivisions with a context that cancels the queries.
+func GetDivisionsContext(ctx context.Context) ([]models.Division, error) {
+	rows, err := storage.DB.QueryContext(ctx, `
+		SELECT id, name, tier, promotion_places, relegation_places, playoff_places
+		FROM divisions
+		ORDER BY tier
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	divisions := []models.Division{}
+	index := make(map[int]int)
+	for rows.Next() {
+		var d models.Division
+		if err := rows.Scan(&d.ID, &d.Name, &d.Tier, &d.PromotionPlaces, &d.RelegationPlaces, &d.PlayoffPlaces); err != nil {
+			return nil, err
+		}
+		d.Teams = []models.Team{}
+		index[d.ID] = len(divisions)
+		divisions = append(divisions, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	teams, err := GetTeamsContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range teams {
+		if i, ok := index[t.DivisionID]; ok {
+			divisions[i].Teams = append(divisions[i].Teams, t)
+		}
+	}
+	return divisions, nil
+}
+
+// getDivision returns the settings of a single division, without its teams.
+func getDivision(ctx context.Context, divisionID int) (models.Division, error) {
+	var d models.Division
+	err := storage.DB.QueryRowContext(ctx, `
+		SELECT id, name, tier, promotion_places, relegation_places, playoff_places
+		FROM divisions WHERE id = ?
+	`, divisionID).Scan(&d.ID, &d.Name, &d.Tier, &d.PromotionPlaces, &d.RelegationPlaces, &d.PlayoffPlaces)
+	if err == sql.ErrNoRows {
+		return d, fmt.Errorf("Division %d does not exist", divisionID)
+	}
+	return d, err
+}
+
+// CreateDivision adds a division to the pyramid and returns its ID.
+// Promotion places are ignored for the top tier and relegation places for the bottom one.
+func CreateDivision(d models.Division) (int, error) {
+	return CreateDivisionContext(context.Background(), d)
+}
+
+// CreateDivisionContext is CreateDivision with a context that cancels the insert.
+func CreateDivisionContext(ctx context.Context, d models.Division) (int, error) {
+	if err := validateDivision(d, 0); err != nil {
+		return 0, err
+	}
+
+	res, err := storage.DB.ExecContext(ctx, `
+		INSERT INTO divisions (name, tier, promotion_places, relegation_places, playoff_places)
+		VALUES (?, ?, ?, ?, ?)
+	`, d.Name, d.Tier, d.PromotionPlaces, d.RelegationPlaces, d.PlayoffPlaces)
+	if err != nil {
+		return 0, fmt.Errorf("Failed to create division: %v", err)
+	}
+	id, err := res.LastInsertId()
+	return int(id), err
+}
+
+// UpdateDivision changes the name, tier and promotion and relegation places of a division.
+func UpdateDivision(d models.Division) error {
+	return UpdateDivisionContext(context.Background(), d)
+}
+
+// UpdateDivisionContext is UpdateDivision with a context that cancels the queries.
+func UpdateDivisionContext(ctx context.Context, d models.Division) error {
+	var teams int
+	err := storage.DB.QueryRowContext(ctx, "SELECT COUNT(*) FROM teams WHERE division_id = ? AND active = 1", d.ID).Scan(&teams)
+	if err != nil {
+		return err
+	}
+	if err := validateDivision(d, teams); err != nil {
+		return err
+	}
+
+	res, err := storage.DB.ExecContext(ctx, `
+		UPDATE divisions
+		SET name = ?, tier = ?, promotion_places = ?, relegation_places = ?, playoff_places = ?
+		WHERE id = ?
+	`, d.Name, d.Tier, d.PromotionPlaces, d.RelegationPlaces, d.PlayoffPlaces, d.ID)
+	if err != nil {
+		return fmt.Errorf("Failed to update division: %v", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("Division %d does not exist", d.ID)
+	}
+	return nil
+}
+
+// validateDivision checks the settings of a division with the given number of active teams
+// before it is stored. The playoff places must form a knockout bracket without byes and fit
+// below the automatic promotion places; a division without teams yet, such as a new one,
+// is only checked for that when its playoffs are drawn.
+func validateDivision(d models.Division, teams int) error {
+	if strings.TrimSpace(d.Name) == "" {
+		return errors.New("Division name is required")
+	}
+	if d.Tier < 1 {
+		return errors.New("Division tier must be 1 or higher")
+	}
+	if d.PromotionPlaces < 0 || d.RelegationPlaces < 0 || d.PlayoffPlaces < 0 {
+		return errors.New("Promotion, relegation and playoff places cannot be negative")
+	}
+	if d.PlayoffPlaces != 0 && (d.PlayoffPlaces < 2 || d.PlayoffPlaces&(d.PlayoffPlaces-1) != 0) {
+		return errors.New("Playoff places must be 0 or a power of two such as 2, 4 or 8")
+	}
+	if teams > 0 && d.PlayoffPlaces > teams-d.PromotionPlaces {
+		return fmt.Errorf("%s has %d teams, room for at most %d playoff places below its %d promotion places",
+			d.Name, teams, max(teams-d.PromotionPlaces, 0), d.PromotionPlaces)
+	}
+	return nil
+}
+
+// GetTeams returns all active teams ordered by division tier and name.
+func GetTeams() ([]models.Team, error) {
+	return GetTeamsContext(context.Background())
+}
+
+// GetTeamsContext is GetTeams with a context that cancels the query.
+func GetTeamsContext(ctx context.Context) ([]models.Team, error) {
+	rows, err := storage.DB.QueryContext(ctx, `
+		SELECT t.id, t.name, t.power, t.division_id, COALESCE(t.venue_id, 0)
+		FROM teams t
+		JOIN divisions d ON t.division_id = d.id
+		WHERE t.active = 1
+		ORDER BY d.tier, t.name
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	teams := []models.Team{}
+	for rows.Next() {
+		var t models.Team
+		if err := rows.Scan(&t.ID, &t.Name, &t.Power, &t.DivisionID, &t.VenueID); err != nil {
+			return nil, err
+		}
+		teams = append(teams, t)
+	}
+	return teams, rows.Err()
+}
+
+// CreateTeam adds a team to a division and returns its ID.
+// The team takes part in the league from the next fixture generation onwards.
+func CreateTeam(name string, power, divisionID int) (int, error) {
+	return CreateTeamContext(context.Background(), name, power, divisionID)
+}
+
+// CreateTeamContext is CreateTeam with a context that cancels the queries.
+func CreateTeamContext(ctx context.Context, name string, power, divisionID int) (int, error) {
+	if err := validateTeam(name, power); err != nil {
+		return 0, err
+	}
+	if err := checkDivision(ctx, divisionID); err != nil {
+		return 0, err
+	}
+
+	res, err := storage.DB.ExecContext(ctx, `
+		INSERT INTO teams (name, power, division_id) VALUES (?, ?, ?)
+	`, name, power, divisionID)
+	if err != nil {
+		return 0, fmt.Errorf("Failed to create team: %v", err)
+	}
+	id, err := res.LastInsertId()
+	return int(id), err
+}
+
+// validateTeam checks the name and power rating of a new team.
+func validateTeam(name string, power int) error {
+	if strings.TrimSpace(name) == "" {
+		return errors.New("Team name is required")
+	}
+	if power < 1 || power > 100 {
+		return errors.New("Team power must be between 1 and 100")
+	}
+	return nil
+}
+
+// AssignTeamDivision moves a team to another division.
+// Fixtures that already exist are not changed.
+func AssignTeamDivision(teamID, divisionID int) error {
+	return AssignTeamDivisionContext(context.Background(), teamID, divisionID)
+}
+
+// AssignTeamDivisionContext is AssignTeamDivision with a context that cancels the queries.
+func AssignTeamDivisionContext(ctx context.Context, teamID, divisionID int) error {
+	if err := checkDivision(ctx, divisionID); err != nil {
+		return err
+	}
+	res, err := storage.DB.ExecContext(ctx, "UPDATE teams SET division_id = ? WHERE id = ?", divisionID, teamID)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("Team %d does not exist", teamID)
+	}
+	return nil
+}
+
+// checkDivision returns an error if the division does not exist.
+func checkDivision(ctx context.Context, divisionID int) error {
+	var exists bool
+	err := storage.DB.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM divisions WHERE id = ?)", divisionID).Scan(&exists)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return fmt.Errorf("Division %d does not exist", divisionID)
+	}
+	return nil
+}
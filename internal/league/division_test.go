@@ -0,0 +1,39 @@
+package league
+
+import (
+	"testing"
+
+	models "go-football-league/internal/domain"
+)
+
+func TestValidateDivision(t *testing.T) {
+	tests := []struct {
+		name      string
+		promotion int
+		playoff   int
+		teams     int
+		wantErr   bool
+	}{
+		{name: "no playoffs", promotion: 2, teams: 20},
+		{name: "two playoff places", promotion: 2, playoff: 2, teams: 20},
+		{name: "four playoff places", promotion: 2, playoff: 4, teams: 20},
+		{name: "eight playoff places", playoff: 8, teams: 8},
+		{name: "one playoff place", playoff: 1, teams: 20, wantErr: true},
+		{name: "three playoff places", playoff: 3, teams: 20, wantErr: true},
+		{name: "six playoff places", playoff: 6, teams: 20, wantErr: true},
+		{name: "negative playoff places", playoff: -2, teams: 20, wantErr: true},
+		{name: "playoffs filling the division", promotion: 2, playoff: 4, teams: 6},
+		{name: "more playoff places than teams below promotion", promotion: 2, playoff: 4, teams: 5, wantErr: true},
+		{name: "promotion places filling the division", promotion: 4, playoff: 2, teams: 4, wantErr: true},
+		{name: "new division without teams", promotion: 2, playoff: 8},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := models.Division{Name: "Championship", Tier: 2, PromotionPlaces: tt.promotion, PlayoffPlaces: tt.playoff}
+			err := validateDivision(d, tt.teams)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("got error %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,183 @@
+package league
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"math"
+	"strings"
+	"time"
+
+	models "go-football-league/internal/domain"
+	storage "go-football-league/internal/repository"
+)
+
+// Fatigue and injury model parameters.
+const (
+	daysPerWeek       = 7    // Days between two consecutive match weeks
+	recoveryDays      = 4.0  // Match load decays by a factor of e every 4 days of rest
+	fatigueCapacity   = 90.0 // Remaining load at which a player counts as fully fatigued
+	maxFatiguePenalty = 0.2  // A fully fatigued eleven loses 20% of its strength
+
+	injuryChance        = 0.01 // Per player, per 90 minutes played while fully fresh
+	fatigueInjuryFactor = 2.0  // A fully fatigued player is three times as likely to get injured
+	maxInjuryWeeks      = 6
+)
+
+// injuryTypes are the descriptions randomly assigned to simulated injuries.
+var injuryTypes = []string{
+	"Hamstring strain",
+	"Ankle sprain",
+	"Knee ligament damage",
+	"Groin strain",
+	"Calf strain",
+	"Concussion",
+	"Muscle fatigue",
+}
+
+// daysBetweenWeeks returns the number of rest days between matches of two weeks.
+func daysBetweenWeeks(from, to int) int {
+	return (to - from) * daysPerWeek
+}
+
+// restDays returns the days of rest between two matches. Kickoff times are used when
+// both are known, otherwise the distance between their weeks.
+func restDays(fromWeek int, from sql.NullString, toWeek int, to time.Time) (float64, error) {
+	if !from.Valid || to.IsZero() {
+		return float64(daysBetweenWeeks(fromWeek, toWeek)), nil
+	}
+	played, err := parseKickoff(from, time.UTC)
+	if err != nil {
+		return 0, err
+	}
+	return to.Sub(played).Hours() / 24, nil
+}
+
+// playerFatigue computes each player's fatigue before a match in the given week kicking off at kickoff.
+// Minutes played in earlier matches of the season add load that decays with the
+// days of rest since; the result is between 0 (fresh) and 1 (exhausted).
+func playerFatigue(ctx context.Context, seasonID, week int, kickoff time.Time, players []int) (map[int]float64, error) {
+	fatigue := make(map[int]float64, len(players))
+	if len(players) == 0 {
+		return fatigue, nil
+	}
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(players)), ",")
+	args := []interface{}{seasonID, week}
+	for _, id := range players {
+		args = append(args, id)
+	}
+	rows, err := storage.DB.QueryContext(ctx, `
+		SELECT l.player_id, l.minutes, m.week, m.kickoff
+		FROM match_lineups l
+		JOIN matches m ON l.match_id = m.id
+		WHERE m.season_id = ? AND m.week < ? AND l.player_id IN (`+placeholders+`)
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	load := make(map[int]float64, len(players))
+	for rows.Next() {
+		var playerID, minutes, playedWeek int
+		var playedKickoff sql.NullString
+		if err := rows.Scan(&playerID, &minutes, &playedWeek, &playedKickoff); err != nil {
+			return nil, err
+		}
+		rest, err := restDays(playedWeek, playedKickoff, week, kickoff)
+		if err != nil {
+			return nil, err
+		}
+		load[playerID] += float64(minutes) * math.Exp(-rest/recoveryDays)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, id := range players {
+		fatigue[id] = math.Min(1, load[id]/fatigueCapacity)
+	}
+	return fatigue, nil
+}
+
+// teamFatigue returns the average fatigue of a team's starting eleven.
+func teamFatigue(fatigue map[int]float64, starters []int) float64 {
+	if len(starters) == 0 {
+		return 0
+	}
+	total := 0.0
+	for _, id := range starters {
+		total += fatigue[id]
+	}
+	return total / float64(len(starters))
+}
+
+// applyFatigue reduces a power rating according to the team's fatigue.
+func applyFatigue(power int, fatigue float64) int {
+	return int(math.Round(float64(power) * (1 - maxFatiguePenalty*fatigue)))
+}
+
+// simulateInjuries randomly injures players who took part in a match.
+// Tired players and players with more minutes are more likely to get injured.
+// An injury keeps the player out from the following week for 1 to maxInjuryWeeks weeks.
+func simulateInjuries(ctx context.Context, matchID, seasonID, week int, minutes []appearance, fatigue map[int]float64) error {
+	for _, a := range minutes {
+		chance := injuryChance * float64(a.Minutes) / 90 * (1 + fatigueInjuryFactor*fatigue[a.PlayerID])
+		if random.Float64() >= chance {
+			continue
+		}
+		description := injuryTypes[random.Intn(len(injuryTypes))]
+		weeksOut := random.Intn(maxInjuryWeeks) + 1
+		_, err := storage.DB.ExecContext(ctx, `
+			INSERT INTO injuries (season_id, player_id, match_id, description, start_week, weeks_out)
+			VALUES (?, ?, ?, ?, ?, ?)
+		`, seasonID, a.PlayerID, matchID, description, week+1, weeksOut)
+		if err != nil {
+			return fmt.Errorf("Failed to record injury for player %d: %v", a.PlayerID, err)
+		}
+		slog.InfoContext(ctx, "Player injured", "player", a.PlayerID, "injury", description, "weeks_out", weeksOut)
+	}
+	return nil
+}
+
+// GetTeamInjuries returns the injuries of a team's players in the current season.
+// If week is greater than zero, only players who are out in that week are returned.
+func GetTeamInjuries(teamID, week int) ([]models.Injury, error) {
+	return GetTeamInjuriesContext(context.Background(), teamID, week)
+}
+
+// GetTeamInjuriesContext is GetTeamInjuries with a context that cancels the queries.
+func GetTeamInjuriesContext(ctx context.Context, teamID, week int) ([]models.Injury, error) {
+	seasonID, err := CurrentSeasonIDContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := storage.DB.QueryContext(ctx, `
+		SELECT i.id, i.season_id, i.player_id, p.name, p.team_id, i.match_id,
+		       i.description, i.start_week, i.weeks_out
+		FROM injuries i
+		JOIN players p ON i.player_id = p.id
+		WHERE p.team_id = ? AND i.season_id = ?
+		  AND (? <= 0 OR (? >= i.start_week AND ? < i.start_week + i.weeks_out))
+		ORDER BY i.start_week, i.id
+	`, teamID, seasonID, week, week, week)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	injuries := []models.Injury{}
+	for rows.Next() {
+		var i models.Injury
+		err := rows.Scan(&i.ID, &i.SeasonID, &i.PlayerID, &i.PlayerName, &i.TeamID, &i.MatchID,
+			&i.Description, &i.StartWeek, &i.WeeksOut)
+		if err != nil {
+			return nil, err
+		}
+		injuries = append(injuries, i)
+	}
+	return injuries, rows.Err()
+}
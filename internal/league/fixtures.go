@@ -0,0 +1,88 @@
+package league
+
+// fixtureMatch is one match of a generated fixture list.
+type fixtureMatch struct {
+	Week int
+	Home int
+	Away int
+}
+
+// singleRoundRobin pairs every team with every other team exactly once using the
+// circle method: the last team keeps its place while the others rotate one place per
+// round. Each slice holds the matches of one round. With an odd number of teams a bye
+// takes the fixed place, so the team drawn against it sits the round out. The fixed team
+// alternates between home and away, and every other pair is turned around by its distance
+// on the circle, so no team plays more than two consecutive home or away matches.
+func singleRoundRobin(teamIDs []int) [][]fixtureMatch {
+	const bye = 0
+
+	teams := append([]int{}, teamIDs...)
+	if len(teams)%2 == 1 {
+		teams = append(teams, bye)
+	}
+	n := len(teams)
+	circle := n - 1 // Teams rotating around the fixed last one
+
+	var rounds [][]fixtureMatch
+	for r := 0; r < circle; r++ {
+		// The fixed team meets the team at position r, the others pair up around it
+		home, away := teams[r], teams[circle]
+		if r%2 == 1 {
+			home, away = away, home
+		}
+		pairs := [][2]int{{home, away}}
+		for k := 1; k < n/2; k++ {
+			home, away := teams[(r+k)%circle], teams[(r-k+circle)%circle]
+			if k%2 == 0 {
+				home, away = away, home
+			}
+			pairs = append(pairs, [2]int{home, away})
+		}
+
+		round := []fixtureMatch{}
+		for _, p := range pairs {
+			if p[0] != bye && p[1] != bye {
+				round = append(round, fixtureMatch{Week: r + 1, Home: p[0], Away: p[1]})
+			}
+		}
+		rounds = append(rounds, round)
+	}
+	return rounds
+}
+
+// roundRobin generates a double round-robin fixture list: every team plays every
+// other team once at home and once away. The second half mirrors the rounds of the
+// first with home and away swapped, from the second round on and ending with the first.
+// Mirrored in the same order, teams would play three home or away matches in a row
+// around the turn of the season.
+func roundRobin(teamIDs []int) []fixtureMatch {
+	rounds := singleRoundRobin(teamIDs)
+	half := len(rounds)
+
+	var fixture []fixtureMatch
+	for _, round := range rounds {
+		fixture = append(fixture, round...)
+	}
+	for i := range rounds {
+		for _, m := range rounds[(i+1)%half] {
+			fixture = append(fixture, fixtureMatch{Week: half + i + 1, Home: m.Away, Away: m.Home})
+		}
+	}
+	return fixture
+}
+
+// firstLeg returns the first half of a double round-robin fixture list, in which every
+// team meets every other team once.
+func firstLeg(fixture []fixtureMatch) []fixtureMatch {
+	weeks := 0
+	for _, m := range fixture {
+		weeks = max(weeks, m.Week)
+	}
+	var leg []fixtureMatch
+	for _, m := range fixture {
+		if m.Week <= weeks/2 {
+			leg = append(leg, m)
+		}
+	}
+	return leg
+}
@@ -0,0 +1,104 @@
+package league
+
+import "testing"
+
+// checkFixture fails the test unless every team plays every other team the given number
+// of times, at most once per week, never more than two home or away matches in a row
+// (weeks without a match do not break a run), and with home and away balanced for a
+// double round-robin.
+func checkFixture(t *testing.T, teams int, fixture []fixtureMatch, double bool) {
+	t.Helper()
+	meetings := map[[2]int]int{}
+	venues := map[int][]bool{} // Team: home or away in week order
+	playing := map[[2]int]bool{}
+	for _, m := range fixture {
+		if m.Home == m.Away {
+			t.Fatalf("%d teams: team %d plays itself in week %d", teams, m.Home, m.Week)
+		}
+		for _, team := range []int{m.Home, m.Away} {
+			if playing[[2]int{team, m.Week}] {
+				t.Fatalf("%d teams: team %d plays twice in week %d", teams, team, m.Week)
+			}
+			playing[[2]int{team, m.Week}] = true
+		}
+		meetings[[2]int{m.Home, m.Away}]++
+		venues[m.Home] = append(venues[m.Home], true)
+		venues[m.Away] = append(venues[m.Away], false)
+	}
+
+	for a := 1; a <= teams; a++ {
+		for b := a + 1; b <= teams; b++ {
+			ab, ba := meetings[[2]int{a, b}], meetings[[2]int{b, a}]
+			if double && (ab != 1 || ba != 1) {
+				t.Errorf("%d teams: %d and %d meet %d times at home and %d away, want once each", teams, a, b, ab, ba)
+			}
+			if !double && ab+ba != 1 {
+				t.Errorf("%d teams: %d and %d meet %d times, want once", teams, a, b, ab+ba)
+			}
+		}
+	}
+
+	for team, v := range venues {
+		run := 1
+		for i := 1; i < len(v); i++ {
+			if v[i] == v[i-1] {
+				run++
+			} else {
+				run = 1
+			}
+			if run > maxConsecutiveVenue {
+				t.Errorf("%d teams: team %d plays %d home or away matches in a row", teams, team, run)
+				break
+			}
+		}
+	}
+}
+
+// teamRange returns the team IDs 1 to n.
+func teamRange(n int) []int {
+	ids := make([]int, n)
+	for i := range ids {
+		ids[i] = i + 1
+	}
+	return ids
+}
+
+// sortByWeek returns the matches of a fixture in week order.
+func sortByWeek(fixture []fixtureMatch) []fixtureMatch {
+	sorted := append([]fixtureMatch{}, fixture...)
+	for i := 1; i < len(sorted); i++ {
+		for j := i; j > 0 && sorted[j].Week < sorted[j-1].Week; j-- {
+			sorted[j], sorted[j-1] = sorted[j-1], sorted[j]
+		}
+	}
+	return sorted
+}
+
+func TestSingleRoundRobin(t *testing.T) {
+	for teams := 1; teams <= 20; teams++ {
+		rounds := singleRoundRobin(teamRange(teams))
+		wantRounds := teams - 1
+		if teams%2 == 1 {
+			wantRounds = teams
+		}
+		if len(rounds) != wantRounds {
+			t.Errorf("%d teams: got %d rounds, want %d", teams, len(rounds), wantRounds)
+		}
+		var fixture []fixtureMatch
+		for i, round := range rounds {
+			for _, m := range round {
+				if m.Week != i+1 {
+					t.Errorf("%d teams: match of round %d has week %d", teams, i+1, m.Week)
+				}
+			}
+			fixture = append(fixture, round...)
+		}
+		checkFixture(t, teams, fixture, false)
+	}
+}
+
+func TestRoundRobin(t *testing.T) {
+	for teams := 2; teams <= 20; teams++ {
+		checkFixture(t, teams, sortByWeek(roundRobin(teamRange(teams))), true)
+	}
+}
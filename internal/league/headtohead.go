@@ -0,0 +1,183 @@
+package league
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	models "go-football-league/internal/domain"
+	storage "go-football-league/internal/repository"
+)
+
+// GetHeadToHead returns the record of a team against an opponent: every meeting in the
+// league, cups, playoffs and tournaments, oldest first. Cup and tournament matches have no
+// date, so they are placed after the league matches of their season.
+func GetHeadToHead(teamID, opponentID int) (models.HeadToHead, error) {
+	return GetHeadToHeadContext(context.Background(), teamID, opponentID)
+}
+
+// GetHeadToHeadContext is GetHeadToHead with a context that cancels the queries.
+func GetHeadToHeadContext(ctx context.Context, teamID, opponentID int) (models.HeadToHead, error) {
+	h := models.HeadToHead{TeamID: teamID, OpponentID: opponentID, Meetings: []models.Meeting{}}
+	if teamID == opponentID {
+		return h, fmt.Errorf("Team %d cannot play itself", teamID)
+	}
+	var err error
+	if h.TeamName, err = teamName(ctx, teamID); err != nil {
+		return h, err
+	}
+	if h.OpponentName, err = teamName(ctx, opponentID); err != nil {
+		return h, err
+	}
+
+	loc, err := leagueLocation(ctx)
+	if err != nil {
+		return h, err
+	}
+
+	rows, err := storage.DB.QueryContext(ctx, `
+		SELECT season_id, season_name, competition, stage, kickoff,
+		       home_team_id, home_name, away_team_id, away_name, home_goals, away_goals,
+		       extra_time, home_penalties, away_penalties
+		FROM (
+		    SELECT s.id AS season_id, s.name AS season_name, d.name AS competition,
+		           'Week ' || m.week AS stage, m.kickoff, 0 AS kind, m.id AS ordinal,
+		           m.home_team_id, ht.name AS home_name, m.away_team_id, at.name AS away_name,
+		           m.home_goals, m.away_goals, 0 AS extra_time,
+		           NULL AS home_penalties, NULL AS away_penalties
+		    FROM matches m
+		    JOIN seasons s ON m.season_id = s.id
+		    JOIN divisions d ON m.division_id = d.id
+		    JOIN teams ht ON m.home_team_id = ht.id
+		    JOIN teams at ON m.away_team_id = at.id
+		    WHERE m.status IN ('played', 'awarded') AND m.home_goals IS NOT NULL AND m.away_goals IS NOT NULL
+
+		    UNION ALL
+		    SELECT s.id, s.name, c.name,
+		           'Round ' || ct.round || CASE WHEN c.two_legged THEN ', leg ' || l.leg ELSE '' END,
+		           NULL, 1, l.id,
+		           l.home_team_id, ht.name, l.away_team_id, at.name,
+		           l.home_goals, l.away_goals, l.extra_time, l.home_penalties, l.away_penalties
+		    FROM cup_legs l
+		    JOIN cup_ties ct ON l.tie_id = ct.id
+		    JOIN cups c ON ct.cup_id = c.id
+		    JOIN seasons s ON c.season_id = s.id
+		    JOIN teams ht ON l.home_team_id = ht.id
+		    JOIN teams at ON l.away_team_id = at.id
+
+		    UNION ALL
+		    SELECT s.id, s.name, t.name, 'Group ' || tm.group_name || ', matchday ' || tm.matchday,
+		           NULL, 2, tm.id,
+		           tm.home_team_id, ht.name, tm.away_team_id, at.name,
+		           tm.home_goals, tm.away_goals, 0, NULL, NULL
+		    FROM tournament_matches tm
+		    JOIN tournaments t ON tm.tournament_id = t.id
+		    JOIN seasons s ON t.season_id = s.id
+		    JOIN teams ht ON tm.home_team_id = ht.id
+		    JOIN teams at ON tm.away_team_id = at.id
+		    WHERE tm.home_goals IS NOT NULL AND tm.away_goals IS NOT NULL
+		)
+		WHERE (home_team_id = ?1 AND away_team_id = ?2) OR (home_team_id = ?2 AND away_team_id = ?1)
+		ORDER BY season_id, kind, kickoff, ordinal
+	`, teamID, opponentID)
+	if err != nil {
+		return h, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var m models.Meeting
+		var kickoff sql.NullString
+		var homePens, awayPens sql.NullInt64
+		err := rows.Scan(&m.SeasonID, &m.SeasonName, &m.Competition, &m.Stage, &kickoff,
+			&m.HomeTeamID, &m.HomeTeamName, &m.AwayTeamID, &m.AwayTeamName, &m.HomeGoals, &m.AwayGoals,
+			&m.ExtraTime, &homePens, &awayPens)
+		if err != nil {
+			return h, err
+		}
+		if m.Kickoff, err = parseKickoff(kickoff, loc); err != nil {
+			return h, err
+		}
+		if homePens.Valid && awayPens.Valid {
+			m.Penalties = fmt.Sprintf("%d-%d", homePens.Int64, awayPens.Int64)
+		}
+		h.Meetings = append(h.Meetings, m)
+	}
+	if err := rows.Err(); err != nil {
+		return h, err
+	}
+
+	var results []string
+	for i := range h.Meetings {
+		m := &h.Meetings[i]
+		goalsFor, goalsAgainst := m.HomeGoals, m.AwayGoals
+		if m.AwayTeamID == teamID {
+			goalsFor, goalsAgainst = goalsAgainst, goalsFor
+		}
+
+		h.Played++
+		h.GoalsFor += goalsFor
+		h.GoalsAgainst += goalsAgainst
+		switch {
+		case goalsFor > goalsAgainst:
+			h.Wins++
+			results = append(results, "W")
+			if h.BiggestWin == nil || widerWin(m.HomeGoals, m.AwayGoals, h.BiggestWin.HomeGoals, h.BiggestWin.AwayGoals) {
+				h.BiggestWin = m
+			}
+		case goalsFor < goalsAgainst:
+			h.Losses++
+			results = append(results, "L")
+			if h.OpponentBiggestWin == nil || widerWin(m.HomeGoals, m.AwayGoals, h.OpponentBiggestWin.HomeGoals, h.OpponentBiggestWin.AwayGoals) {
+				h.OpponentBiggestWin = m
+			}
+		default:
+			h.Draws++
+			results = append(results, "D")
+		}
+	}
+	h.CurrentStreak = currentStreak(results)
+	return h, nil
+}
+
+// widerWin reports whether the score homeA-awayA is a win by a wider margin than
+// homeB-awayB, or by the same margin with more goals. Equal scores are not wider, so the
+// earlier match keeps a record.
+func widerWin(homeA, awayA, homeB, awayB int) bool {
+	marginA, marginB := abs(homeA-awayA), abs(homeB-awayB)
+	if marginA != marginB {
+		return marginA > marginB
+	}
+	return homeA+awayA > homeB+awayB
+}
+
+// currentStreak returns the run of identical results at the end of a sequence of W, D and L
+// results, such as "W3", or an empty string for no results.
+func currentStreak(results []string) string {
+	if len(results) == 0 {
+		return ""
+	}
+	last, n := results[len(results)-1], 0
+	for i := len(results) - 1; i >= 0 && results[i] == last; i-- {
+		n++
+	}
+	return fmt.Sprintf("%s%d", last, n)
+}
+
+// teamName returns the name of a team, or an error if it does not exist.
+func teamName(ctx context.Context, teamID int) (string, error) {
+	var name string
+	err := storage.DB.QueryRowContext(ctx, "SELECT name FROM teams WHERE id = ?", teamID).Scan(&name)
+	if err == sql.ErrNoRows {
+		return "", fmt.Errorf("Team %d does not exist", teamID)
+	}
+	return name, err
+}
+
+// abs returns the absolute value of an integer.
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
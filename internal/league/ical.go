@@ -0,0 +1,213 @@
+package league
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	storage "go-football-league/internal/repository"
+)
+
+const (
+	icalProductID   = "-//go-football-league//Fixtures//EN"
+	icalUIDDomain   = "go-football-league"
+	icalLineLimit   = 75 // Octets per content line before folding (RFC 5545, section 3.1)
+	icalStampLayout = "20060102T150405Z"
+	matchDuration   = 2 * time.Hour // Calendar length of a match including half-time and stoppages
+)
+
+// calendarMatch is a fixture as published in an iCalendar feed.
+type calendarMatch struct {
+	ID        int
+	Season    string
+	Division  string
+	Week      int
+	HomeTeam  string
+	AwayTeam  string
+	HomeGoals sql.NullInt64
+	AwayGoals sql.NullInt64
+	Kickoff   sql.NullString
+	Status    string
+	Revision  int
+	UpdatedAt sql.NullString
+	Venue     sql.NullString
+	City      sql.NullString
+	Neutral   bool
+}
+
+// TeamFixturesICS returns the current season's fixtures of a team as an RFC 5545 iCalendar document.
+func TeamFixturesICS(teamID int) (string, error) {
+	return TeamFixturesICSContext(context.Background(), teamID)
+}
+
+// TeamFixturesICSContext is TeamFixturesICS with a context that cancels the queries.
+func TeamFixturesICSContext(ctx context.Context, teamID int) (string, error) {
+	name, err := teamName(ctx, teamID)
+	if err != nil {
+		return "", err
+	}
+	seasonID, err := CurrentSeasonIDContext(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	matches, err := calendarMatches(ctx, "m.season_id = ? AND (m.home_team_id = ? OR m.away_team_id = ?)", seasonID, teamID, teamID)
+	if err != nil {
+		return "", err
+	}
+	return renderICalendar(name+" fixtures", matches), nil
+}
+
+// SeasonFixturesICS returns all fixtures of a season as an RFC 5545 iCalendar document.
+func SeasonFixturesICS(seasonID int) (string, error) {
+	return SeasonFixturesICSContext(context.Background(), seasonID)
+}
+
+// SeasonFixturesICSContext is SeasonFixturesICS with a context that cancels the queries.
+func SeasonFixturesICSContext(ctx context.Context, seasonID int) (string, error) {
+	season, err := getSeason(ctx, seasonID)
+	if err != nil {
+		return "", err
+	}
+
+	matches, err := calendarMatches(ctx, "m.season_id = ?", seasonID)
+	if err != nil {
+		return "", err
+	}
+	return renderICalendar(season.Name+" fixtures", matches), nil
+}
+
+// calendarMatches reads the scheduled matches matching a filter on the matches table (alias m).
+func calendarMatches(ctx context.Context, filter string, args ...interface{}) ([]calendarMatch, error) {
+	rows, err := storage.DB.QueryContext(ctx, `
+		SELECT m.id, s.name, d.name, m.week, ht.name, at.name, m.home_goals, m.away_goals,
+		       m.kickoff, m.status, m.revision, m.updated_at, v.name, v.city, m.neutral
+		FROM matches m
+		JOIN seasons s ON m.season_id = s.id
+		JOIN divisions d ON m.division_id = d.id
+		JOIN teams ht ON m.home_team_id = ht.id
+		JOIN teams at ON m.away_team_id = at.id
+		LEFT JOIN venues v ON v.id = COALESCE(m.venue_id, ht.venue_id)
+		WHERE m.kickoff IS NOT NULL AND `+filter+`
+		ORDER BY m.kickoff, m.id
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var matches []calendarMatch
+	for rows.Next() {
+		var m calendarMatch
+		err := rows.Scan(&m.ID, &m.Season, &m.Division, &m.Week, &m.HomeTeam, &m.AwayTeam,
+			&m.HomeGoals, &m.AwayGoals, &m.Kickoff, &m.Status, &m.Revision, &m.UpdatedAt, &m.Venue, &m.City, &m.Neutral)
+		if err != nil {
+			return nil, err
+		}
+		matches = append(matches, m)
+	}
+	return matches, rows.Err()
+}
+
+// renderICalendar builds a calendar with one event per match. Each match keeps the same
+// UID for its whole life; its SEQUENCE goes up whenever it is rescheduled or gets a
+// result, so subscribed calendars replace the old event.
+func renderICalendar(name string, matches []calendarMatch) string {
+	var b strings.Builder
+	line := func(content string) {
+		b.WriteString(foldICalLine(content))
+		b.WriteString("\r\n")
+	}
+
+	stamp := time.Now().UTC().Format(icalStampLayout)
+	line("BEGIN:VCALENDAR")
+	line("VERSION:2.0")
+	line("PRODID:" + icalProductID)
+	line("CALSCALE:GREGORIAN")
+	line("METHOD:PUBLISH")
+	line("X-WR-CALNAME:" + escapeICalText(name))
+	for _, m := range matches {
+		kickoff, err := parseKickoff(m.Kickoff, time.UTC)
+		if err != nil {
+			continue
+		}
+
+		summary := fmt.Sprintf("%s vs %s", m.HomeTeam, m.AwayTeam)
+		description := fmt.Sprintf("%s %s, week %d", m.Division, m.Season, m.Week)
+		if m.Neutral {
+			description += " (neutral ground)"
+		}
+		status := "CONFIRMED"
+		switch {
+		case m.Status == StatusPostponed || m.Status == StatusAbandoned:
+			summary = strings.ToUpper(m.Status) + ": " + summary
+			status = "CANCELLED"
+		case m.HomeGoals.Valid && m.AwayGoals.Valid:
+			summary = fmt.Sprintf("%s %d-%d %s", m.HomeTeam, m.HomeGoals.Int64, m.AwayGoals.Int64, m.AwayTeam)
+			description += fmt.Sprintf("\nFull time: %s", summary)
+			if m.Status == StatusAwarded {
+				summary += " (awarded)"
+				description += " (awarded)"
+			}
+		}
+
+		line("BEGIN:VEVENT")
+		line(fmt.Sprintf("UID:match-%d@%s", m.ID, icalUIDDomain))
+		line("DTSTAMP:" + stamp)
+		if updated, err := parseKickoff(m.UpdatedAt, time.UTC); err == nil && !updated.IsZero() {
+			line("LAST-MODIFIED:" + updated.Format(icalStampLayout))
+		}
+		line(fmt.Sprintf("SEQUENCE:%d", m.Revision))
+		line("DTSTART:" + kickoff.Format(icalStampLayout))
+		line("DTEND:" + kickoff.Add(matchDuration).Format(icalStampLayout))
+		line("SUMMARY:" + escapeICalText(summary))
+		line("LOCATION:" + escapeICalText(m.location()))
+		line("DESCRIPTION:" + escapeICalText(description))
+		line("STATUS:" + status)
+		line("END:VEVENT")
+	}
+	line("END:VCALENDAR")
+	return b.String()
+}
+
+// location returns where a match is played: its venue and city, or the home team's
+// ground when no venue is known.
+func (m calendarMatch) location() string {
+	switch {
+	case m.Venue.Valid:
+		return m.Venue.String + ", " + m.City.String
+	case m.Neutral:
+		return "Neutral ground"
+	default:
+		return m.HomeTeam + " home ground"
+	}
+}
+
+// escapeICalText escapes a TEXT property value (RFC 5545, section 3.3.11).
+func escapeICalText(s string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\n", `\n`).Replace(s)
+}
+
+// foldICalLine splits a content line longer than 75 octets into continuation lines,
+// never breaking a UTF-8 character (RFC 5545, section 3.1).
+func foldICalLine(s string) string {
+	if len(s) <= icalLineLimit {
+		return s
+	}
+	var b strings.Builder
+	limit := icalLineLimit
+	for len(s) > limit {
+		cut := limit
+		for cut > 0 && s[cut]&0xC0 == 0x80 {
+			cut--
+		}
+		b.WriteString(s[:cut])
+		b.WriteString("\r\n ")
+		s = s[cut:]
+		limit = icalLineLimit - 1 // The leading space of a continuation line counts
+	}
+	b.WriteString(s)
+	return b.String()
+}
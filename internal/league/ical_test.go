@@ -0,0 +1,69 @@
+package league
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestEscapeICalText(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{name: "plain text", in: "Chelsea vs Arsenal", want: "Chelsea vs Arsenal"},
+		{name: "comma", in: "London, England", want: `London\, England`},
+		{name: "semicolon", in: "Home; Away", want: `Home\; Away`},
+		{name: "backslash", in: `A\B`, want: `A\\B`},
+		{name: "newline", in: "Week 1\nFinal", want: `Week 1\nFinal`},
+		{name: "escaped backslash before a comma", in: `\,`, want: `\\\,`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := escapeICalText(tt.in); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFoldICalLine(t *testing.T) {
+	tests := []struct {
+		name      string
+		in        string
+		wantLines int
+	}{
+		{name: "short line", in: "SUMMARY:Chelsea vs Arsenal", wantLines: 1},
+		{name: "exactly the limit", in: strings.Repeat("a", icalLineLimit), wantLines: 1},
+		{name: "one octet over the limit", in: strings.Repeat("a", icalLineLimit+1), wantLines: 2},
+		{name: "long line", in: "DESCRIPTION:" + strings.Repeat("x", 200), wantLines: 3},
+		{name: "multi-byte characters", in: "LOCATION:" + strings.Repeat("Estádio São Januário ", 8), wantLines: 3},
+		{name: "three- and four-byte characters", in: strings.Repeat("⚽🏆", 30), wantLines: 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := foldICalLine(tt.in)
+			lines := strings.Split(got, "\r\n")
+			if len(lines) != tt.wantLines {
+				t.Errorf("got %d lines, want %d: %q", len(lines), tt.wantLines, got)
+			}
+			for i, line := range lines {
+				if len(line) > icalLineLimit {
+					t.Errorf("line %d is %d octets long", i, len(line))
+				}
+				if !utf8.ValidString(line) {
+					t.Errorf("line %d breaks a character: %q", i, line)
+				}
+				if i > 0 && !strings.HasPrefix(line, " ") {
+					t.Errorf("continuation line %d does not start with a space: %q", i, line)
+				}
+			}
+
+			// Unfolding gives back the original line
+			if unfolded := strings.ReplaceAll(got, "\r\n ", ""); unfolded != tt.in {
+				t.Errorf("unfolded to %q, want %q", unfolded, tt.in)
+			}
+		})
+	}
+}
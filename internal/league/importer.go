@@ -0,0 +1,440 @@
+package league
+
+import (
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	models "go-football-league/internal/domain"
+	storage "go-football-league/internal/repository"
+)
+
+// Kinds of data that can be imported.
+const (
+	ImportTeams    = "teams"    // name, power, division
+	ImportFixtures = "fixtures" // season, week, division, home, away, kickoff
+	ImportResults  = "results"  // The fixture columns plus home_goals and away_goals
+)
+
+// importColumns are the columns of each kind of import; required ones are marked with *.
+var importColumns = map[string][]string{
+	ImportTeams:    {"name*", "power*", "division"},
+	ImportFixtures: {"season", "week*", "division", "home*", "away*", "kickoff"},
+	ImportResults:  {"season", "week*", "division", "home*", "away*", "kickoff", "home_goals*", "away_goals*"},
+}
+
+// ImportOptions describe an import.
+type ImportOptions struct {
+	Kind   string
+	Format string // FormatCSV or FormatJSON
+	DryRun bool   // Validate and load the rows, then roll everything back
+}
+
+// importRow is one row of an imported file with its values by column.
+type importRow struct {
+	number int
+	values map[string]string
+}
+
+// importer loads the rows of a file in a single transaction and collects row errors.
+type importer struct {
+	tx        *sql.Tx
+	report    *models.ImportReport
+	teams     map[string]importTeam
+	divisions map[string]int
+	seasons   map[string]int
+	seasonID  int // Current season, used when a row has no season
+	topID     int // Top division, used for teams without a division
+}
+
+// importTeam is a team as the importer looks it up by name.
+type importTeam struct {
+	ID         int
+	DivisionID int
+}
+
+// Import reads teams, fixtures or results from a CSV or JSON file and stores them in one
+// transaction. Team, season and division names must exist (teams may also come earlier in
+// the same file). Problems with individual rows are listed in the report, and then nothing
+// is stored; a file that cannot be read at all is an error.
+func Import(r io.Reader, opts ImportOptions) (models.ImportReport, error) {
+	return ImportContext(context.Background(), r, opts)
+}
+
+// ImportContext is Import with a context that cancels the queries and, before it commits, the import.
+func ImportContext(ctx context.Context, r io.Reader, opts ImportOptions) (models.ImportReport, error) {
+	report := models.ImportReport{Kind: opts.Kind, Format: opts.Format, DryRun: opts.DryRun, Errors: []models.ImportError{}}
+	columns, ok := importColumns[opts.Kind]
+	if !ok {
+		return report, fmt.Errorf("Invalid import kind %q, expected teams, fixtures or results", opts.Kind)
+	}
+
+	var rows []importRow
+	var err error
+	switch opts.Format {
+	case FormatCSV:
+		rows, err = readCSVRows(r, columns, &report)
+	case FormatJSON:
+		rows, err = readJSONRows(r, columns, &report)
+	default:
+		return report, fmt.Errorf("Invalid import format %q, expected csv or json", opts.Format)
+	}
+	if err != nil {
+		return report, err
+	}
+	report.Rows = len(rows)
+
+	tx, err := storage.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return report, err
+	}
+	defer tx.Rollback()
+
+	imp := &importer{tx: tx, report: &report}
+	if err := imp.loadNames(ctx); err != nil {
+		return report, err
+	}
+	for _, row := range rows {
+		if opts.Kind == ImportTeams {
+			err = imp.importTeam(ctx, row)
+		} else {
+			err = imp.importMatch(ctx, row, opts.Kind == ImportResults)
+		}
+		if err != nil {
+			return report, err
+		}
+	}
+
+	if len(report.Errors) > 0 || opts.DryRun {
+		return report, nil
+	}
+	return report, tx.Commit()
+}
+
+// readCSVRows reads a CSV file whose first line names the columns.
+func readCSVRows(r io.Reader, columns []string, report *models.ImportReport) ([]importRow, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+	records, err := cr.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read CSV: %v", err)
+	}
+	if len(records) == 0 {
+		return nil, errors.New("The file is empty")
+	}
+
+	header := records[0]
+	for i := range header {
+		header[i] = strings.ToLower(strings.TrimSpace(header[i]))
+		if !knownColumn(columns, header[i]) {
+			addImportError(report, 1, header[i], "Unknown column")
+		}
+	}
+	for _, c := range columns {
+		if name, required := strings.CutSuffix(c, "*"); required && !contains(header, name) {
+			addImportError(report, 1, name, "Required column is missing")
+		}
+	}
+
+	var rows []importRow
+	for i, record := range records[1:] {
+		number := i + 2
+		if len(record) != len(header) {
+			addImportError(report, number, "", fmt.Sprintf("Expected %d values, got %d", len(header), len(record)))
+			continue
+		}
+		row := importRow{number: number, values: map[string]string{}}
+		for j, value := range record {
+			row.values[header[j]] = strings.TrimSpace(value)
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// readJSONRows reads a JSON array of objects keyed by column name. Values may be strings or numbers.
+func readJSONRows(r io.Reader, columns []string, report *models.ImportReport) ([]importRow, error) {
+	var objects []map[string]interface{}
+	if err := json.NewDecoder(r).Decode(&objects); err != nil {
+		return nil, fmt.Errorf("Failed to read JSON: %v", err)
+	}
+
+	var rows []importRow
+	for i, object := range objects {
+		row := importRow{number: i + 1, values: map[string]string{}}
+		for key, value := range object {
+			key = strings.ToLower(key)
+			if !knownColumn(columns, key) {
+				addImportError(report, row.number, key, "Unknown field")
+				continue
+			}
+			switch v := value.(type) {
+			case nil:
+			case string:
+				row.values[key] = strings.TrimSpace(v)
+			case float64:
+				row.values[key] = strconv.FormatFloat(v, 'f', -1, 64)
+			default:
+				addImportError(report, row.number, key, "Expected a string or a number")
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// loadNames reads the teams, divisions and seasons rows can refer to.
+func (imp *importer) loadNames(ctx context.Context) error {
+	imp.teams = map[string]importTeam{}
+	imp.divisions = map[string]int{}
+	imp.seasons = map[string]int{}
+
+	rows, err := imp.tx.QueryContext(ctx, "SELECT id, name, division_id FROM teams")
+	if err != nil {
+		return err
+	}
+	for rows.Next() {
+		var t importTeam
+		var name string
+		if err := rows.Scan(&t.ID, &name, &t.DivisionID); err != nil {
+			rows.Close()
+			return err
+		}
+		imp.teams[name] = t
+	}
+	rows.Close()
+
+	rows, err = imp.tx.QueryContext(ctx, "SELECT id, name FROM divisions ORDER BY tier DESC")
+	if err != nil {
+		return err
+	}
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&imp.topID, &name); err != nil {
+			rows.Close()
+			return err
+		}
+		imp.divisions[name] = imp.topID
+	}
+	rows.Close()
+
+	rows, err = imp.tx.QueryContext(ctx, "SELECT id, name FROM seasons ORDER BY id")
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&imp.seasonID, &name); err != nil {
+			return err
+		}
+		imp.seasons[name] = imp.seasonID
+	}
+	return rows.Err()
+}
+
+// importTeam adds the team of a row.
+func (imp *importer) importTeam(ctx context.Context, row importRow) error {
+	valid := true
+	name := row.values["name"]
+	if name == "" {
+		valid = imp.fail(row, "name", "Team name is required")
+	} else if _, exists := imp.teams[name]; exists {
+		valid = imp.fail(row, "name", fmt.Sprintf("Team %q already exists", name))
+	}
+	power, ok := imp.intValue(row, "power", 1, 100)
+	valid = valid && ok
+	divisionID, ok := imp.division(row)
+	valid = valid && ok
+	if !valid {
+		return nil
+	}
+
+	res, err := imp.tx.ExecContext(ctx, "INSERT INTO teams (name, power, division_id) VALUES (?, ?, ?)", name, power, divisionID)
+	if err != nil {
+		imp.fail(row, "", fmt.Sprintf("Failed to add team: %v", err))
+		return nil
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return err
+	}
+	imp.teams[name] = importTeam{ID: int(id), DivisionID: divisionID}
+	imp.report.Created++
+	return nil
+}
+
+// importMatch adds the fixture of a row. With a result, a fixture that already exists is
+// given the score instead, and a new one is stored as played.
+func (imp *importer) importMatch(ctx context.Context, row importRow, withResult bool) error {
+	valid := true
+	seasonID := imp.seasonID
+	if name := row.values["season"]; name != "" {
+		if id, ok := imp.seasons[name]; ok {
+			seasonID = id
+		} else {
+			valid = imp.fail(row, "season", fmt.Sprintf("Unknown season %q", name))
+		}
+	}
+	week, ok := imp.intValue(row, "week", 1, 0)
+	valid = valid && ok
+	home, ok := imp.team(row, "home")
+	valid = valid && ok
+	away, ok := imp.team(row, "away")
+	valid = valid && ok
+	if home.ID != 0 && home.ID == away.ID {
+		valid = imp.fail(row, "away", "A team cannot play itself")
+	}
+	divisionID := home.DivisionID
+	if row.values["division"] != "" {
+		divisionID, ok = imp.division(row)
+		valid = valid && ok
+	}
+	var kickoff interface{}
+	if value := row.values["kickoff"]; value != "" {
+		if t, err := time.Parse(time.RFC3339, value); err != nil {
+			valid = imp.fail(row, "kickoff", fmt.Sprintf("Invalid kickoff %q, expected RFC 3339 such as 2025-08-16T15:00:00+01:00", value))
+		} else {
+			kickoff = formatKickoff(t)
+		}
+	}
+	var homeGoals, awayGoals interface{}
+	status := StatusScheduled
+	if withResult {
+		h, ok1 := imp.intValue(row, "home_goals", 0, 0)
+		a, ok2 := imp.intValue(row, "away_goals", 0, 0)
+		valid = valid && ok1 && ok2
+		homeGoals, awayGoals, status = h, a, StatusPlayed
+	}
+	if !valid {
+		return nil
+	}
+
+	if withResult {
+		res, err := imp.tx.ExecContext(ctx, `
+			UPDATE matches
+			SET home_goals = ?1, away_goals = ?2, status = 'played', kickoff = COALESCE(?3, kickoff),
+			    kickoff_set = CASE WHEN ?3 IS NULL THEN kickoff_set ELSE 1 END,
+			    revision = revision + 1, updated_at = `+nowUTC+`
+			WHERE season_id = ?4 AND week = ?5 AND home_team_id = ?6 AND away_team_id = ?7
+			  AND status IN ('scheduled', 'played')
+		`, homeGoals, awayGoals, kickoff, seasonID, week, home.ID, away.ID)
+		if err != nil {
+			return err
+		}
+		if n, _ := res.RowsAffected(); n > 0 {
+			imp.report.Updated++
+			return nil
+		}
+
+		// A postponed, abandoned or awarded match keeps its status
+		var current string
+		err = imp.tx.QueryRowContext(ctx, `
+			SELECT status FROM matches
+			WHERE season_id = ? AND week = ? AND home_team_id = ? AND away_team_id = ?
+		`, seasonID, week, home.ID, away.ID).Scan(&current)
+		if err == nil {
+			imp.fail(row, "", fmt.Sprintf("The match is %s; only scheduled or played matches take a result", current))
+			return nil
+		}
+		if err != sql.ErrNoRows {
+			return err
+		}
+	}
+
+	_, err := imp.tx.ExecContext(ctx, `
+		INSERT INTO matches (season_id, division_id, week, home_team_id, away_team_id, home_goals, away_goals, status, kickoff, kickoff_set)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, seasonID, divisionID, week, home.ID, away.ID, homeGoals, awayGoals, status, kickoff, kickoff != nil)
+	if err != nil {
+		if strings.Contains(err.Error(), "UNIQUE") {
+			imp.fail(row, "", "The match is already in the fixture")
+		} else {
+			imp.fail(row, "", fmt.Sprintf("Failed to add match: %v", err))
+		}
+		return nil
+	}
+	imp.report.Created++
+	return nil
+}
+
+// team looks up the team named in a column of a row.
+func (imp *importer) team(row importRow, column string) (importTeam, bool) {
+	name := row.values[column]
+	if name == "" {
+		return importTeam{}, imp.fail(row, column, "Team is required")
+	}
+	t, ok := imp.teams[name]
+	if !ok {
+		return importTeam{}, imp.fail(row, column, fmt.Sprintf("Unknown team %q", name))
+	}
+	return t, true
+}
+
+// division looks up the division of a row, defaulting to the top division.
+func (imp *importer) division(row importRow) (int, bool) {
+	name := row.values["division"]
+	if name == "" {
+		return imp.topID, true
+	}
+	id, ok := imp.divisions[name]
+	if !ok {
+		return 0, imp.fail(row, "division", fmt.Sprintf("Unknown division %q", name))
+	}
+	return id, true
+}
+
+// intValue reads a required whole number of at least lo and, when hi is above zero, at most hi.
+func (imp *importer) intValue(row importRow, column string, lo, hi int) (int, bool) {
+	value := row.values[column]
+	if value == "" {
+		return 0, imp.fail(row, column, "Value is required")
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < lo || (hi > 0 && n > hi) {
+		if hi > 0 {
+			return 0, imp.fail(row, column, fmt.Sprintf("Expected a whole number from %d to %d, got %q", lo, hi, value))
+		}
+		return 0, imp.fail(row, column, fmt.Sprintf("Expected a whole number of at least %d, got %q", lo, value))
+	}
+	return n, true
+}
+
+// fail records a problem with a row and returns false, so callers can clear their valid flag.
+func (imp *importer) fail(row importRow, column, message string) bool {
+	addImportError(imp.report, row.number, column, message)
+	return false
+}
+
+// addImportError appends a row error to a report.
+func addImportError(report *models.ImportReport, row int, column, message string) {
+	report.Errors = append(report.Errors, models.ImportError{Row: row, Field: column, Message: message})
+}
+
+// knownColumn reports whether a column belongs to an import kind.
+func knownColumn(columns []string, name string) bool {
+	for _, c := range columns {
+		if strings.TrimSuffix(c, "*") == name {
+			return true
+		}
+	}
+	return false
+}
+
+// contains reports whether a list of strings has a value.
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
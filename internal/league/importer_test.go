@@ -0,0 +1,205 @@
+package league
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	models "go-football-league/internal/domain"
+)
+
+// importErrors lists the errors of a report as row:field.
+func importErrors(report models.ImportReport) string {
+	var errs []string
+	for _, e := range report.Errors {
+		errs = append(errs, fmt.Sprintf("%d:%s", e.Row, e.Field))
+	}
+	return strings.Join(errs, ",")
+}
+
+func TestReadCSVRows(t *testing.T) {
+	tests := []struct {
+		name       string
+		kind       string
+		file       string
+		wantErr    bool
+		wantRows   int
+		wantErrors string            // Row errors as row:field
+		wantValues map[string]string // Values of the first row
+	}{
+		{
+			name:       "teams",
+			kind:       ImportTeams,
+			file:       "name,power,division\nChelsea,80,Premier League\nArsenal,78,\n",
+			wantRows:   2,
+			wantValues: map[string]string{"name": "Chelsea", "power": "80", "division": "Premier League"},
+		},
+		{
+			name:       "header case and spaces",
+			kind:       ImportTeams,
+			file:       " Name , POWER\n  Everton , 70 \n",
+			wantRows:   1,
+			wantValues: map[string]string{"name": "Everton", "power": "70"},
+		},
+		{
+			name:       "quoted values",
+			kind:       ImportTeams,
+			file:       "name,power\n\"Brighton, Hove\",65\n",
+			wantRows:   1,
+			wantValues: map[string]string{"name": "Brighton, Hove", "power": "65"},
+		},
+		{
+			name:       "results",
+			kind:       ImportResults,
+			file:       "week,home,away,home_goals,away_goals\n1,Chelsea,Arsenal,2,1\n",
+			wantRows:   1,
+			wantValues: map[string]string{"week": "1", "home": "Chelsea", "away": "Arsenal", "home_goals": "2", "away_goals": "1"},
+		},
+		{
+			name:       "unknown column",
+			kind:       ImportTeams,
+			file:       "name,power,stadium\nChelsea,80,Stamford Bridge\n",
+			wantRows:   1,
+			wantErrors: "1:stadium",
+		},
+		{
+			name:       "missing required columns",
+			kind:       ImportResults,
+			file:       "week,home,away\n1,Chelsea,Arsenal\n",
+			wantRows:   1,
+			wantErrors: "1:home_goals,1:away_goals",
+		},
+		{
+			name:       "row with too few values",
+			kind:       ImportTeams,
+			file:       "name,power\nChelsea,80\nArsenal\nEverton,70\n",
+			wantRows:   2,
+			wantErrors: "3:",
+		},
+		{name: "empty file", kind: ImportTeams, file: "", wantErr: true},
+		{name: "broken quotes", kind: ImportTeams, file: "name,power\n\"Chelsea,80\n", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var report models.ImportReport
+			rows, err := readCSVRows(strings.NewReader(tt.file), importColumns[tt.kind], &report)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, want error %v", err, tt.wantErr)
+			}
+			if len(rows) != tt.wantRows {
+				t.Errorf("got %d rows, want %d", len(rows), tt.wantRows)
+			}
+			if got := importErrors(report); got != tt.wantErrors {
+				t.Errorf("got errors %q, want %q", got, tt.wantErrors)
+			}
+			for column, want := range tt.wantValues {
+				if got := rows[0].values[column]; got != want {
+					t.Errorf("got %s %q, want %q", column, got, want)
+				}
+			}
+			for i, row := range rows {
+				if i > 0 && row.number <= rows[i-1].number {
+					t.Errorf("row numbers %d and %d are out of order", rows[i-1].number, row.number)
+				}
+			}
+		})
+	}
+}
+
+func TestReadJSONRows(t *testing.T) {
+	tests := []struct {
+		name       string
+		kind       string
+		file       string
+		wantErr    bool
+		wantRows   int
+		wantErrors string
+		wantValues map[string]string
+	}{
+		{
+			name:       "teams",
+			kind:       ImportTeams,
+			file:       `[{"name": "Chelsea", "power": 80, "division": "Premier League"}, {"name": "Arsenal", "power": 78}]`,
+			wantRows:   2,
+			wantValues: map[string]string{"name": "Chelsea", "power": "80", "division": "Premier League"},
+		},
+		{
+			name:       "numbers as strings and field case",
+			kind:       ImportFixtures,
+			file:       `[{"Week": "3", "HOME": " Chelsea ", "away": "Arsenal", "kickoff": null}]`,
+			wantRows:   1,
+			wantValues: map[string]string{"week": "3", "home": "Chelsea", "away": "Arsenal", "kickoff": ""},
+		},
+		{
+			name:       "unknown field",
+			kind:       ImportTeams,
+			file:       `[{"name": "Chelsea", "power": 80}, {"name": "Arsenal", "power": 78, "colour": "red"}]`,
+			wantRows:   2,
+			wantErrors: "2:colour",
+		},
+		{
+			name:       "nested value",
+			kind:       ImportTeams,
+			file:       `[{"name": ["Chelsea"], "power": 80}]`,
+			wantRows:   1,
+			wantErrors: "1:name",
+		},
+		{name: "empty list", kind: ImportTeams, file: `[]`},
+		{name: "not a list", kind: ImportTeams, file: `{"name": "Chelsea"}`, wantErr: true},
+		{name: "invalid JSON", kind: ImportTeams, file: `[{"name": "Chelsea",}]`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var report models.ImportReport
+			rows, err := readJSONRows(strings.NewReader(tt.file), importColumns[tt.kind], &report)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, want error %v", err, tt.wantErr)
+			}
+			if len(rows) != tt.wantRows {
+				t.Errorf("got %d rows, want %d", len(rows), tt.wantRows)
+			}
+			if got := importErrors(report); got != tt.wantErrors {
+				t.Errorf("got errors %q, want %q", got, tt.wantErrors)
+			}
+			for column, want := range tt.wantValues {
+				if got := rows[0].values[column]; got != want {
+					t.Errorf("got %s %q, want %q", column, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestImporterIntValue(t *testing.T) {
+	tests := []struct {
+		name   string
+		value  string
+		lo, hi int
+		want   int
+		wantOK bool
+	}{
+		{name: "within the range", value: "80", lo: 1, hi: 100, want: 80, wantOK: true},
+		{name: "lower bound", value: "1", lo: 1, hi: 100, want: 1, wantOK: true},
+		{name: "upper bound", value: "100", lo: 1, hi: 100, want: 100, wantOK: true},
+		{name: "no upper bound", value: "38", lo: 1, want: 38, wantOK: true},
+		{name: "below the range", value: "0", lo: 1, hi: 100},
+		{name: "above the range", value: "101", lo: 1, hi: 100},
+		{name: "not a number", value: "eighty", lo: 1, hi: 100},
+		{name: "decimal", value: "2.5", lo: 0},
+		{name: "missing", value: "", lo: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := models.ImportReport{}
+			imp := &importer{report: &report}
+			row := importRow{number: 2, values: map[string]string{"power": tt.value}}
+			got, ok := imp.intValue(row, "power", tt.lo, tt.hi)
+			if ok != tt.wantOK || got != tt.want {
+				t.Errorf("got %d, %v, want %d, %v", got, ok, tt.want, tt.wantOK)
+			}
+			if tt.wantOK != (len(report.Errors) == 0) {
+				t.Errorf("got errors %v", report.Errors)
+			}
+		})
+	}
+}
@@ -0,0 +1,181 @@
+package league
+
+// Ways a knockout tie can be decided.
+const (
+	DecidedByBye       = "bye"
+	DecidedByNormal    = "normal time"
+	DecidedByAggregate = "aggregate"
+	DecidedByAwayGoals = "away goals"
+	DecidedByExtraTime = "extra time"
+	DecidedByPenalties = "penalties"
+)
+
+const (
+	extraTimeChances   = 2     // Scoring chances per team in 30 minutes of extra time
+	penaltyKicks       = 5     // Kicks per team before sudden death
+	basePenaltyChance  = 0.75  // Conversion rate of an average (power 50) team
+	penaltyPowerFactor = 0.002 // Extra conversion rate per power point above 50
+)
+
+// contender is a team taking part in a knockout tie.
+type contender struct {
+	ID    int
+	Power int
+}
+
+// legResult is the outcome of one match of a knockout tie.
+type legResult struct {
+	HomeID    int
+	AwayID    int
+	HomeGoals int // Including extra time
+	AwayGoals int
+	ExtraTime bool
+	Shootout  bool
+	HomePens  int
+	AwayPens  int
+}
+
+// tieRules configures how a knockout tie is played.
+type tieRules struct {
+	TwoLegged bool
+	AwayGoals bool // Away goals break a level aggregate in two-legged ties
+	Neutral   bool // Single matches on neutral ground give no home advantage
+	SeedLast  bool // The first-named team of a two-legged tie hosts the second leg
+}
+
+// extraTimeGoals returns the goals a team scores in 30 minutes of extra time.
+func extraTimeGoals(power int) int {
+	power = modelPower(power)
+	goals := 0
+	for i := 0; i < extraTimeChances; i++ {
+		if random.Float64() < float64(power)/400 {
+			goals++
+		}
+	}
+	return goals
+}
+
+// penaltyShootout simulates a shootout: five kicks each, stopping as soon as one
+// side cannot catch up, followed by sudden death.
+func penaltyShootout(homePower, awayPower int) (int, int) {
+	homePower, awayPower = modelPower(homePower), modelPower(awayPower)
+	homeChance := basePenaltyChance + float64(homePower-50)*penaltyPowerFactor
+	awayChance := basePenaltyChance + float64(awayPower-50)*penaltyPowerFactor
+
+	home, away := 0, 0
+	for kick := 1; kick <= penaltyKicks; kick++ {
+		if random.Float64() < homeChance {
+			home++
+		}
+		if home > away+(penaltyKicks-kick+1) || away > home+(penaltyKicks-kick) {
+			return home, away
+		}
+		if random.Float64() < awayChance {
+			away++
+		}
+		if home > away+(penaltyKicks-kick) || away > home+(penaltyKicks-kick) {
+			return home, away
+		}
+	}
+
+	// Sudden death
+	for home == away {
+		if random.Float64() < homeChance {
+			home++
+		}
+		if random.Float64() < awayChance {
+			away++
+		}
+	}
+	return home, away
+}
+
+// playTie simulates a complete knockout tie between two teams.
+// A single match that ends level goes to extra time and penalties. In a two-legged
+// tie the aggregate score decides, then away goals if enabled, then extra time and
+// penalties at the end of the second leg.
+// It returns the played legs, the ID of the winning team and how the tie was decided.
+func playTie(first, second contender, rules tieRules) ([]legResult, int, string) {
+	if !rules.TwoLegged {
+		leg := legResult{
+			HomeID:    first.ID,
+			AwayID:    second.ID,
+			HomeGoals: scoreGoals(first.Power, !rules.Neutral),
+			AwayGoals: scoreGoals(second.Power, false),
+		}
+		winner, decidedBy := settleLevel(&leg, first, second, nil, false)
+		return []legResult{leg}, winner, decidedBy
+	}
+
+	firstLeg := legResult{
+		HomeID:    first.ID,
+		AwayID:    second.ID,
+		HomeGoals: scoreGoals(first.Power, true),
+		AwayGoals: scoreGoals(second.Power, false),
+	}
+	secondLeg := legResult{
+		HomeID:    second.ID,
+		AwayID:    first.ID,
+		HomeGoals: scoreGoals(second.Power, true),
+		AwayGoals: scoreGoals(first.Power, false),
+	}
+	winner, decidedBy := settleLevel(&secondLeg, second, first, &firstLeg, rules.AwayGoals)
+	return []legResult{firstLeg, secondLeg}, winner, decidedBy
+}
+
+// settleLevel decides a tie after its last (or only) leg has been played in normal time.
+// For two-legged ties, first is the opening leg, in which this leg's away side played at home.
+// Extra time and penalties are added to the leg when needed.
+func settleLevel(leg *legResult, home, away contender, first *legResult, awayGoalsRule bool) (int, string) {
+	// Goals this leg's home and away sides scored in the first leg
+	priorHome, priorAway := 0, 0
+	if first != nil {
+		priorHome, priorAway = first.AwayGoals, first.HomeGoals
+	} else {
+		awayGoalsRule = false
+	}
+
+	decided := func() (int, bool) {
+		homeTotal, awayTotal := priorHome+leg.HomeGoals, priorAway+leg.AwayGoals
+		if homeTotal > awayTotal {
+			return home.ID, true
+		}
+		if awayTotal > homeTotal {
+			return away.ID, true
+		}
+		if awayGoalsRule && priorHome > leg.AwayGoals {
+			return home.ID, true
+		}
+		if awayGoalsRule && leg.AwayGoals > priorHome {
+			return away.ID, true
+		}
+		return 0, false
+	}
+
+	if winner, ok := decided(); ok {
+		switch {
+		case priorHome+leg.HomeGoals == priorAway+leg.AwayGoals:
+			return winner, DecidedByAwayGoals
+		case first != nil:
+			return winner, DecidedByAggregate
+		default:
+			return winner, DecidedByNormal
+		}
+	}
+
+	// Extra time
+	leg.ExtraTime = true
+	leg.HomeGoals += extraTimeGoals(home.Power)
+	leg.AwayGoals += extraTimeGoals(away.Power)
+	if winner, ok := decided(); ok {
+		return winner, DecidedByExtraTime
+	}
+
+	// Penalties
+	leg.Shootout = true
+	leg.HomePens, leg.AwayPens = penaltyShootout(home.Power, away.Power)
+	if leg.HomePens > leg.AwayPens {
+		return home.ID, DecidedByPenalties
+	}
+	return away.ID, DecidedByPenalties
+}
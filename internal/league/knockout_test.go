@@ -0,0 +1,157 @@
+package league
+
+import "testing"
+
+func TestSettleLevel(t *testing.T) {
+	tests := []struct {
+		name          string
+		first         *legResult // Opening leg of a two-legged tie, hosted by the away side
+		leg           legResult
+		awayGoals     bool
+		homePower     int // Power 0 never scores in extra time, 400 always does
+		awayPower     int
+		wantWinner    int
+		wantBy        string
+		wantExtraTime bool
+		wantShootout  bool
+	}{
+		{
+			name:       "home win in a single match",
+			leg:        legResult{HomeGoals: 2, AwayGoals: 1},
+			wantWinner: 1,
+			wantBy:     DecidedByNormal,
+		},
+		{
+			name:       "away win in a single match",
+			leg:        legResult{HomeGoals: 0, AwayGoals: 1},
+			wantWinner: 2,
+			wantBy:     DecidedByNormal,
+		},
+		{
+			name:       "aggregate win after losing the first leg",
+			first:      &legResult{HomeGoals: 1, AwayGoals: 0},
+			leg:        legResult{HomeGoals: 2, AwayGoals: 0},
+			wantWinner: 1,
+			wantBy:     DecidedByAggregate,
+		},
+		{
+			name:       "aggregate win of the first leg's hosts",
+			first:      &legResult{HomeGoals: 3, AwayGoals: 0},
+			leg:        legResult{HomeGoals: 2, AwayGoals: 1},
+			awayGoals:  true,
+			wantWinner: 2,
+			wantBy:     DecidedByAggregate,
+		},
+		{
+			name:       "away goals for the home side",
+			first:      &legResult{HomeGoals: 2, AwayGoals: 1},
+			leg:        legResult{HomeGoals: 1, AwayGoals: 0},
+			awayGoals:  true,
+			wantWinner: 1,
+			wantBy:     DecidedByAwayGoals,
+		},
+		{
+			name:       "away goals for the away side",
+			first:      &legResult{HomeGoals: 0, AwayGoals: 0},
+			leg:        legResult{HomeGoals: 1, AwayGoals: 1},
+			awayGoals:  true,
+			wantWinner: 2,
+			wantBy:     DecidedByAwayGoals,
+		},
+		{
+			name:          "level aggregate without the away goals rule",
+			first:         &legResult{HomeGoals: 2, AwayGoals: 1},
+			leg:           legResult{HomeGoals: 1, AwayGoals: 0},
+			homePower:     400,
+			wantWinner:    1,
+			wantBy:        DecidedByExtraTime,
+			wantExtraTime: true,
+		},
+		{
+			name:          "away goals rule ignored in a single match",
+			leg:           legResult{HomeGoals: 1, AwayGoals: 1},
+			awayGoals:     true,
+			awayPower:     400,
+			wantWinner:    2,
+			wantBy:        DecidedByExtraTime,
+			wantExtraTime: true,
+		},
+		{
+			name:          "penalties after a goalless extra time",
+			leg:           legResult{HomeGoals: 0, AwayGoals: 0},
+			wantBy:        DecidedByPenalties,
+			wantExtraTime: true,
+			wantShootout:  true,
+		},
+		{
+			name:          "penalties after a level aggregate and away goals",
+			first:         &legResult{HomeGoals: 1, AwayGoals: 1},
+			leg:           legResult{HomeGoals: 1, AwayGoals: 1},
+			awayGoals:     true,
+			wantBy:        DecidedByPenalties,
+			wantExtraTime: true,
+			wantShootout:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			leg := tt.leg
+			home, away := contender{ID: 1, Power: tt.homePower}, contender{ID: 2, Power: tt.awayPower}
+			winner, by := settleLevel(&leg, home, away, tt.first, tt.awayGoals)
+
+			if by != tt.wantBy || leg.ExtraTime != tt.wantExtraTime || leg.Shootout != tt.wantShootout {
+				t.Fatalf("decided by %q with extra time %v and shootout %v, want %q, %v and %v",
+					by, leg.ExtraTime, leg.Shootout, tt.wantBy, tt.wantExtraTime, tt.wantShootout)
+			}
+			if tt.wantShootout {
+				// The shootout picks the winner, whichever side it is
+				want := away.ID
+				if leg.HomePens > leg.AwayPens {
+					want = home.ID
+				}
+				if winner != want {
+					t.Errorf("shootout %d-%d won by team %d", leg.HomePens, leg.AwayPens, winner)
+				}
+			} else if winner != tt.wantWinner {
+				t.Errorf("got winner %d, want %d", winner, tt.wantWinner)
+			}
+		})
+	}
+}
+
+func TestPenaltyShootout(t *testing.T) {
+	tests := []struct {
+		name      string
+		homePower int
+		awayPower int
+	}{
+		{name: "average teams", homePower: 50, awayPower: 50},
+		{name: "weakest teams", homePower: 1, awayPower: 1},
+		{name: "strongest teams", homePower: 100, awayPower: 100},
+		{name: "mismatch", homePower: 100, awayPower: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for i := 0; i < 1000; i++ {
+				home, away := penaltyShootout(tt.homePower, tt.awayPower)
+				if home == away {
+					t.Fatalf("shootout ended level at %d-%d", home, away)
+				}
+				if home < 0 || away < 0 {
+					t.Fatalf("negative shootout score %d-%d", home, away)
+				}
+
+				// Sudden death ends after the first round one side misses
+				if max(home, away) > penaltyKicks && abs(home-away) != 1 {
+					t.Fatalf("sudden death ended at %d-%d", home, away)
+				}
+
+				// The first five kicks stop as soon as one side cannot catch up, which
+				// happens before a side leads by four
+				if abs(home-away) > 3 {
+					t.Fatalf("shootout went on to %d-%d", home, away)
+				}
+			}
+		})
+	}
+}
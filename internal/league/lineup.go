@@ -0,0 +1,187 @@
+package league
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	storage "go-football-league/internal/repository"
+)
+
+const (
+	lineupSize       = 11
+	benchSize        = 5
+	maxSubstitutions = 3
+)
+
+// lineup holds the players a team can use in a simulated match.
+type lineup struct {
+	starters []int
+	bench    []int
+	hasSquad bool // False when the team has no registered players at all
+}
+
+// appearance records how many minutes a player spent on the pitch in a match.
+type appearance struct {
+	PlayerID int
+	Minutes  int
+}
+
+// selectLineup picks the starting eleven and the bench for a team in the given week.
+// Players with a ban still to serve and injured players are never selected. One goalkeeper is picked first
+// and the remaining places are filled in squad number order.
+func selectLineup(ctx context.Context, teamID, seasonID, week int) (lineup, error) {
+	rows, err := storage.DB.QueryContext(ctx, `
+		SELECT p.id, p.position,
+		       EXISTS (
+		           SELECT 1 FROM suspensions s
+		           WHERE s.player_id = p.id AND s.season_id = ? AND s.served < s.matches
+		       ) OR EXISTS (
+		           SELECT 1 FROM injuries i
+		           WHERE i.player_id = p.id AND i.season_id = ?
+		             AND ? >= i.start_week AND ? < i.start_week + i.weeks_out
+		       )
+		FROM players p
+		WHERE p.team_id = ?
+		ORDER BY p.squad_number
+	`, seasonID, seasonID, week, week, teamID)
+	if err != nil {
+		return lineup{}, err
+	}
+	defer rows.Close()
+
+	var l lineup
+	var goalkeepers, outfield []int
+	for rows.Next() {
+		var id int
+		var position string
+		var unavailable bool
+		if err := rows.Scan(&id, &position, &unavailable); err != nil {
+			return lineup{}, err
+		}
+		l.hasSquad = true
+		if unavailable {
+			continue
+		}
+		if position == "GK" {
+			goalkeepers = append(goalkeepers, id)
+		} else {
+			outfield = append(outfield, id)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return lineup{}, err
+	}
+
+	// First choice goalkeeper, then outfield players; whoever is left goes on the bench
+	var rest []int
+	if len(goalkeepers) > 0 {
+		l.starters = append(l.starters, goalkeepers[0])
+		rest = append(rest, goalkeepers[1:]...)
+	}
+	for i, id := range outfield {
+		if len(l.starters) == lineupSize {
+			rest = append(append([]int{}, outfield[i:]...), rest...)
+			break
+		}
+		l.starters = append(l.starters, id)
+	}
+	if len(rest) > benchSize {
+		rest = rest[:benchSize]
+	}
+	l.bench = rest
+	return l, nil
+}
+
+// lineupStrength scales a team's power rating by the number of players it could field.
+// Teams without a registered squad keep their full rating.
+func lineupStrength(power int, l lineup) int {
+	if !l.hasSquad {
+		return power
+	}
+	return power * len(l.starters) / lineupSize
+}
+
+// playingTime decides how many minutes each player gets. Starters play the full match
+// unless they are replaced by one of the substitutes between the 55th and 85th minute.
+func playingTime(l lineup) []appearance {
+	minutes := make([]appearance, len(l.starters))
+	for i, id := range l.starters {
+		minutes[i] = appearance{PlayerID: id, Minutes: 90}
+	}
+
+	// Goalkeepers are never substituted, so only outfield starters (index 1+) are replaced
+	if len(l.starters) < 2 {
+		return minutes
+	}
+	subs := min(maxSubstitutions, min(len(l.bench), len(l.starters)-1))
+	replaced := random.Perm(len(l.starters) - 1)
+	for i := 0; i < subs; i++ {
+		minute := 55 + random.Intn(31)
+		minutes[replaced[i]+1].Minutes = minute
+		minutes = append(minutes, appearance{PlayerID: l.bench[i], Minutes: 90 - minute})
+	}
+	return minutes
+}
+
+// saveLineup stores the players used in a match together with their minutes played.
+func saveLineup(ctx context.Context, matchID, teamID int, minutes []appearance) error {
+	for _, a := range minutes {
+		_, err := storage.DB.ExecContext(ctx, `
+			INSERT OR IGNORE INTO match_lineups (match_id, player_id, team_id, minutes)
+			VALUES (?, ?, ?, ?)
+		`, matchID, a.PlayerID, teamID, a.Minutes)
+		if err != nil {
+			return fmt.Errorf("Failed to save lineup for match %d: %v", matchID, err)
+		}
+	}
+	return nil
+}
+
+// playerIDs returns the IDs of the players in a list of appearances.
+func playerIDs(minutes []appearance) []int {
+	ids := make([]int, len(minutes))
+	for i, a := range minutes {
+		ids[i] = a.PlayerID
+	}
+	return ids
+}
+
+// matchSide is a team's selection and effective strength for one simulated match.
+type matchSide struct {
+	teamID  int
+	minutes []appearance
+	fatigue map[int]float64
+	power   int
+}
+
+// prepareSide selects a team for a match and works out its effective strength from
+// its base power, the number of players available and how tired the starters are.
+func prepareSide(ctx context.Context, teamID, seasonID, week int, kickoff time.Time, power int) (matchSide, error) {
+	l, err := selectLineup(ctx, teamID, seasonID, week)
+	if err != nil {
+		return matchSide{}, err
+	}
+	minutes := playingTime(l)
+	fatigue, err := playerFatigue(ctx, seasonID, week, kickoff, playerIDs(minutes))
+	if err != nil {
+		return matchSide{}, err
+	}
+	return matchSide{
+		teamID:  teamID,
+		minutes: minutes,
+		fatigue: fatigue,
+		power:   applyFatigue(lineupStrength(power, l), teamFatigue(fatigue, l.starters)),
+	}, nil
+}
+
+// recordSide stores a side's lineup and simulates the cards and injuries of its players.
+func recordSide(ctx context.Context, matchID, seasonID, week int, side matchSide) error {
+	if err := saveLineup(ctx, matchID, side.teamID, side.minutes); err != nil {
+		return err
+	}
+	if err := simulateCards(ctx, matchID, playerIDs(side.minutes)); err != nil {
+		return err
+	}
+	return simulateInjuries(ctx, matchID, seasonID, week, side.minutes, side.fatigue)
+}
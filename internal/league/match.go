@@ -1,45 +1,63 @@
 package league
 
 import (
+	"context"
+	"database/sql"
 	"errors"
 	"fmt"
-	"math/rand"
+	"log/slog"
 	"time"
 
 	models "go-football-league/internal/domain"
 	storage "go-football-league/internal/repository"
 )
 
-// GenerateWeeklyMatches checks whether match fixtures already exist for the specified week.
-// It returns an error if the number of matches is unexpected or fixtures haven't been created yet.
+// GenerateWeeklyMatches checks whether match fixtures already exist for the specified week of the current season.
+// It returns an error if fixtures haven't been created yet.
 func GenerateWeeklyMatches(week int) error {
-	var count int
-	err := storage.DB.QueryRow("SELECT COUNT(*) FROM matches WHERE week = ?", week).Scan(&count)
+	return GenerateWeeklyMatchesContext(context.Background(), week)
+}
+
+// GenerateWeeklyMatchesContext is GenerateWeeklyMatches with a context that cancels the queries.
+func GenerateWeeklyMatchesContext(ctx context.Context, week int) error {
+	seasonID, err := CurrentSeasonIDContext(ctx)
 	if err != nil {
 		return err
 	}
-	if count == 2 {
-		fmt.Printf("Matches already exist for week %d\n", week)
-		return nil
+
+	var count int
+	err = storage.DB.QueryRowContext(ctx, "SELECT COUNT(*) FROM matches WHERE season_id = ? AND week = ?", seasonID, week).Scan(&count)
+	if err != nil {
+		return err
 	}
 	if count == 0 {
 		return errors.New("Fixture not created — please run CreateFixture() first")
 	}
-	if count != 2 {
-		return fmt.Errorf("Unexpected match count for week %d: expected 2, got %d", week, count)
-	}
+	slog.DebugContext(ctx, "Matches already exist", "week", week)
 	return nil
 }
 
-// SimulateScores generates random scores for matches that haven't been played yet, based on the power rating of the home and away teams.
+// SimulateScores generates random scores for matches of the current season that haven't been played yet, based on the power rating of the home and away teams.
 func SimulateScores(week int) error {
-	rows, err := storage.DB.Query(`
-		SELECT m.id, t1.power, t2.power
+	return SimulateScoresContext(context.Background(), week)
+}
+
+// SimulateScoresContext is SimulateScores with a context. A cancelled context stops the
+// simulation before it starts, but once the first match has been simulated the rest of the
+// week is always played, so a cancelled request never leaves a week half played.
+func SimulateScoresContext(ctx context.Context, week int) error {
+	seasonID, err := CurrentSeasonIDContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	rows, err := storage.DB.QueryContext(ctx, `
+		SELECT m.id, m.season_id, m.home_team_id, m.away_team_id, t1.power, t2.power, m.kickoff, m.neutral
 		FROM matches m
 		JOIN teams t1 ON m.home_team_id = t1.id
 		JOIN teams t2 ON m.away_team_id = t2.id
-		WHERE m.week = ? AND m.home_goals IS NULL AND m.away_goals IS NULL
-	`, week)
+		WHERE m.season_id = ? AND m.week = ? AND m.status = 'scheduled'
+	`, seasonID, week)
 	if err != nil {
 		return err
 	}
@@ -47,114 +65,248 @@ func SimulateScores(week int) error {
 
 	type match struct {
 		ID        int
+		SeasonID  int
+		HomeID    int
+		AwayID    int
 		PowerHome int
 		PowerAway int
+		Kickoff   time.Time
+		Neutral   bool
 	}
 	// Collect matches that need score simulation
 	var matches []match			
 	for rows.Next() {
 		var m match
-		if err := rows.Scan(&m.ID, &m.PowerHome, &m.PowerAway); err != nil {
+		var kickoff sql.NullString
+		if err := rows.Scan(&m.ID, &m.SeasonID, &m.HomeID, &m.AwayID, &m.PowerHome, &m.PowerAway, &kickoff, &m.Neutral); err != nil {
+			return err
+		}
+		if m.Kickoff, err = parseKickoff(kickoff, time.UTC); err != nil {
 			return err
 		}
 		matches = append(matches, m)
 	}
+
+	// From here on the context only carries the request ID into the log: the week is played
+	// to the end even when the context is cancelled
+	ctx = context.WithoutCancel(ctx)
+
 	// Randomly generate scores based on team power ratings
 	// The scores are capped to a maximum of 6 goals to prevent unrealistic results
-	// The rand.Seed is set to the current time to ensure different results each time this is important for randomness in simulations	
-	rand.Seed(time.Now().UnixNano())
 	for _, m := range matches {
 		if m.PowerHome < 0 || m.PowerAway < 0 {
 			return fmt.Errorf("Invalid team power for match %d: home %d, away %d", m.ID, m.PowerHome, m.PowerAway)
 		}
-		homeGoals := rand.Intn(min((m.PowerHome/10)+2+1, 6)) 	// +1 point home team advantage
-		awayGoals := rand.Intn(min((m.PowerAway/10)+2, 6)) 		// away team has no advantage
-		fmt.Printf("Match %d simulated → Home: %d | Away: %d\n", m.ID, homeGoals, awayGoals)
-		res, err := storage.DB.Exec(`
-			UPDATE matches SET home_goals = ?, away_goals = ? WHERE id = ?
+
+		// Pick lineups without suspended or injured players; missing and tired players weaken the team
+		home, err := prepareSide(ctx, m.HomeID, m.SeasonID, week, m.Kickoff, m.PowerHome)
+		if err != nil {
+			return fmt.Errorf("Failed to select home lineup for match %d: %v", m.ID, err)
+		}
+		away, err := prepareSide(ctx, m.AwayID, m.SeasonID, week, m.Kickoff, m.PowerAway)
+		if err != nil {
+			return fmt.Errorf("Failed to select away lineup for match %d: %v", m.ID, err)
+		}
+
+		// Neutral ground gives the home side no advantage
+		homeGoals := scoreGoals(home.power, !m.Neutral)
+		awayGoals := scoreGoals(away.power, false)
+		_, err = storage.DB.ExecContext(ctx, `
+			UPDATE matches
+			SET home_goals = ?, away_goals = ?, status = 'played', revision = revision + 1, updated_at = `+nowUTC+`
+			WHERE id = ?
 		`, homeGoals, awayGoals, m.ID)
 		if err != nil {
-			fmt.Printf("Failed to update match %d: %v\n", m.ID, err)
+			return fmt.Errorf("Failed to update match %d: %v", m.ID, err)
+		}
+		slog.DebugContext(ctx, "Match simulated", "match", m.ID, "week", week, "home_goals", homeGoals, "away_goals", awayGoals)
+
+		matchesSimulated.With(competitionLeague).Inc()
+
+		// Record the lineups, book players and pick up injuries
+		if err := recordSide(ctx, m.ID, m.SeasonID, week, home); err != nil {
 			return err
 		}
-		rowsAffected, _ := res.RowsAffected()
-		fmt.Printf("Match %d update affected rows: %d\n", m.ID, rowsAffected)
+		if err := recordSide(ctx, m.ID, m.SeasonID, week, away); err != nil {
+			return err
+		}
+	}
+	if len(matches) > 0 {
+		simulationsRun.With(competitionLeague).Inc()
+		slog.InfoContext(ctx, "Week simulated", "week", week, "matches", len(matches))
 	}
 	return nil
 }
 
-// CreateFixture generates a complete fixture list for a 4-team league over 6 weeks.
-// Each team plays against every other team both home and away.
+// scoreGoals returns a random number of goals for a team with the given power rating.
+// Scores are capped to a maximum of 5 goals to prevent unrealistic results.
+func scoreGoals(power int, homeAdvantage bool) int {
+	power = modelPower(power)
+	if homeAdvantage {
+		return random.Intn(min((power/10)+2+1, 6)) // +1 point home team advantage
+	}
+	return random.Intn(min((power/10)+2, 6)) // away team has no advantage
+}
+
+// CreateFixture generates the complete fixture list of the current season.
+// Within each division every team plays against every other team both home and away.
+// The fixture constraints it could not satisfy are printed. The fixture is stored in
+// one transaction, so a failure leaves no partial fixture behind.
 func CreateFixture() error {
+	return CreateFixtureContext(context.Background())
+}
+
+// CreateFixtureContext is CreateFixture with a context that cancels the queries and, before
+// it commits, the fixture.
+func CreateFixtureContext(ctx context.Context) error {
+	seasonID, err := CurrentSeasonIDContext(ctx)
+	if err != nil {
+		return fmt.Errorf("Failed to determine current season: %v", err)
+	}
+
+	tx, err := storage.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := createFixture(ctx, tx, seasonID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// createFixture generates and schedules the fixture list of a season on a database or
+// transaction, unless the season already has one.
+func createFixture(ctx context.Context, q storage.Querier, seasonID int) error {
 	var existing int
-	err := storage.DB.QueryRow("SELECT COUNT(*) FROM matches").Scan(&existing)
+	err := q.QueryRowContext(ctx, "SELECT COUNT(*) FROM matches WHERE season_id = ?", seasonID).Scan(&existing)
 	if err != nil {
 		return fmt.Errorf("Failed to check existing fixture: %v", err)
 	}
 	if existing > 0 {
-		fmt.Println("Fixture already exists. Skipping creation.")
+		slog.InfoContext(ctx, "Fixture already exists, skipping creation", "season", seasonID)
 		return nil
 	}
 
-	rows, err := storage.DB.Query("SELECT id FROM teams ORDER BY id")
+	rows, err := q.QueryContext(ctx, `
+		SELECT d.id, d.name, t.id
+		FROM teams t
+		JOIN divisions d ON t.division_id = d.id
+		WHERE t.active = 1
+		ORDER BY d.tier, t.id
+	`)
 	if err != nil {
 		return err
 	}
 	defer rows.Close()
 
-	var teamIDs []int
+	// Group the teams by division
+	type division struct {
+		ID      int
+		Name    string
+		TeamIDs []int
+	}
+	var divisions []division
 	for rows.Next() {
-		var id int
-		if err := rows.Scan(&id); err != nil {
+		var divisionID, teamID int
+		var name string
+		if err := rows.Scan(&divisionID, &name, &teamID); err != nil {
 			return err
 		}
-		teamIDs = append(teamIDs, id)
+		if len(divisions) == 0 || divisions[len(divisions)-1].ID != divisionID {
+			divisions = append(divisions, division{ID: divisionID, Name: name})
+		}
+		d := &divisions[len(divisions)-1]
+		d.TeamIDs = append(d.TeamIDs, teamID)
+	}
+	if err := rows.Err(); err != nil {
+		return err
 	}
 
-	if len(teamIDs) != 4 {
-		return errors.New("Fixture generation requires exactly 4 teams")
+	if len(divisions) == 0 {
+		return errors.New("Fixture generation requires at least 2 teams")
+	}
+	for _, d := range divisions {
+		if len(d.TeamIDs) < 2 {
+			return fmt.Errorf("Fixture generation requires at least 2 teams in %s", d.Name)
+		}
 	}
 
-	// Static fixture for a 4-team round-robin league (home and away system)
-	type Match struct {
-		Week int
-		Home int
-		Away int
+	// Round-robin fixture for every division (home and away system), arranged to satisfy
+	// the fixture constraints as far as possible
+	rules, err := loadScheduleRules(ctx, q, seasonID)
+	if err != nil {
+		return fmt.Errorf("Failed to load fixture constraints: %v", err)
+	}
+	names, err := teamNames(ctx, q)
+	if err != nil {
+		return err
 	}
-	fixture := []Match{
-		{1, teamIDs[0], teamIDs[1]}, {1, teamIDs[2], teamIDs[3]},
-		{2, teamIDs[0], teamIDs[2]}, {2, teamIDs[1], teamIDs[3]},
-		{3, teamIDs[0], teamIDs[3]}, {3, teamIDs[1], teamIDs[2]},
-		{4, teamIDs[1], teamIDs[0]}, {4, teamIDs[3], teamIDs[2]},
-		{5, teamIDs[2], teamIDs[0]}, {5, teamIDs[3], teamIDs[1]},
-		{6, teamIDs[3], teamIDs[0]}, {6, teamIDs[2], teamIDs[1]},
+	teamIDs := make([][]int, len(divisions))
+	for i, d := range divisions {
+		teamIDs[i] = d.TeamIDs
 	}
+	fixtures, violations := scheduleFixture(teamIDs, rules, names)
 
-	for _, match := range fixture {
-		_, err := storage.DB.Exec(`
-			INSERT INTO matches (week, home_team_id, away_team_id, home_goals, away_goals)
-			VALUES (?, ?, ?, NULL, NULL)
-		`, match.Week, match.Home, match.Away)
-		if err != nil {
-			return fmt.Errorf("Failed to insert match: %v", err)
+	for i, d := range divisions {
+		for _, match := range fixtures[i] {
+			_, err := q.ExecContext(ctx, `
+				INSERT INTO matches (season_id, division_id, week, home_team_id, away_team_id, home_goals, away_goals)
+				VALUES (?, ?, ?, ?, ?, NULL, NULL)
+			`, seasonID, d.ID, match.Week, match.Home, match.Away)
+			if err != nil {
+				return fmt.Errorf("Failed to insert match: %v", err)
+			}
 		}
 	}
 
-	fmt.Println("Fixture created successfully.")
+	// Spread every match week over its Friday to Monday kickoff slots
+	if err := scheduleSeason(ctx, q, seasonID); err != nil {
+		return err
+	}
+
+	slog.InfoContext(ctx, "Fixture created", "season", seasonID)
+	for _, v := range violations {
+		slog.WarnContext(ctx, "Fixture constraint not satisfied", "season", seasonID, "violation", describeViolation(v))
+	}
 	return nil
 }
 
-// GetMatchesByWeek retrieves all matches played in a given week,
+// GetMatchesByWeek retrieves all matches of the current season played in a given week,
 // Tncluding team names and match details.
 func GetMatchesByWeek(week int) ([]models.Match, error) {
-	rows, err := storage.DB.Query(`
+	return GetMatchesByWeekContext(context.Background(), week)
+}
+
+// GetMatchesByWeekContext is GetMatchesByWeek with a context that cancels the queries.
+func GetMatchesByWeekContext(ctx context.Context, week int) ([]models.Match, error) {
+	seasonID, err := CurrentSeasonIDContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	_, loc, err := seasonCalendar(ctx, seasonID)
+	if err != nil {
+		return nil, err
+	}
+	return queryMatches(ctx, loc, "m.season_id = ? AND m.week = ?", seasonID, week)
+}
+
+// queryMatches reads the matches matching a filter on the matches table (alias m),
+// ordered by kickoff, with kickoffs rendered in the given time zone.
+func queryMatches(ctx context.Context, loc *time.Location, filter string, args ...interface{}) ([]models.Match, error) {
+	rows, err := storage.DB.QueryContext(ctx, `
 		SELECT m.id, m.week, m.home_team_id, m.away_team_id, m.home_goals, m.away_goals,
-		       ht.name as home_team_name, at.name as away_team_name
+		       ht.name as home_team_name, at.name as away_team_name, m.kickoff,
+		       m.status, COALESCE(m.original_week, 0), m.neutral, v.id, v.name, v.city, v.capacity
 		FROM matches m
 		JOIN teams ht ON m.home_team_id = ht.id
 		JOIN teams at ON m.away_team_id = at.id
-		WHERE m.week = ?
-	`, week)
+		LEFT JOIN venues v ON v.id = COALESCE(m.venue_id, ht.venue_id)
+		WHERE `+filter+`
+		ORDER BY m.kickoff, m.id
+	`, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -163,24 +315,65 @@ func GetMatchesByWeek(week int) ([]models.Match, error) {
 	var matches []models.Match
 	for rows.Next() {
 		var m models.Match
-		err := rows.Scan(&m.ID, &m.Week, &m.HomeTeamID, &m.AwayTeamID, &m.HomeGoals, &m.AwayGoals, &m.HomeTeamName, &m.AwayTeamName)
+		var homeGoals, awayGoals sql.NullInt64
+		var kickoff, venueName, venueCity sql.NullString
+		var venueID, venueCapacity sql.NullInt64
+		err := rows.Scan(&m.ID, &m.Week, &m.HomeTeamID, &m.AwayTeamID, &homeGoals, &awayGoals, &m.HomeTeamName, &m.AwayTeamName, &kickoff,
+			&m.Status, &m.OriginalWeek, &m.Neutral, &venueID, &venueName, &venueCity, &venueCapacity)
 		if err != nil {
 			return nil, err
 		}
+		if venueID.Valid {
+			m.Venue = &models.Venue{ID: int(venueID.Int64), Name: venueName.String, City: venueCity.String, Capacity: int(venueCapacity.Int64)}
+		}
+		m.HomeGoals, m.AwayGoals = int(homeGoals.Int64), int(awayGoals.Int64)
+		if m.Kickoff, err = parseKickoff(kickoff, loc); err != nil {
+			return nil, err
+		}
 		matches = append(matches, m)
 	}
 
-	return matches, nil
+	return matches, rows.Err()
 }
 
 // UpdateMatchResult updates the result of a specific match with new goal values.
+// Only scheduled and played matches take a result: a postponed or abandoned match is
+// rescheduled first, so it counts in the week it is played, and an awarded result stands.
 func UpdateMatchResult(matchID int, homeGoals, awayGoals int) error {
-	_, err := storage.DB.Exec(`
+	return UpdateMatchResultContext(context.Background(), matchID, homeGoals, awayGoals)
+}
+
+// UpdateMatchResultContext is UpdateMatchResult with a context that cancels the update.
+func UpdateMatchResultContext(ctx context.Context, matchID int, homeGoals, awayGoals int) error {
+	res, err := storage.DB.ExecContext(ctx, `
 		UPDATE matches
-		SET home_goals = ?, away_goals = ?
-		WHERE id = ?
+		SET home_goals = ?, away_goals = ?, status = 'played', revision = revision + 1, updated_at = `+nowUTC+`
+		WHERE id = ? AND status IN ('scheduled', 'played')
 	`, homeGoals, awayGoals, matchID)
-	return err
+	if err != nil {
+		return fmt.Errorf("Failed to update match %d: %v", matchID, err)
+	}
+	if n, _ := res.RowsAffected(); n > 0 {
+		return nil
+	}
+
+	// Nothing changed: the match does not exist or cannot take a result
+	_, status, err := matchState(ctx, matchID)
+	if err != nil {
+		return err
+	}
+	return fmt.Errorf("%w: match %d is %s; only scheduled or played matches take a result", ErrMatchStatus, matchID, status)
+}
+
+// ParseScore reads a score written as home and away goals, e.g. "2-1".
+func ParseScore(score string) (int, int, error) {
+	var homeGoals, awayGoals int
+	var rest string
+	n, _ := fmt.Sscanf(score, "%d-%d%s", &homeGoals, &awayGoals, &rest)
+	if n != 2 || homeGoals < 0 || awayGoals < 0 {
+		return 0, 0, fmt.Errorf("Invalid score %q, expected e.g. 2-1", score)
+	}
+	return homeGoals, awayGoals, nil
 }
 
 // min returns the smaller of two integers.
@@ -0,0 +1,19 @@
+package league
+
+import "go-football-league/internal/metrics"
+
+// Competitions the simulation metrics are labelled with. Playoffs are played as cups.
+const (
+	competitionLeague     = "league"
+	competitionCup        = "cup"
+	competitionTournament = "tournament"
+)
+
+var (
+	simulationsRun = metrics.NewCounterVec("league_simulations_total",
+		"Simulation runs: league weeks, cup rounds and tournament group matchdays.", "competition")
+	matchesSimulated = metrics.NewCounterVec("league_matches_simulated_total",
+		"Matches, or cup legs, whose scores were simulated and stored.", "competition")
+	predictionIterations = metrics.NewCounter("league_prediction_iterations_total",
+		"Seasons simulated by the championship predictor.")
+)
@@ -0,0 +1,245 @@
+package league
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	models "go-football-league/internal/domain"
+	storage "go-football-league/internal/repository"
+)
+
+// CreatePlayoffs seeds the playoffs of a division from its final league table in the
+// current season and returns the ID of the playoff bracket's cup. The best placed
+// playoff team meets the worst placed one in the semi-finals. The bracket and the
+// playoffs are stored in one transaction.
+func CreatePlayoffs(divisionID int) (int, error) {
+	return CreatePlayoffsContext(context.Background(), divisionID)
+}
+
+// CreatePlayoffsContext is CreatePlayoffs with a context that cancels the queries.
+func CreatePlayoffsContext(ctx context.Context, divisionID int) (int, error) {
+	seasonID, err := CurrentSeasonIDContext(ctx)
+	if err != nil {
+		return 0, err
+	}
+	d, err := getDivision(ctx, divisionID)
+	if err != nil {
+		return 0, err
+	}
+	if d.PlayoffPlaces < 2 {
+		return 0, fmt.Errorf("%s has no playoff places", d.Name)
+	}
+
+	var exists bool
+	err = storage.DB.QueryRowContext(ctx, `
+		SELECT EXISTS (SELECT 1 FROM playoffs WHERE season_id = ? AND division_id = ?)
+	`, seasonID, divisionID).Scan(&exists)
+	if err != nil {
+		return 0, err
+	}
+	if exists {
+		return 0, fmt.Errorf("Playoffs of %s have already been drawn", d.Name)
+	}
+
+	var unplayed int
+	err = storage.DB.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM matches
+		WHERE season_id = ? AND division_id = ? AND status NOT IN ('played', 'awarded')
+	`, seasonID, divisionID).Scan(&unplayed)
+	if err != nil {
+		return 0, err
+	}
+	if unplayed > 0 {
+		return 0, fmt.Errorf("%s still has %d unplayed match(es)", d.Name, unplayed)
+	}
+
+	seeds, err := playoffSeeds(ctx, seasonID, d)
+	if err != nil {
+		return 0, err
+	}
+	teamIDs := make([]int, len(seeds))
+	for i, row := range seeds {
+		teamIDs[i] = row.TeamID
+	}
+	teams, err := loadContenders(ctx, storage.DB, teamIDs)
+	if err != nil {
+		return 0, err
+	}
+
+	tx, err := storage.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	cupID, err := createCup(ctx, tx, seasonID, CupOptions{
+		Name:      d.Name + " Playoffs",
+		TeamIDs:   teamIDs,
+		Seeded:    true,
+		TwoLegged: true,
+	}, teams)
+	if err != nil {
+		return 0, err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO playoffs (season_id, division_id, cup_id) VALUES (?, ?, ?)
+	`, seasonID, divisionID, cupID)
+	if err != nil {
+		return 0, fmt.Errorf("Failed to create playoffs: %v", err)
+	}
+	return cupID, tx.Commit()
+}
+
+// playoffSeeds returns the final table rows of a division's playoff places, best first.
+func playoffSeeds(ctx context.Context, seasonID int, d models.Division) ([]models.LeagueTableRow, error) {
+	weeks, err := seasonWeeks(ctx, seasonID)
+	if err != nil {
+		return nil, err
+	}
+	table, err := divisionTable(ctx, seasonID, d.ID, weeks, "", TableOptions{})
+	if err != nil {
+		return nil, err
+	}
+	if len(table) < d.PromotionPlaces+d.PlayoffPlaces {
+		return nil, fmt.Errorf("%s does not have enough teams to fill %d playoff places", d.Name, d.PlayoffPlaces)
+	}
+	return table[d.PromotionPlaces : d.PromotionPlaces+d.PlayoffPlaces], nil
+}
+
+// PlayPlayoffRound simulates the next round of a division's playoffs in the current season,
+// drawing the playoffs first if needed. Semi-finals are played over two legs with the
+// better placed team at home in the second leg; the final is a single match on neutral
+// ground. The winner of the final is recorded as the promoted team.
+func PlayPlayoffRound(divisionID int) error {
+	return PlayPlayoffRoundContext(context.Background(), divisionID)
+}
+
+// PlayPlayoffRoundContext is PlayPlayoffRound with a context. A cancelled context stops the
+// round before its first tie is played, never halfway through.
+func PlayPlayoffRoundContext(ctx context.Context, divisionID int) error {
+	seasonID, err := CurrentSeasonIDContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	var playoffID, cupID int
+	var promoted sql.NullInt64
+	err = storage.DB.QueryRowContext(ctx, `
+		SELECT id, cup_id, promoted_team_id FROM playoffs WHERE season_id = ? AND division_id = ?
+	`, seasonID, divisionID).Scan(&playoffID, &cupID, &promoted)
+	if err == sql.ErrNoRows {
+		if _, err := CreatePlayoffsContext(ctx, divisionID); err != nil {
+			return err
+		}
+		return PlayPlayoffRoundContext(ctx, divisionID)
+	}
+	if err != nil {
+		return err
+	}
+	if promoted.Valid {
+		return fmt.Errorf("Playoffs of division %d have already been completed", divisionID)
+	}
+
+	var round, ties int
+	err = storage.DB.QueryRowContext(ctx, `
+		SELECT round, COUNT(*) FROM cup_ties
+		WHERE cup_id = ? AND round = (SELECT MAX(round) FROM cup_ties WHERE cup_id = ?)
+	`, cupID, cupID).Scan(&round, &ties)
+	if err != nil {
+		return err
+	}
+
+	// Only the read of the ties is cancelled, so a round that has begun is committed in full
+	tx, err := storage.DB.BeginTx(context.WithoutCancel(ctx), nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	rules := tieRules{TwoLegged: true, SeedLast: true}
+	if ties == 1 {
+		rules = tieRules{Neutral: true}
+	}
+	if err := playKnockoutRound(ctx, tx, cupID, round, rules); err != nil {
+		return err
+	}
+
+	// The round has been played, so the winners go through even when ctx is cancelled
+	ctx = context.WithoutCancel(ctx)
+	if err := advanceCup(ctx, tx, cupID, round); err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE playoffs SET promoted_team_id = (SELECT winner_team_id FROM cups WHERE id = ?)
+		WHERE id = ?
+	`, cupID, playoffID)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// PlayPlayoffs plays all remaining rounds of a division's playoffs in the current season.
+func PlayPlayoffs(divisionID int) error {
+	for {
+		// A missing bracket is drawn by the first round played
+		p, err := GetPlayoffs(divisionID)
+		if err == nil && p.PromotedTeamID != 0 {
+			return nil
+		}
+		if err := PlayPlayoffRound(divisionID); err != nil {
+			return err
+		}
+	}
+}
+
+// GetPlayoffs returns the seeds, bracket and promoted team of a division's playoffs in the current season.
+func GetPlayoffs(divisionID int) (models.Playoff, error) {
+	return GetPlayoffsContext(context.Background(), divisionID)
+}
+
+// GetPlayoffsContext is GetPlayoffs with a context that cancels the queries.
+func GetPlayoffsContext(ctx context.Context, divisionID int) (models.Playoff, error) {
+	seasonID, err := CurrentSeasonIDContext(ctx)
+	if err != nil {
+		return models.Playoff{}, err
+	}
+	return getPlayoffs(ctx, seasonID, divisionID)
+}
+
+// getPlayoffs returns a division's playoffs in the given season.
+func getPlayoffs(ctx context.Context, seasonID, divisionID int) (models.Playoff, error) {
+	var p models.Playoff
+	var cupID int
+	var promotedID sql.NullInt64
+	var promotedName sql.NullString
+	err := storage.DB.QueryRowContext(ctx, `
+		SELECT p.id, p.season_id, p.division_id, d.name, p.cup_id, p.promoted_team_id, t.name
+		FROM playoffs p
+		JOIN divisions d ON p.division_id = d.id
+		LEFT JOIN teams t ON p.promoted_team_id = t.id
+		WHERE p.season_id = ? AND p.division_id = ?
+	`, seasonID, divisionID).Scan(&p.ID, &p.SeasonID, &p.DivisionID, &p.DivisionName,
+		&cupID, &promotedID, &promotedName)
+	if err == sql.ErrNoRows {
+		return p, fmt.Errorf("No playoffs for division %d in season %d", divisionID, seasonID)
+	}
+	if err != nil {
+		return p, err
+	}
+	p.PromotedTeamID = int(promotedID.Int64)
+	p.PromotedTeamName = promotedName.String
+
+	d, err := getDivision(ctx, divisionID)
+	if err != nil {
+		return p, err
+	}
+	if p.Seeds, err = playoffSeeds(ctx, seasonID, d); err != nil {
+		return p, err
+	}
+	p.Bracket, err = GetCupBracketContext(ctx, cupID)
+	return p, err
+}
@@ -0,0 +1,137 @@
+package league
+
+import (
+	"context"
+
+	models "go-football-league/internal/domain"
+	storage "go-football-league/internal/repository"
+)
+
+// GetPositionHistory returns every team's position in its division and its points after
+// each week of a season in which matches were played, top division first and teams in
+// their latest order. The standings are built up in a single pass over the season's results.
+func GetPositionHistory(seasonID int) ([]models.PositionHistory, error) {
+	return GetPositionHistoryContext(context.Background(), seasonID)
+}
+
+// GetPositionHistoryContext is GetPositionHistory with a context that cancels the queries.
+func GetPositionHistoryContext(ctx context.Context, seasonID int) ([]models.PositionHistory, error) {
+	if _, err := getSeason(ctx, seasonID); err != nil {
+		return nil, err
+	}
+
+	// Every team with a fixture in the season gets a line, even before its first match
+	teamRows, err := storage.DB.QueryContext(ctx, `
+		SELECT DISTINCT t.id, t.name, d.id, d.name, d.tier
+		FROM matches m
+		JOIN teams t ON t.id IN (m.home_team_id, m.away_team_id)
+		JOIN divisions d ON m.division_id = d.id
+		WHERE m.season_id = ?
+		ORDER BY d.tier, t.name
+	`, seasonID)
+	if err != nil {
+		return nil, err
+	}
+	defer teamRows.Close()
+
+	type division struct {
+		ID    int
+		Teams []int
+	}
+	var divisions []division
+	rows := make(map[int]*models.LeagueTableRow)
+	histories := make(map[int]*models.PositionHistory)
+	for teamRows.Next() {
+		var h models.PositionHistory
+		var tier int
+		if err := teamRows.Scan(&h.TeamID, &h.TeamName, &h.DivisionID, &h.DivisionName, &tier); err != nil {
+			return nil, err
+		}
+		if len(divisions) == 0 || divisions[len(divisions)-1].ID != h.DivisionID {
+			divisions = append(divisions, division{ID: h.DivisionID})
+		}
+		d := &divisions[len(divisions)-1]
+		d.Teams = append(d.Teams, h.TeamID)
+		h.Weeks = []models.WeekPosition{}
+		histories[h.TeamID] = &h
+		rows[h.TeamID] = &models.LeagueTableRow{TeamID: h.TeamID, TeamName: h.TeamName}
+	}
+	if err := teamRows.Err(); err != nil {
+		return nil, err
+	}
+
+	// Results in the order they were played, with the fair-play points each side picked up
+	results, err := storage.DB.QueryContext(ctx, `
+		SELECT m.week, m.home_team_id, m.away_team_id, m.home_goals, m.away_goals,
+		       COALESCE(SUM(CASE WHEN c.team_id = m.home_team_id THEN
+		           CASE c.card WHEN 'Y' THEN ?1 ELSE ?2 END END), 0),
+		       COALESCE(SUM(CASE WHEN c.team_id = m.away_team_id THEN
+		           CASE c.card WHEN 'Y' THEN ?1 ELSE ?2 END END), 0)
+		FROM matches m
+		LEFT JOIN match_cards c ON c.match_id = m.id
+		WHERE m.season_id = ?3 AND m.status IN ('played', 'awarded')
+		  AND m.home_goals IS NOT NULL AND m.away_goals IS NOT NULL
+		GROUP BY m.id
+		ORDER BY m.week, m.kickoff, m.id
+	`, yellowCardPoints, redCardPoints, seasonID)
+	if err != nil {
+		return nil, err
+	}
+	defer results.Close()
+
+	fairPlay := make(map[int]int)
+	// rankWeek records every team's position at the end of a week
+	rankWeek := func(week int) {
+		for _, d := range divisions {
+			table := make([]models.LeagueTableRow, 0, len(d.Teams))
+			for _, teamID := range d.Teams {
+				table = append(table, *rows[teamID])
+			}
+			sortStandings(table, fairPlay)
+			for i, row := range table {
+				h := histories[row.TeamID]
+				h.Weeks = append(h.Weeks, models.WeekPosition{Week: week, Position: i + 1, Points: row.Points})
+			}
+		}
+	}
+
+	week := 0
+	for results.Next() {
+		var w, homeID, awayID, homeGoals, awayGoals, homeFairPlay, awayFairPlay int
+		if err := results.Scan(&w, &homeID, &awayID, &homeGoals, &awayGoals, &homeFairPlay, &awayFairPlay); err != nil {
+			return nil, err
+		}
+		if w != week && week != 0 {
+			rankWeek(week)
+		}
+		week = w
+
+		addResult(rows[homeID], homeGoals, awayGoals)
+		addResult(rows[awayID], awayGoals, homeGoals)
+		fairPlay[homeID] += homeFairPlay
+		fairPlay[awayID] += awayFairPlay
+	}
+	if err := results.Err(); err != nil {
+		return nil, err
+	}
+	if week != 0 {
+		rankWeek(week)
+	}
+
+	// Top division first, each ordered by its latest standings
+	history := []models.PositionHistory{}
+	for _, d := range divisions {
+		first := len(history)
+		for _, teamID := range d.Teams {
+			history = append(history, *histories[teamID])
+		}
+		latest := history[first:]
+		if week != 0 {
+			for _, h := range d.Teams {
+				last := histories[h].Weeks[len(histories[h].Weeks)-1]
+				latest[last.Position-1] = *histories[h]
+			}
+		}
+	}
+	return history, nil
+}
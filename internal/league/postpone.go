@@ -0,0 +1,262 @@
+package league
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	models "go-football-league/internal/domain"
+	storage "go-football-league/internal/repository"
+)
+
+// Match statuses.
+const (
+	StatusScheduled = "scheduled"
+	StatusPostponed = "postponed"
+	StatusAbandoned = "abandoned"
+	StatusPlayed    = "played"
+	StatusAwarded   = "awarded"
+)
+
+// Errors about a match, wrapped with the details so callers can tell them apart.
+var (
+	ErrMatchNotFound = errors.New("Match does not exist")
+	ErrMatchStatus   = errors.New("Match status does not allow the change")
+)
+
+// awardedGoals is the score given to the winner of an awarded match; the loser gets none.
+const awardedGoals = 3
+
+// GetMatch returns a single match with its status and kickoff in the league's time zone.
+func GetMatch(matchID int) (models.Match, error) {
+	return GetMatchContext(context.Background(), matchID)
+}
+
+// GetMatchContext is GetMatch with a context that cancels the queries.
+func GetMatchContext(ctx context.Context, matchID int) (models.Match, error) {
+	seasonID, _, err := matchState(ctx, matchID)
+	if err != nil {
+		return models.Match{}, err
+	}
+	_, loc, err := seasonCalendar(ctx, seasonID)
+	if err != nil {
+		return models.Match{}, err
+	}
+	matches, err := queryMatches(ctx, loc, "m.id = ?", matchID)
+	if err != nil {
+		return models.Match{}, err
+	}
+	return matches[0], nil
+}
+
+// GetResultChanges returns the audit log of a match's results, oldest first. Every change
+// of its score or status is logged by the database, whichever way it was made.
+func GetResultChanges(matchID int) ([]models.ResultChange, error) {
+	return GetResultChangesContext(context.Background(), matchID)
+}
+
+// GetResultChangesContext is GetResultChanges with a context that cancels the queries.
+func GetResultChangesContext(ctx context.Context, matchID int) ([]models.ResultChange, error) {
+	if _, _, err := matchState(ctx, matchID); err != nil {
+		return nil, err
+	}
+	rows, err := storage.DB.QueryContext(ctx, `
+		SELECT id, match_id, revision, old_status, old_home_goals, old_away_goals,
+		       new_status, new_home_goals, new_away_goals, changed_at
+		FROM result_changes
+		WHERE match_id = ?
+		ORDER BY id
+	`, matchID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	changes := []models.ResultChange{}
+	for rows.Next() {
+		var c models.ResultChange
+		err := rows.Scan(&c.ID, &c.MatchID, &c.Revision, &c.OldStatus, &c.OldHomeGoals, &c.OldAwayGoals,
+			&c.NewStatus, &c.NewHomeGoals, &c.NewAwayGoals, &c.ChangedAt)
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, c)
+	}
+	return changes, rows.Err()
+}
+
+// matchState returns the season and status of a match.
+func matchState(ctx context.Context, matchID int) (int, string, error) {
+	var seasonID int
+	var status string
+	err := storage.DB.QueryRowContext(ctx, "SELECT season_id, status FROM matches WHERE id = ?", matchID).Scan(&seasonID, &status)
+	if err == sql.ErrNoRows {
+		return 0, "", fmt.Errorf("%w: %d", ErrMatchNotFound, matchID)
+	}
+	return seasonID, status, err
+}
+
+// PostponeMatch marks a scheduled match as postponed. It is not simulated until it is rescheduled.
+func PostponeMatch(matchID int) error {
+	return PostponeMatchContext(context.Background(), matchID)
+}
+
+// PostponeMatchContext is PostponeMatch with a context that cancels the update.
+func PostponeMatchContext(ctx context.Context, matchID int) error {
+	_, status, err := matchState(ctx, matchID)
+	if err != nil {
+		return err
+	}
+	if status != StatusScheduled {
+		return fmt.Errorf("Only scheduled matches can be postponed; match %d is %s", matchID, status)
+	}
+	return setMatchStatus(ctx, matchID, StatusPostponed)
+}
+
+// AbandonMatch marks a match as abandoned and clears its result. It has to be rescheduled and replayed.
+func AbandonMatch(matchID int) error {
+	return AbandonMatchContext(context.Background(), matchID)
+}
+
+// AbandonMatchContext is AbandonMatch with a context that cancels the update.
+func AbandonMatchContext(ctx context.Context, matchID int) error {
+	_, status, err := matchState(ctx, matchID)
+	if err != nil {
+		return err
+	}
+	if status != StatusScheduled && status != StatusPlayed {
+		return fmt.Errorf("Only scheduled or played matches can be abandoned; match %d is %s", matchID, status)
+	}
+	return setMatchStatus(ctx, matchID, StatusAbandoned)
+}
+
+// ClearMatchResult removes the result of a played match, so it is scheduled again.
+func ClearMatchResult(matchID int) error {
+	return ClearMatchResultContext(context.Background(), matchID)
+}
+
+// ClearMatchResultContext is ClearMatchResult with a context that cancels the update.
+func ClearMatchResultContext(ctx context.Context, matchID int) error {
+	_, status, err := matchState(ctx, matchID)
+	if err != nil {
+		return err
+	}
+	if status != StatusPlayed {
+		return fmt.Errorf("Only played matches can be cleared; match %d is %s", matchID, status)
+	}
+	return setMatchStatus(ctx, matchID, StatusScheduled)
+}
+
+// setMatchStatus changes the status of a match without a result.
+func setMatchStatus(ctx context.Context, matchID int, status string) error {
+	_, err := storage.DB.ExecContext(ctx, `
+		UPDATE matches
+		SET status = ?, home_goals = NULL, away_goals = NULL, revision = revision + 1, updated_at = `+nowUTC+`
+		WHERE id = ?
+	`, status, matchID)
+	if err != nil {
+		return fmt.Errorf("Failed to update match %d: %v", matchID, err)
+	}
+	return nil
+}
+
+// RescheduleMatch moves a match that has not been played to another week and kickoff.
+// A zero week takes the week of the kickoff, or keeps the current week without one; a zero
+// kickoff uses the first kickoff slot of the week. A kickoff outside the given week is an
+// error. The match is scheduled again, and the week it was first scheduled in is remembered.
+// A kickoff given here is kept when the season's calendar changes.
+func RescheduleMatch(matchID, week int, kickoff time.Time) error {
+	return RescheduleMatchContext(context.Background(), matchID, week, kickoff)
+}
+
+// RescheduleMatchContext is RescheduleMatch with a context that cancels the update.
+func RescheduleMatchContext(ctx context.Context, matchID, week int, kickoff time.Time) error {
+	seasonID, status, err := matchState(ctx, matchID)
+	if err != nil {
+		return err
+	}
+	if status == StatusPlayed || status == StatusAwarded {
+		return fmt.Errorf("Match %d has already been %s", matchID, status)
+	}
+	if week < 0 {
+		return errors.New("Week must be 1 or higher")
+	}
+	start, _, err := seasonCalendar(ctx, seasonID)
+	if err != nil {
+		return err
+	}
+	kickoffSet := !kickoff.IsZero()
+	switch {
+	case kickoff.IsZero():
+		if week == 0 {
+			if err := storage.DB.QueryRowContext(ctx, "SELECT week FROM matches WHERE id = ?", matchID).Scan(&week); err != nil {
+				return err
+			}
+		}
+		kickoff = slotKickoff(start, week, matchweekSlots[0])
+	case kickoffWeek(start, kickoff) == 0:
+		return fmt.Errorf("Kickoff %s is before the season starts", kickoff.Format(time.RFC3339))
+	case week == 0:
+		week = kickoffWeek(start, kickoff)
+	case kickoffWeek(start, kickoff) != week:
+		return fmt.Errorf("Kickoff %s is in week %d, not week %d", kickoff.Format(time.RFC3339), kickoffWeek(start, kickoff), week)
+	}
+
+	_, err = storage.DB.ExecContext(ctx, `
+		UPDATE matches
+		SET original_week = CASE WHEN week = ? THEN original_week ELSE COALESCE(original_week, week) END,
+		    week = ?, kickoff = ?, kickoff_set = ?, status = ?, revision = revision + 1, updated_at = `+nowUTC+`
+		WHERE id = ?
+	`, week, week, formatKickoff(kickoff), kickoffSet, StatusScheduled, matchID)
+	if err != nil {
+		return fmt.Errorf("Failed to reschedule match %d: %v", matchID, err)
+	}
+	return nil
+}
+
+// AwardMatch records an awarded result, such as a forfeit, in favour of the given team.
+// The winner is credited with awardedGoals goals to nil and the match is marked as awarded.
+// Only scheduled, postponed and abandoned matches can be awarded; a played match is abandoned first.
+func AwardMatch(matchID, winnerTeamID int) error {
+	return AwardMatchContext(context.Background(), matchID, winnerTeamID)
+}
+
+// AwardMatchContext is AwardMatch with a context that cancels the update.
+func AwardMatchContext(ctx context.Context, matchID, winnerTeamID int) error {
+	var homeID, awayID int
+	var status string
+	err := storage.DB.QueryRowContext(ctx, `
+		SELECT home_team_id, away_team_id, status FROM matches WHERE id = ?
+	`, matchID).Scan(&homeID, &awayID, &status)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("%w: %d", ErrMatchNotFound, matchID)
+	}
+	if err != nil {
+		return err
+	}
+	if status != StatusScheduled && status != StatusPostponed && status != StatusAbandoned {
+		return fmt.Errorf("%w: match %d is %s; only scheduled, postponed or abandoned matches can be awarded", ErrMatchStatus, matchID, status)
+	}
+
+	homeGoals, awayGoals := 0, 0
+	switch winnerTeamID {
+	case homeID:
+		homeGoals = awardedGoals
+	case awayID:
+		awayGoals = awardedGoals
+	default:
+		return fmt.Errorf("Team %d does not play in match %d", winnerTeamID, matchID)
+	}
+
+	_, err = storage.DB.ExecContext(ctx, `
+		UPDATE matches
+		SET home_goals = ?, away_goals = ?, status = ?, revision = revision + 1, updated_at = `+nowUTC+`
+		WHERE id = ?
+	`, homeGoals, awayGoals, StatusAwarded, matchID)
+	if err != nil {
+		return fmt.Errorf("Failed to award match %d: %v", matchID, err)
+	}
+	return nil
+}
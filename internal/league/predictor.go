@@ -1,57 +1,186 @@
 package league
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"math"
 	"sort"
+
 	"go-football-league/internal/domain"
+	storage "go-football-league/internal/repository"
 )
-// PrintChampionshipPredictions displays the league title chances for each team based on the current standings of the given week.
-// It only runs if the league has progressed to at least week 4.
-func PrintChampionshipPredictions(week int, table []models.LeagueTableRow) {
-	if week < 4 {
-		// Not enough data to calculate predictions
-		return
-	}
 
-	fmt.Printf("\nChampionship Predictions - Week %d:\n", week)
+// ChampionshipPredictions returns the league title chances of each team in percent based on the
+// standings of the given week, highest first. There are none before the configured first
+// prediction week. When prediction iterations are configured the rest of the season is
+// simulated that many times and a team's chance is the share of seasons it wins; otherwise
+// the chances are the teams' shares of the points.
+func ChampionshipPredictions(week int, table []models.LeagueTableRow) ([]models.Prediction, error) {
+	return ChampionshipPredictionsContext(context.Background(), week, table)
+}
 
-	// Calculate total points in the table
-	totalPoints := 0
-	for _, t := range table {
-		totalPoints += t.Points
+// ChampionshipPredictionsContext is ChampionshipPredictions with a context that cancels the
+// queries and stops the simulated seasons.
+func ChampionshipPredictionsContext(ctx context.Context, week int, table []models.LeagueTableRow) ([]models.Prediction, error) {
+	if week < settings.PredictionFromWeek {
+		// Not enough data to calculate predictions
+		return nil, nil
 	}
 
-	// If total points is 0, assign equal chances to all teams
-	if totalPoints == 0 {
+	var preds []models.Prediction
+	if settings.PredictionIterations > 0 {
+		titles, err := simulateTitles(ctx, week, table, settings.PredictionIterations)
+		if err != nil {
+			return nil, err
+		}
+		for _, t := range table {
+			chance := float64(titles[t.TeamID]) / float64(settings.PredictionIterations) * 100
+			preds = append(preds, models.Prediction{TeamID: t.TeamID, TeamName: t.TeamName, Chance: chance})
+		}
+	} else {
+		// Calculate total points in the table
+		totalPoints := 0
+		for _, t := range table {
+			totalPoints += t.Points
+		}
+
+		// Compute each team's chance based on their points; equal chances while nobody has any
 		for _, t := range table {
-			fmt.Printf("%-15s 25%%\n", t.TeamName)
+			chance := 100 / float64(len(table))
+			if totalPoints > 0 {
+				chance = (float64(t.Points) / float64(totalPoints)) * 100
+			}
+			preds = append(preds, models.Prediction{TeamID: t.TeamID, TeamName: t.TeamName, Chance: chance})
 		}
-		return
 	}
 
-	// Compute each team's chance based on their points
-	type Prediction struct {
-		Team string
-		Rate float64
+	// Sort predictions in descending order
+	sort.SliceStable(preds, func(i, j int) bool {
+		return preds[i].Chance > preds[j].Chance
+	})
+	return preds, nil
+}
+
+// remainingMatch is a top-division match after the prediction week, with the ratings the
+// two teams play it with.
+type remainingMatch struct {
+	HomeID    int
+	AwayID    int
+	HomePower int
+	AwayPower int
+}
+
+// simulateTitles plays the top-division matches after a week the given number of times,
+// starting each time from the table of that week, and counts the titles of every team.
+// Simulated seasons are ranked by points, goal difference and goals scored.
+func simulateTitles(ctx context.Context, week int, table []models.LeagueTableRow, iterations int) (map[int]int, error) {
+	seasonID, err := CurrentSeasonIDContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	divisionID, err := topDivisionID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := storage.DB.QueryContext(ctx, `
+		SELECT m.home_team_id, m.away_team_id, t1.power, t2.power
+		FROM matches m
+		JOIN teams t1 ON m.home_team_id = t1.id
+		JOIN teams t2 ON m.away_team_id = t2.id
+		WHERE m.season_id = ? AND m.division_id = ? AND m.week > ?
+		ORDER BY m.week, m.id
+	`, seasonID, divisionID, week)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var remaining []remainingMatch
+	for rows.Next() {
+		var m remainingMatch
+		if err := rows.Scan(&m.HomeID, &m.AwayID, &m.HomePower, &m.AwayPower); err != nil {
+			return nil, err
+		}
+		remaining = append(remaining, m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
 	}
 
-	var preds []Prediction
-	for _, t := range table {
-		p := (float64(t.Points) / float64(totalPoints)) * 100
-		preds = append(preds, Prediction{
-			Team: t.TeamName,
-			Rate: p,
-		})
+	titles := make(map[int]int)
+	index := make(map[int]int, len(table))
+	for i, t := range table {
+		index[t.TeamID] = i
+	}
+	season := make([]models.LeagueTableRow, len(table))
+	for n := 0; n < iterations; n++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		copy(season, table)
+		for _, m := range remaining {
+			home, okHome := index[m.HomeID]
+			away, okAway := index[m.AwayID]
+			if !okHome || !okAway {
+				continue
+			}
+			homeGoals, awayGoals := scoreGoals(m.HomePower, true), scoreGoals(m.AwayPower, false)
+			addResult(&season[home], homeGoals, awayGoals)
+			addResult(&season[away], awayGoals, homeGoals)
+		}
+		champion := 0
+		for i := range season {
+			if standsAbove(season[i], season[champion]) {
+				champion = i
+			}
+		}
+		if len(season) > 0 {
+			titles[season[champion].TeamID]++
+		}
+		predictionIterations.Inc()
 	}
+	return titles, nil
+}
 
-	// Sort predictions in descending order
-	sort.Slice(preds, func(i, j int) bool {
-		return preds[i].Rate > preds[j].Rate
-	})
+// standsAbove reports whether row a ranks above row b by points, goal difference and goals scored.
+func standsAbove(a, b models.LeagueTableRow) bool {
+	if a.Points != b.Points {
+		return a.Points > b.Points
+	}
+	if a.GoalDiff != b.GoalDiff {
+		return a.GoalDiff > b.GoalDiff
+	}
+	return a.GoalsFor > b.GoalsFor
+}
 
-	// Display rounded predictions 
+// PredictionsReport builds the report of the championship predictions after a week,
+// with the chances rounded to whole percents.
+func PredictionsReport(week int, table []models.LeagueTableRow) (Report, error) {
+	preds, err := ChampionshipPredictions(week, table)
+	if err != nil {
+		return Report{}, err
+	}
+	r := Report{
+		Title:   fmt.Sprintf("Championship Predictions - Week %d", week),
+		Columns: []Column{{Name: "Team"}, {Name: "Chance", Numeric: true}},
+		Data:    preds,
+	}
 	for _, p := range preds {
-		fmt.Printf("%-15s %.0f%%\n", p.Team, math.Round(p.Rate))
+		r.Rows = append(r.Rows, []string{p.TeamName, fmt.Sprintf("%.0f%%", math.Round(p.Chance))})
+	}
+	return r, nil
+}
+
+// PrintChampionshipPredictions writes the league title chances for each team based on the current standings of the given week to w.
+// It only writes anything once the league has reached the first prediction week.
+func PrintChampionshipPredictions(w io.Writer, week int, table []models.LeagueTableRow) error {
+	if week < settings.PredictionFromWeek {
+		return nil
+	}
+	r, err := PredictionsReport(week, table)
+	if err != nil {
+		return err
 	}
+	return textRenderer{}.Render(w, r)
 }
@@ -2,23 +2,246 @@ package league
 
 import (
 	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
 	models "go-football-league/internal/domain"
 )
 
-// PrintLeagueTableRows renders a formatted league table to the console.
+// LeagueTableReport builds the report of a league table, one row per team.
+func LeagueTableReport(title string, table []models.LeagueTableRow) Report {
+	r := Report{
+		Title: title,
+		Columns: []Column{
+			{Name: "Team"}, {Name: "MP", Numeric: true}, {Name: "W", Numeric: true}, {Name: "D", Numeric: true},
+			{Name: "L", Numeric: true}, {Name: "GF", Numeric: true}, {Name: "GA", Numeric: true},
+			{Name: "GD", Numeric: true}, {Name: "Pts", Numeric: true}, {Name: "Form"},
+		},
+		Data: table,
+	}
+	for _, row := range table {
+		r.Rows = append(r.Rows, []string{
+			row.TeamName, strconv.Itoa(row.Played), strconv.Itoa(row.Wins), strconv.Itoa(row.Draws),
+			strconv.Itoa(row.Losses), strconv.Itoa(row.GoalsFor), strconv.Itoa(row.GoalsAgainst),
+			strconv.Itoa(row.GoalDiff), strconv.Itoa(row.Points), row.Form,
+		})
+	}
+	return r
+}
+
+// PrintLeagueTableRows writes a league table to w as a text table.
 // It displays each team's performance statistics in a tabular view.
-func PrintLeagueTableRows(table []models.LeagueTableRow) {
-	fmt.Println("------------------------------------------------------------")
-	fmt.Printf("%-15s %2s %2s %2s %2s %4s %4s %4s %4s\n", 
-		"Team", "MP", "W", "D", "L", "GF", "GA", "GD", "Pts")
-	fmt.Println("------------------------------------------------------------")
+func PrintLeagueTableRows(w io.Writer, table []models.LeagueTableRow) error {
+	return textRenderer{}.Render(w, LeagueTableReport("", table))
+}
 
-	// Print each row of the table with aligned columns
-	for _, row := range table {
-		fmt.Printf("%-15s %2d %2d %2d %2d %4d %4d %4d %4d\n",
-			row.TeamName, row.Played, row.Wins, row.Draws, row.Losses,
-			row.GoalsFor, row.GoalsAgainst, row.GoalDiff, row.Points)
+// MatchesReport builds the report of a list of matches: the score of those with a result
+// and the kickoff of the others.
+func MatchesReport(title string, matches []models.Match) Report {
+	r := Report{
+		Title: title,
+		Columns: []Column{
+			{Name: "Match", Numeric: true}, {Name: "Week", Numeric: true}, {Name: "Home"}, {Name: "Score"},
+			{Name: "Away"}, {Name: "Kickoff"}, {Name: "Status"},
+		},
+		Data: matches,
+	}
+	for _, m := range matches {
+		score := "vs" // Default text if match has not been played
+		if m.Status == StatusPlayed || m.Status == StatusAwarded {
+			score = fmt.Sprintf("%d-%d", m.HomeGoals, m.AwayGoals)
+		}
+		kickoff := ""
+		if !m.Kickoff.IsZero() {
+			kickoff = m.Kickoff.Format("Mon 2 Jan 15:04")
+		}
+		r.Rows = append(r.Rows, []string{
+			strconv.Itoa(m.ID), strconv.Itoa(m.Week), m.HomeTeamName, score, m.AwayTeamName, kickoff, m.Status,
+		})
+	}
+	return r
+}
+
+// PrintCupBracket writes every drawn round of a knockout bracket to w.
+// Two-legged ties show both legs, followed by how the tie was decided.
+func PrintCupBracket(w io.Writer, bracket models.CupBracket) {
+	for _, round := range bracket.Rounds {
+		fmt.Fprintf(w, "\n%s:\n", round.Name)
+		for _, tie := range round.Ties {
+			if tie.AwayTeamID == 0 {
+				fmt.Fprintf(w, "  %s (bye)\n", tie.HomeTeamName)
+				continue
+			}
+			if len(tie.Legs) == 0 {
+				fmt.Fprintf(w, "  %s vs %s\n", tie.HomeTeamName, tie.AwayTeamName)
+				continue
+			}
+			for _, leg := range tie.Legs {
+				score := fmt.Sprintf("%d-%d", leg.HomeGoals, leg.AwayGoals)
+				if leg.ExtraTime {
+					score += " aet"
+				}
+				if leg.HomePenalties != nil && leg.AwayPenalties != nil {
+					score += fmt.Sprintf(" (%d-%d pens)", *leg.HomePenalties, *leg.AwayPenalties)
+				}
+				fmt.Fprintf(w, "  %s %s %s\n", leg.HomeTeamName, score, leg.AwayTeamName)
+			}
+			winner := tie.HomeTeamName
+			if tie.WinnerTeamID == tie.AwayTeamID {
+				winner = tie.AwayTeamName
+			}
+			fmt.Fprintf(w, "    → %s advance (%s)\n", winner, tie.DecidedBy)
+		}
+	}
+	if bracket.WinnerTeamID != 0 {
+		fmt.Fprintf(w, "\nWinner: %s\n", bracket.WinnerName)
+	}
+}
+
+// PrintTournament writes the group tables of a tournament and, once drawn, its knockout bracket to w.
+func PrintTournament(w io.Writer, overview models.TournamentOverview) {
+	fmt.Fprintf(w, "\n===== %s (%s) =====\n", overview.Name, overview.Stage)
+	for _, group := range overview.Groups {
+		fmt.Fprintf(w, "\nGroup %s:\n", group.Name)
+		PrintLeagueTableRows(w, group.Table)
+	}
+	if overview.Knockout != nil {
+		PrintCupBracket(w, *overview.Knockout)
+	}
+}
+
+// PrintPlayoffs writes the seeding, the bracket and the promoted team of a division's playoffs to w.
+func PrintPlayoffs(w io.Writer, p models.Playoff) {
+	fmt.Fprintf(w, "\n===== %s Playoffs =====\n", p.DivisionName)
+	for i, row := range p.Seeds {
+		fmt.Fprintf(w, "  Seed %d: %-15s %3d pts\n", i+1, row.TeamName, row.Points)
+	}
+	PrintCupBracket(w, p.Bracket)
+	if p.PromotedTeamID != 0 {
+		fmt.Fprintf(w, "Promoted: %s\n", p.PromotedTeamName)
+	}
+}
+
+// chartMarkers label the teams of a position chart, in order of their latest position.
+const chartMarkers = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+// PrintPositionChart writes the week-by-week positions of every division to w as an ASCII line chart.
+// Each team is drawn with a letter per played week, joined by lines to its next position.
+func PrintPositionChart(w io.Writer, history []models.PositionHistory) {
+	for start := 0; start < len(history); {
+		end := start
+		for end < len(history) && history[end].DivisionID == history[start].DivisionID {
+			end++
+		}
+		fmt.Fprintf(w, "\n===== %s Positions =====\n", history[start].DivisionName)
+		for _, line := range positionChart(history[start:end]) {
+			fmt.Fprintln(w, line)
+		}
+		start = end
+	}
+}
+
+// positionChart draws the position lines of the teams of one division. Position 1 is at
+// the top and every week takes four columns, with a spacer row between two positions.
+func positionChart(teams []models.PositionHistory) []string {
+	if len(teams) == 0 || len(teams[0].Weeks) == 0 {
+		return []string{"No matches played yet"}
+	}
+	const colWidth = 4
+	weeks := len(teams[0].Weeks)
+	height, width := 2*len(teams)-1, colWidth*(weeks-1)+1
+
+	grid := make([][]byte, height)
+	for y := range grid {
+		grid[y] = []byte(strings.Repeat(" ", width))
+	}
+	x := func(i int) int { return colWidth * i }
+	y := func(position int) int { return 2 * (position - 1) }
+
+	// Lines first, so the markers stay visible where lines cross
+	for _, team := range teams {
+		for i := 1; i < len(team.Weeks); i++ {
+			x1, y1 := x(i-1), y(team.Weeks[i-1].Position)
+			x2, y2 := x(i), y(team.Weeks[i].Position)
+			for cx := x1 + 1; cx < x2; cx++ {
+				cy := y1 + (y2-y1)*(cx-x1)/(x2-x1)
+				switch {
+				case y2 == y1:
+					grid[cy][cx] = '-'
+				case y2 < y1:
+					grid[cy][cx] = '/'
+				default:
+					grid[cy][cx] = '\\'
+				}
+			}
+		}
+	}
+	for i, team := range teams {
+		marker := byte('?')
+		if i < len(chartMarkers) {
+			marker = chartMarkers[i]
+		}
+		for w, pos := range team.Weeks {
+			grid[y(pos.Position)][x(w)] = marker
+		}
+	}
+
+	var lines []string
+	for row := range grid {
+		label := "   "
+		if row%2 == 0 {
+			label = fmt.Sprintf("%3d", row/2+1)
+		}
+		lines = append(lines, label+" | "+strings.TrimRight(string(grid[row]), " "))
+	}
+	lines = append(lines, "    +-"+strings.Repeat("-", width))
+	var axis strings.Builder
+	for _, w := range teams[0].Weeks {
+		axis.WriteString(fmt.Sprintf("%-*d", colWidth, w.Week))
 	}
+	lines = append(lines, "Week  "+strings.TrimRight(axis.String(), " "))
 
-	fmt.Println("------------------------------------------------------------")
+	for i, team := range teams {
+		marker := "?"
+		if i < len(chartMarkers) {
+			marker = chartMarkers[i : i+1]
+		}
+		last := team.Weeks[len(team.Weeks)-1]
+		lines = append(lines, fmt.Sprintf("  %s  %-15s %3d pts", marker, team.TeamName, last.Points))
+	}
+	return lines
+}
+
+// PrintHeadToHead writes a team's record against an opponent and every meeting between them to w.
+func PrintHeadToHead(w io.Writer, h models.HeadToHead) {
+	fmt.Fprintf(w, "\n===== %s vs %s =====\n", h.TeamName, h.OpponentName)
+	fmt.Fprintf(w, "Played %d | %s wins %d | Draws %d | %s wins %d | Goals %d-%d\n",
+		h.Played, h.TeamName, h.Wins, h.Draws, h.OpponentName, h.Losses, h.GoalsFor, h.GoalsAgainst)
+	if h.CurrentStreak != "" {
+		fmt.Fprintf(w, "Current streak (%s): %s\n", h.TeamName, h.CurrentStreak)
+	}
+	if h.BiggestWin != nil {
+		fmt.Fprintf(w, "Biggest %s win: %s\n", h.TeamName, formatMeeting(*h.BiggestWin))
+	}
+	if h.OpponentBiggestWin != nil {
+		fmt.Fprintf(w, "Biggest %s win: %s\n", h.OpponentName, formatMeeting(*h.OpponentBiggestWin))
+	}
+
+	fmt.Fprintln(w, "\nMeetings:")
+	for _, m := range h.Meetings {
+		fmt.Fprintf(w, "  %s\n", formatMeeting(m))
+	}
+}
+
+// formatMeeting renders a meeting on one line with its season, competition and stage.
+func formatMeeting(m models.Meeting) string {
+	score := fmt.Sprintf("%s %d-%d %s", m.HomeTeamName, m.HomeGoals, m.AwayGoals, m.AwayTeamName)
+	if m.ExtraTime {
+		score += " (aet)"
+	}
+	if m.Penalties != "" {
+		score += fmt.Sprintf(" (%s pens)", m.Penalties)
+	}
+	return fmt.Sprintf("%-36s %s %s, %s", score, m.SeasonName, m.Competition, m.Stage)
 }
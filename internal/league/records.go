@@ -0,0 +1,219 @@
+package league
+
+import (
+	"context"
+	"sort"
+
+	models "go-football-league/internal/domain"
+	storage "go-football-league/internal/repository"
+)
+
+// runTracker follows the current and the longest run of a kind of result for one team.
+type runTracker struct {
+	current models.Streak
+	best    models.Streak
+}
+
+// extend adds a match in the given week to the current run.
+func (r *runTracker) extend(teamID int, teamName string, week int) {
+	if r.current.Length == 0 {
+		r.current = models.Streak{TeamID: teamID, TeamName: teamName, FirstWeek: week}
+	}
+	r.current.Length++
+	r.current.LastWeek = week
+	if r.current.Length > r.best.Length {
+		r.best = r.current
+	}
+}
+
+// reset ends the current run.
+func (r *runTracker) reset() {
+	r.current = models.Streak{}
+}
+
+// longest returns the longest run, marked as ongoing if it is also the current one.
+func (r *runTracker) longest() models.Streak {
+	best := r.best
+	best.Ongoing = best.Length > 0 && r.current.Length == best.Length && r.current.FirstWeek == best.FirstWeek
+	return best
+}
+
+// teamTracker collects the records of one team while its matches are read in order.
+type teamTracker struct {
+	records   models.TeamRecords
+	wins      runTracker
+	unbeaten  runTracker
+	scoreless runTracker
+}
+
+// GetSeasonRecords returns the records of every team in a season and the league-wide records
+// over all of its divisions. Records are built from played matches; awarded results are left out.
+func GetSeasonRecords(seasonID int) (models.SeasonRecords, error) {
+	return GetSeasonRecordsContext(context.Background(), seasonID)
+}
+
+// GetSeasonRecordsContext is GetSeasonRecords with a context that cancels the queries.
+func GetSeasonRecordsContext(ctx context.Context, seasonID int) (models.SeasonRecords, error) {
+	season, err := getSeason(ctx, seasonID)
+	if err != nil {
+		return models.SeasonRecords{}, err
+	}
+	teams, err := seasonRecords(ctx, season, 0)
+	if err != nil {
+		return models.SeasonRecords{}, err
+	}
+
+	result := models.SeasonRecords{SeasonID: season.ID, SeasonName: season.Name, Teams: teams}
+	best := &result.League
+	for i := range teams {
+		t := &teams[i]
+		if t.BiggestWin != nil && (best.BiggestWin == nil ||
+			widerWin(t.BiggestWin.HomeGoals, t.BiggestWin.AwayGoals, best.BiggestWin.HomeGoals, best.BiggestWin.AwayGoals)) {
+			best.BiggestWin = t.BiggestWin
+		}
+		if t.MostGoals != nil && (best.HighestScoring == nil || totalGoals(*t.MostGoals) > totalGoals(*best.HighestScoring)) {
+			best.HighestScoring = t.MostGoals
+		}
+		if t.LongestWinningStreak.Length > best.LongestWinningStreak.Length {
+			best.LongestWinningStreak = t.LongestWinningStreak
+		}
+		if t.LongestUnbeatenStreak.Length > best.LongestUnbeatenStreak.Length {
+			best.LongestUnbeatenStreak = t.LongestUnbeatenStreak
+		}
+		if t.LongestScorelessRun.Length > best.LongestScorelessRun.Length {
+			best.LongestScorelessRun = t.LongestScorelessRun
+		}
+	}
+	return result, nil
+}
+
+// GetTeamRecords returns a team's records in every season it has played a match in, oldest first.
+func GetTeamRecords(teamID int) ([]models.TeamRecords, error) {
+	return GetTeamRecordsContext(context.Background(), teamID)
+}
+
+// GetTeamRecordsContext is GetTeamRecords with a context that cancels the queries.
+func GetTeamRecordsContext(ctx context.Context, teamID int) ([]models.TeamRecords, error) {
+	if _, err := teamName(ctx, teamID); err != nil {
+		return nil, err
+	}
+
+	rows, err := storage.DB.QueryContext(ctx, `
+		SELECT DISTINCT season_id FROM matches
+		WHERE (home_team_id = ?1 OR away_team_id = ?1) AND status = 'played'
+		ORDER BY season_id
+	`, teamID)
+	if err != nil {
+		return nil, err
+	}
+	var seasonIDs []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		seasonIDs = append(seasonIDs, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	records := []models.TeamRecords{}
+	for _, seasonID := range seasonIDs {
+		season, err := getSeason(ctx, seasonID)
+		if err != nil {
+			return nil, err
+		}
+		teams, err := seasonRecords(ctx, season, teamID)
+		if err != nil {
+			return nil, err
+		}
+		for _, t := range teams {
+			if t.TeamID == teamID {
+				records = append(records, t)
+			}
+		}
+	}
+	return records, nil
+}
+
+// seasonRecords reads the played matches of a season in the order they kicked off, only those
+// of one team if teamID is not zero, and returns the records of every team in them by name.
+func seasonRecords(ctx context.Context, season models.Season, teamID int) ([]models.TeamRecords, error) {
+	_, loc, err := seasonCalendar(ctx, season.ID)
+	if err != nil {
+		return nil, err
+	}
+	filter, args := "m.season_id = ? AND m.status = 'played'", []interface{}{season.ID}
+	if teamID != 0 {
+		filter += " AND (m.home_team_id = ? OR m.away_team_id = ?)"
+		args = append(args, teamID, teamID)
+	}
+	matches, err := queryMatches(ctx, loc, filter, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	trackers := make(map[int]*teamTracker)
+	track := func(m *models.Match, id int, name string, goalsFor, goalsAgainst int) {
+		t, ok := trackers[id]
+		if !ok {
+			t = &teamTracker{records: models.TeamRecords{SeasonID: season.ID, SeasonName: season.Name, TeamID: id, TeamName: name}}
+			trackers[id] = t
+		}
+		r := &t.records
+		r.Played++
+		if r.FirstResult == nil {
+			r.FirstResult = m
+		}
+		r.LastResult = m
+
+		switch {
+		case goalsFor > goalsAgainst:
+			t.wins.extend(id, name, m.Week)
+			t.unbeaten.extend(id, name, m.Week)
+			if r.BiggestWin == nil || widerWin(m.HomeGoals, m.AwayGoals, r.BiggestWin.HomeGoals, r.BiggestWin.AwayGoals) {
+				r.BiggestWin = m
+			}
+		case goalsFor < goalsAgainst:
+			t.wins.reset()
+			t.unbeaten.reset()
+			if r.BiggestLoss == nil || widerWin(m.HomeGoals, m.AwayGoals, r.BiggestLoss.HomeGoals, r.BiggestLoss.AwayGoals) {
+				r.BiggestLoss = m
+			}
+		default:
+			t.wins.reset()
+			t.unbeaten.extend(id, name, m.Week)
+		}
+		if goalsFor == 0 {
+			t.scoreless.extend(id, name, m.Week)
+		} else {
+			t.scoreless.reset()
+		}
+		if r.MostGoals == nil || totalGoals(*m) > totalGoals(*r.MostGoals) {
+			r.MostGoals = m
+		}
+	}
+	for i := range matches {
+		m := &matches[i]
+		track(m, m.HomeTeamID, m.HomeTeamName, m.HomeGoals, m.AwayGoals)
+		track(m, m.AwayTeamID, m.AwayTeamName, m.AwayGoals, m.HomeGoals)
+	}
+
+	teams := make([]models.TeamRecords, 0, len(trackers))
+	for _, t := range trackers {
+		t.records.LongestWinningStreak = t.wins.longest()
+		t.records.LongestUnbeatenStreak = t.unbeaten.longest()
+		t.records.LongestScorelessRun = t.scoreless.longest()
+		teams = append(teams, t.records)
+	}
+	sort.Slice(teams, func(i, j int) bool { return teams[i].TeamName < teams[j].TeamName })
+	return teams, nil
+}
+
+// totalGoals returns the number of goals scored in a match.
+func totalGoals(m models.Match) int {
+	return m.HomeGoals + m.AwayGoals
+}
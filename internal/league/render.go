@@ -0,0 +1,202 @@
+package league
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+// Output formats a report can be rendered in.
+const (
+	FormatTable    = "table"    // Fixed-width text for the terminal
+	FormatJSON     = "json"     // The report's data, indented
+	FormatCSV      = "csv"      // A header line and one line per row
+	FormatMarkdown = "markdown" // A GitHub-flavoured Markdown table
+	FormatHTML     = "html"     // An HTML table
+)
+
+// Formats lists the output formats in the order they are documented.
+var Formats = []string{FormatTable, FormatJSON, FormatCSV, FormatMarkdown, FormatHTML}
+
+// Column is a column of a report. Numeric columns are right-aligned.
+type Column struct {
+	Name    string
+	Numeric bool
+}
+
+// Report is the output of a printer before it is rendered: a title, the columns and the
+// text of every cell. The JSON renderer encodes Data instead of the cells, so machine
+// readers get the same values as the API.
+type Report struct {
+	Title   string
+	Columns []Column
+	Rows    [][]string
+	Data    interface{}
+}
+
+// Renderer writes a report in one output format.
+type Renderer interface {
+	Render(w io.Writer, r Report) error
+}
+
+// NewRenderer returns the renderer of an output format; an empty format is the text table.
+func NewRenderer(format string) (Renderer, error) {
+	switch format {
+	case "", FormatTable:
+		return textRenderer{}, nil
+	case FormatJSON:
+		return jsonRenderer{}, nil
+	case FormatCSV:
+		return csvRenderer{}, nil
+	case FormatMarkdown:
+		return markdownRenderer{}, nil
+	case FormatHTML:
+		return htmlRenderer{}, nil
+	}
+	return nil, fmt.Errorf("Invalid format %q, expected one of %s", format, strings.Join(Formats, ", "))
+}
+
+// textRenderer writes the title and a table with columns as wide as their widest cell.
+type textRenderer struct{}
+
+func (textRenderer) Render(w io.Writer, r Report) error {
+	widths := make([]int, len(r.Columns))
+	for i, c := range r.Columns {
+		widths[i] = utf8.RuneCountInString(c.Name)
+	}
+	for _, row := range r.Rows {
+		for i, cell := range row {
+			widths[i] = max(widths[i], utf8.RuneCountInString(cell))
+		}
+	}
+	total := 0
+	for _, width := range widths {
+		total += width + 2
+	}
+	rule := strings.Repeat("-", max(total-2, 0))
+
+	line := func(cells []string) string {
+		var b strings.Builder
+		for i, cell := range cells {
+			pad := strings.Repeat(" ", widths[i]-utf8.RuneCountInString(cell))
+			if i > 0 {
+				b.WriteString("  ")
+			}
+			if r.Columns[i].Numeric {
+				b.WriteString(pad + cell)
+			} else {
+				b.WriteString(cell + pad)
+			}
+		}
+		return strings.TrimRight(b.String(), " ")
+	}
+
+	var b strings.Builder
+	if r.Title != "" {
+		fmt.Fprintf(&b, "%s:\n", r.Title)
+	}
+	names := make([]string, len(r.Columns))
+	for i, c := range r.Columns {
+		names[i] = c.Name
+	}
+	fmt.Fprintf(&b, "%s\n%s\n%s\n", rule, line(names), rule)
+	for _, row := range r.Rows {
+		fmt.Fprintln(&b, line(row))
+	}
+	fmt.Fprintln(&b, rule)
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// jsonRenderer writes the report's data as indented JSON.
+type jsonRenderer struct{}
+
+func (jsonRenderer) Render(w io.Writer, r Report) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r.Data)
+}
+
+// csvRenderer writes a header line with the column names and one line per row.
+type csvRenderer struct{}
+
+func (csvRenderer) Render(w io.Writer, r Report) error {
+	cw := csv.NewWriter(w)
+	names := make([]string, len(r.Columns))
+	for i, c := range r.Columns {
+		names[i] = c.Name
+	}
+	cw.Write(names)
+	cw.WriteAll(r.Rows)
+	return cw.Error()
+}
+
+// markdownRenderer writes the title as a heading and the rows as a Markdown table.
+type markdownRenderer struct{}
+
+func (markdownRenderer) Render(w io.Writer, r Report) error {
+	cell := func(s string) string {
+		return strings.ReplaceAll(s, "|", `\|`)
+	}
+
+	var b strings.Builder
+	if r.Title != "" {
+		fmt.Fprintf(&b, "### %s\n\n", r.Title)
+	}
+	b.WriteString("|")
+	for _, c := range r.Columns {
+		fmt.Fprintf(&b, " %s |", cell(c.Name))
+	}
+	b.WriteString("\n|")
+	for _, c := range r.Columns {
+		if c.Numeric {
+			b.WriteString(" ---: |")
+		} else {
+			b.WriteString(" --- |")
+		}
+	}
+	b.WriteString("\n")
+	for _, row := range r.Rows {
+		b.WriteString("|")
+		for _, s := range row {
+			fmt.Fprintf(&b, " %s |", cell(s))
+		}
+		b.WriteString("\n")
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// htmlRenderer writes an HTML table with the title as its caption.
+type htmlRenderer struct{}
+
+func (htmlRenderer) Render(w io.Writer, r Report) error {
+	var b strings.Builder
+	b.WriteString("<table>\n")
+	if r.Title != "" {
+		fmt.Fprintf(&b, "  <caption>%s</caption>\n", html.EscapeString(r.Title))
+	}
+	b.WriteString("  <thead>\n    <tr>")
+	for _, c := range r.Columns {
+		fmt.Fprintf(&b, "<th>%s</th>", html.EscapeString(c.Name))
+	}
+	b.WriteString("</tr>\n  </thead>\n  <tbody>\n")
+	for _, row := range r.Rows {
+		b.WriteString("    <tr>")
+		for i, s := range row {
+			if r.Columns[i].Numeric {
+				fmt.Fprintf(&b, `<td align="right">%s</td>`, html.EscapeString(s))
+			} else {
+				fmt.Fprintf(&b, "<td>%s</td>", html.EscapeString(s))
+			}
+		}
+		b.WriteString("</tr>\n")
+	}
+	b.WriteString("  </tbody>\n</table>\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
@@ -0,0 +1,206 @@
+package league
+
+import (
+	"math/rand"
+
+	models "go-football-league/internal/domain"
+)
+
+const (
+	schedulerIterations = 20000 // Moves the scheduler tries before settling for the best fixture found
+	schedulerPatience   = 1000  // Moves in a row without a lower cost after which it settles early
+	schedulerSeed       = 1     // Fixed so the same teams and constraints always give the same fixture
+)
+
+// divisionSchedule is the fixture of one division as the scheduler rearranges it. The
+// rounds of a single round-robin are assigned to the weeks of the first half of the
+// season, every match can be turned around and two teams can trade places, which keeps
+// the alternation of home and away matches of the round-robin. The second half mirrors the first like
+// roundRobin does: from its second week on, ending with the first.
+type divisionSchedule struct {
+	teamIDs []int
+	rounds  [][]fixtureMatch
+	order   []int    // Round played in each week of the first half
+	flipped [][]bool // Matches played with home and away swapped in the first half
+}
+
+// newDivisionSchedule starts from the plain round-robin fixture of a division.
+func newDivisionSchedule(teamIDs []int) *divisionSchedule {
+	d := &divisionSchedule{teamIDs: teamIDs, rounds: singleRoundRobin(teamIDs)}
+	for i, round := range d.rounds {
+		d.order = append(d.order, i)
+		d.flipped = append(d.flipped, make([]bool, len(round)))
+	}
+	return d
+}
+
+// fixture returns the double round-robin fixture list of the current arrangement.
+func (d *divisionSchedule) fixture() []fixtureMatch {
+	half := len(d.rounds)
+	var first, second []fixtureMatch
+	for w, r := range d.order {
+		for i, m := range d.rounds[r] {
+			home, away := m.Home, m.Away
+			if d.flipped[r][i] {
+				home, away = away, home
+			}
+			first = append(first, fixtureMatch{Week: w + 1, Home: home, Away: away})
+			second = append(second, fixtureMatch{Week: half + (w+half-1)%half + 1, Home: away, Away: home})
+		}
+	}
+	return append(first, second...)
+}
+
+// swapTeams makes two teams play each other's matches.
+func (d *divisionSchedule) swapTeams(a, b int) {
+	other := map[int]int{a: b, b: a}
+	for _, round := range d.rounds {
+		for i, m := range round {
+			if t, ok := other[m.Home]; ok {
+				round[i].Home = t
+			}
+			if t, ok := other[m.Away]; ok {
+				round[i].Away = t
+			}
+		}
+	}
+}
+
+// scheduleFixture generates the fixtures of several divisions playing on the same weeks.
+// It starts from the round-robin fixture of each division and searches for an arrangement
+// of rounds and home and away sides that satisfies the constraints, breaking as few as
+// possible when they cannot all be met. It returns the fixture of each division and the
+// constraints it could not satisfy.
+//
+// Divisions that share no constraint are scored apart, so a move only re-scores the group
+// of divisions it changed, and only groups that break a constraint are rearranged. The
+// search settles once schedulerPatience moves in a row have not lowered the cost.
+func scheduleFixture(divisions [][]int, rules []scheduleRule, names map[int]string) ([][]fixtureMatch, []models.ConstraintViolation) {
+	schedules := make([]*divisionSchedule, len(divisions))
+	for i, teamIDs := range divisions {
+		schedules[i] = newDivisionSchedule(teamIDs)
+	}
+	all := func() []fixtureMatch {
+		var matches []fixtureMatch
+		for _, d := range schedules {
+			matches = append(matches, d.fixture()...)
+		}
+		return matches
+	}
+
+	group, groupRules := scheduleGroups(divisions, rules)
+	score := func(g int) int {
+		var matches []fixtureMatch
+		for i, d := range schedules {
+			if group[i] == g {
+				matches = append(matches, d.fixture()...)
+			}
+		}
+		cost, _ := evaluateSchedule(matches, groupRules[g], names, false)
+		return cost
+	}
+	costs := make(map[int]int) // Cost of every group, by its first division
+	for i := range schedules {
+		if group[i] == i {
+			costs[i] = score(i)
+		}
+	}
+
+	rng := rand.New(rand.NewSource(schedulerSeed))
+	for i, idle := 0, 0; i < schedulerIterations && idle < schedulerPatience; i++ {
+		var candidates []int // Divisions of the groups that break a constraint
+		for k := range schedules {
+			if costs[group[k]] > 0 {
+				candidates = append(candidates, k)
+			}
+		}
+		if len(candidates) == 0 {
+			break
+		}
+		k := candidates[rng.Intn(len(candidates))]
+		d := schedules[k]
+
+		// Swap the weeks of two rounds, swap two teams or turn one match around, and keep
+		// the move unless it makes the fixture worse
+		var undo func()
+		switch move := rng.Intn(3); {
+		case len(d.order) > 1 && move == 0:
+			a, b := rng.Intn(len(d.order)), rng.Intn(len(d.order))
+			d.order[a], d.order[b] = d.order[b], d.order[a]
+			undo = func() { d.order[a], d.order[b] = d.order[b], d.order[a] }
+		case len(d.teamIDs) > 1 && move == 1:
+			a, b := d.teamIDs[rng.Intn(len(d.teamIDs))], d.teamIDs[rng.Intn(len(d.teamIDs))]
+			d.swapTeams(a, b)
+			undo = func() { d.swapTeams(a, b) }
+		default:
+			r := rng.Intn(len(d.rounds))
+			if len(d.rounds[r]) == 0 {
+				continue
+			}
+			m := rng.Intn(len(d.rounds[r]))
+			d.flipped[r][m] = !d.flipped[r][m]
+			undo = func() { d.flipped[r][m] = !d.flipped[r][m] }
+		}
+
+		g := group[k]
+		next := score(g)
+		if next < costs[g] {
+			idle = 0
+		} else {
+			idle++
+		}
+		if next <= costs[g] {
+			costs[g] = next
+		} else {
+			undo()
+		}
+	}
+
+	fixtures := make([][]fixtureMatch, len(schedules))
+	for i, d := range schedules {
+		fixtures[i] = d.fixture()
+	}
+	_, violations := evaluateSchedule(all(), rules, names, true)
+	return fixtures, violations
+}
+
+// scheduleGroups joins divisions whose teams share a constraint, such as a shared stadium,
+// into groups that have to be scored together. It returns the group of every division,
+// named by its first division, and the constraints of every group. A constraint on teams
+// outside every division goes with the first group; moves cannot change its cost.
+func scheduleGroups(divisions [][]int, rules []scheduleRule) ([]int, map[int][]scheduleRule) {
+	group := make([]int, len(divisions))
+	divisionOf := make(map[int]int)
+	for i, teamIDs := range divisions {
+		group[i] = i
+		for _, id := range teamIDs {
+			divisionOf[id] = i
+		}
+	}
+	for _, r := range rules {
+		a, okA := divisionOf[r.TeamID]
+		b, okB := divisionOf[r.OtherTeamID]
+		if !okA || !okB || group[a] == group[b] {
+			continue
+		}
+		from, to := max(group[a], group[b]), min(group[a], group[b])
+		for i := range group {
+			if group[i] == from {
+				group[i] = to
+			}
+		}
+	}
+
+	groupRules := make(map[int][]scheduleRule)
+	for _, r := range rules {
+		i, ok := divisionOf[r.TeamID]
+		if !ok {
+			i = divisionOf[r.OtherTeamID]
+		}
+		if len(group) > 0 {
+			i = group[i]
+		}
+		groupRules[i] = append(groupRules[i], r)
+	}
+	return group, groupRules
+}
@@ -0,0 +1,258 @@
+package league
+
+import (
+	"strings"
+	"testing"
+
+	models "go-football-league/internal/domain"
+)
+
+func rule(kind string, teamID, otherTeamID, week int) scheduleRule {
+	return scheduleRule{
+		FixtureConstraint: models.FixtureConstraint{Kind: kind, TeamID: teamID, OtherTeamID: otherTeamID, Week: week},
+		week:              week,
+	}
+}
+
+func TestEvaluateSchedule(t *testing.T) {
+	names := map[int]string{1: "Chelsea", 2: "Arsenal", 3: "Liverpool", 4: "Everton"}
+	tests := []struct {
+		name     string
+		matches  []fixtureMatch
+		rules    []scheduleRule
+		wantCost int
+		want     []string // Kinds of the violations, hard ones marked with a !
+	}{
+		{
+			name: "balanced fixture",
+			matches: []fixtureMatch{
+				{Week: 1, Home: 1, Away: 2}, {Week: 2, Home: 2, Away: 1}, {Week: 3, Home: 1, Away: 2},
+			},
+		},
+		{
+			name: "three home matches in a row",
+			matches: []fixtureMatch{
+				{Week: 1, Home: 1, Away: 2}, {Week: 2, Home: 1, Away: 3}, {Week: 3, Home: 1, Away: 4},
+			},
+			wantCost: hardConstraintCost,
+			want:     []string{"consecutive_venue!"},
+		},
+		{
+			name: "a week without a match does not break a run",
+			matches: []fixtureMatch{
+				{Week: 1, Home: 2, Away: 1}, {Week: 2, Home: 3, Away: 1}, {Week: 4, Home: 4, Away: 1},
+			},
+			wantCost: hardConstraintCost,
+			want:     []string{"consecutive_venue!"},
+		},
+		{
+			name:     "teams sharing a stadium both at home",
+			matches:  []fixtureMatch{{Week: 1, Home: 1, Away: 3}, {Week: 1, Home: 2, Away: 4}},
+			rules:    []scheduleRule{rule(ConstraintSharedStadium, 1, 2, 0)},
+			wantCost: hardConstraintCost,
+			want:     []string{"shared_stadium!"},
+		},
+		{
+			name:    "teams sharing a stadium at home in turn",
+			matches: []fixtureMatch{{Week: 1, Home: 1, Away: 3}, {Week: 1, Home: 4, Away: 2}},
+			rules:   []scheduleRule{rule(ConstraintSharedStadium, 1, 2, 0)},
+		},
+		{
+			name:     "derby in another week",
+			matches:  []fixtureMatch{{Week: 1, Home: 3, Away: 4}, {Week: 2, Home: 4, Away: 3}},
+			rules:    []scheduleRule{rule(ConstraintDerby, 3, 4, 3)},
+			wantCost: 1,
+			want:     []string{"derby"},
+		},
+		{
+			name:    "derby in its week, second leg",
+			matches: []fixtureMatch{{Week: 1, Home: 3, Away: 4}, {Week: 2, Home: 4, Away: 3}},
+			rules:   []scheduleRule{rule(ConstraintDerby, 4, 3, 2)},
+		},
+		{
+			name:     "unavailable team plays",
+			matches:  []fixtureMatch{{Week: 1, Home: 1, Away: 2}, {Week: 2, Home: 2, Away: 1}},
+			rules:    []scheduleRule{rule(ConstraintUnavailable, 2, 0, 2)},
+			wantCost: hardConstraintCost,
+			want:     []string{"unavailable!"},
+		},
+		{
+			name:    "unavailable date outside the match days",
+			matches: []fixtureMatch{{Week: 1, Home: 1, Away: 2}},
+			rules:   []scheduleRule{rule(ConstraintUnavailable, 2, 0, 0)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cost, violations := evaluateSchedule(tt.matches, tt.rules, names, true)
+			var got []string
+			for _, v := range violations {
+				kind := v.Kind
+				if v.Hard {
+					kind += "!"
+				}
+				got = append(got, kind)
+				if v.Message == "" {
+					t.Errorf("violation %s has no message", v.Kind)
+				}
+			}
+			if cost != tt.wantCost || strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("got cost %d and %v, want %d and %v", cost, got, tt.wantCost, tt.want)
+			}
+
+			// Without report the cost is the same and nothing is described
+			if quiet, none := evaluateSchedule(tt.matches, tt.rules, names, false); quiet != cost || none != nil {
+				t.Errorf("without report got cost %d and %v", quiet, none)
+			}
+		})
+	}
+}
+
+func TestScheduleFixture(t *testing.T) {
+	tests := []struct {
+		name      string
+		divisions [][]int
+		rules     []scheduleRule
+		wantHard  int // Hard violations that cannot be avoided
+		wantSoft  int
+	}{
+		{name: "four teams", divisions: [][]int{teamRange(4)}},
+		{name: "five teams", divisions: [][]int{teamRange(5)}},
+		{name: "twenty teams", divisions: [][]int{teamRange(20)}},
+		{
+			name:      "shared stadium across divisions",
+			divisions: [][]int{{1, 2, 3, 4}, {5, 6, 7, 8}},
+			rules:     []scheduleRule{rule(ConstraintSharedStadium, 1, 5, 0), rule(ConstraintSharedStadium, 2, 6, 0)},
+		},
+		{
+			name:      "derbies",
+			divisions: [][]int{teamRange(6)},
+			rules:     []scheduleRule{rule(ConstraintDerby, 1, 2, 1), rule(ConstraintDerby, 3, 5, 9)},
+		},
+		{
+			name:      "unavailable week met by a bye",
+			divisions: [][]int{teamRange(5)},
+			rules:     []scheduleRule{rule(ConstraintUnavailable, 3, 0, 2)},
+		},
+		{
+			name:      "unavailable week without byes",
+			divisions: [][]int{teamRange(4)},
+			rules:     []scheduleRule{rule(ConstraintUnavailable, 3, 0, 2)},
+			wantHard:  1,
+		},
+		{
+			name:      "derbies in the same week",
+			divisions: [][]int{teamRange(4)},
+			rules:     []scheduleRule{rule(ConstraintDerby, 1, 2, 3), rule(ConstraintDerby, 1, 3, 3)},
+			wantSoft:  1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fixtures, violations := scheduleFixture(tt.divisions, tt.rules, map[int]string{})
+			if len(fixtures) != len(tt.divisions) {
+				t.Fatalf("got %d fixtures for %d divisions", len(fixtures), len(tt.divisions))
+			}
+			var all []fixtureMatch
+			for i, fixture := range fixtures {
+				checkFixture(t, len(tt.divisions[i]), relabel(sortByWeek(fixture), tt.divisions[i]), true)
+				all = append(all, fixture...)
+			}
+
+			hard, soft := 0, 0
+			for _, v := range violations {
+				if v.Hard {
+					hard++
+				} else {
+					soft++
+				}
+			}
+			if hard != tt.wantHard || soft != tt.wantSoft {
+				t.Errorf("got %d hard and %d soft violations, want %d and %d: %v", hard, soft, tt.wantHard, tt.wantSoft, violations)
+			}
+
+			// The violations reported are those of the fixture returned
+			if _, again := evaluateSchedule(all, tt.rules, map[int]string{}, true); len(again) != len(violations) {
+				t.Errorf("the fixture has %d violations, %d reported", len(again), len(violations))
+			}
+		})
+	}
+}
+
+// relabel maps the team IDs of a division to 1..n so checkFixture can count the meetings.
+func relabel(fixture []fixtureMatch, teamIDs []int) []fixtureMatch {
+	index := map[int]int{}
+	for i, id := range teamIDs {
+		index[id] = i + 1
+	}
+	relabelled := make([]fixtureMatch, len(fixture))
+	for i, m := range fixture {
+		relabelled[i] = fixtureMatch{Week: m.Week, Home: index[m.Home], Away: index[m.Away]}
+	}
+	return relabelled
+}
+
+func TestScheduleFixtureIsRepeatable(t *testing.T) {
+	rules := []scheduleRule{rule(ConstraintDerby, 1, 2, 4), rule(ConstraintSharedStadium, 3, 4, 0)}
+	first, _ := scheduleFixture([][]int{teamRange(8)}, rules, map[int]string{})
+	second, _ := scheduleFixture([][]int{teamRange(8)}, rules, map[int]string{})
+	for i := range first[0] {
+		if first[0][i] != second[0][i] {
+			t.Fatalf("match %d differs between runs: %v and %v", i, first[0][i], second[0][i])
+		}
+	}
+}
+
+func TestScheduleGroups(t *testing.T) {
+	divisions := [][]int{{1, 2}, {3, 4}, {5, 6}}
+	tests := []struct {
+		name      string
+		rules     []scheduleRule
+		wantGroup []int
+		wantRules map[int]int // Number of rules of every group
+	}{
+		{name: "no constraints", wantGroup: []int{0, 1, 2}, wantRules: map[int]int{}},
+		{
+			name:      "constraints within divisions",
+			rules:     []scheduleRule{rule(ConstraintDerby, 3, 4, 2), rule(ConstraintUnavailable, 5, 0, 1)},
+			wantGroup: []int{0, 1, 2},
+			wantRules: map[int]int{1: 1, 2: 1},
+		},
+		{
+			name:      "shared stadium across divisions",
+			rules:     []scheduleRule{rule(ConstraintSharedStadium, 6, 3, 0), rule(ConstraintDerby, 1, 2, 1)},
+			wantGroup: []int{0, 1, 1},
+			wantRules: map[int]int{0: 1, 1: 1},
+		},
+		{
+			name:      "chain of shared stadiums",
+			rules:     []scheduleRule{rule(ConstraintSharedStadium, 1, 3, 0), rule(ConstraintSharedStadium, 4, 5, 0)},
+			wantGroup: []int{0, 0, 0},
+			wantRules: map[int]int{0: 2},
+		},
+		{
+			name:      "team outside every division",
+			rules:     []scheduleRule{rule(ConstraintUnavailable, 9, 0, 1)},
+			wantGroup: []int{0, 1, 2},
+			wantRules: map[int]int{0: 1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			group, groupRules := scheduleGroups(divisions, tt.rules)
+			for i := range tt.wantGroup {
+				if group[i] != tt.wantGroup[i] {
+					t.Fatalf("got groups %v, want %v", group, tt.wantGroup)
+				}
+			}
+			if len(groupRules) != len(tt.wantRules) {
+				t.Errorf("got rules for %d groups, want %d", len(groupRules), len(tt.wantRules))
+			}
+			for g, n := range tt.wantRules {
+				if len(groupRules[g]) != n {
+					t.Errorf("group %d has %d rules, want %d", g, len(groupRules[g]), n)
+				}
+			}
+		})
+	}
+}
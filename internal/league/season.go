@@ -0,0 +1,335 @@
+package league
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	models "go-football-league/internal/domain"
+	storage "go-football-league/internal/repository"
+)
+
+// Reasons a team moves between divisions at the end of a season.
+const (
+	MovementPromoted        = "promoted"
+	MovementPlayoffPromoted = "promoted via playoffs"
+	MovementRelegated       = "relegated"
+)
+
+// CurrentSeasonID returns the ID of the season that new fixtures and results belong to.
+// The most recently created season is always the current one.
+func CurrentSeasonID() (int, error) {
+	return CurrentSeasonIDContext(context.Background())
+}
+
+// CurrentSeasonIDContext is CurrentSeasonID with a context that cancels the query.
+func CurrentSeasonIDContext(ctx context.Context) (int, error) {
+	var id int
+	err := storage.DB.QueryRowContext(ctx, "SELECT COALESCE(MAX(id), 1) FROM seasons").Scan(&id)
+	if err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+// SeasonWeeks returns the number of match weeks in the current season's fixture list.
+// It returns 0 if no fixtures have been created yet.
+func SeasonWeeks() (int, error) {
+	return SeasonWeeksContext(context.Background())
+}
+
+// SeasonWeeksContext is SeasonWeeks with a context that cancels the queries.
+func SeasonWeeksContext(ctx context.Context) (int, error) {
+	seasonID, err := CurrentSeasonIDContext(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return seasonWeeks(ctx, seasonID)
+}
+
+// seasonWeeks returns the number of match weeks in a season's fixture list.
+func seasonWeeks(ctx context.Context, seasonID int) (int, error) {
+	var weeks int
+	err := storage.DB.QueryRowContext(ctx, "SELECT COALESCE(MAX(week), 0) FROM matches WHERE season_id = ?", seasonID).Scan(&weeks)
+	return weeks, err
+}
+
+// getSeason returns a single season.
+func getSeason(ctx context.Context, seasonID int) (models.Season, error) {
+	var s models.Season
+	err := storage.DB.QueryRowContext(ctx, `
+		SELECT id, name, start_date, timezone FROM seasons WHERE id = ?
+	`, seasonID).Scan(&s.ID, &s.Name, &s.StartDate, &s.Timezone)
+	if err == sql.ErrNoRows {
+		return s, fmt.Errorf("Season %d does not exist", seasonID)
+	}
+	return s, err
+}
+
+// GetSeasons returns all seasons, the oldest first.
+func GetSeasons() ([]models.Season, error) {
+	return GetSeasonsContext(context.Background())
+}
+
+// GetSeasonsContext is GetSeasons with a context that cancels the query.
+func GetSeasonsContext(ctx context.Context) ([]models.Season, error) {
+	rows, err := storage.DB.QueryContext(ctx, "SELECT id, name, start_date, timezone FROM seasons ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	seasons := []models.Season{}
+	for rows.Next() {
+		var s models.Season
+		if err := rows.Scan(&s.ID, &s.Name, &s.StartDate, &s.Timezone); err != nil {
+			return nil, err
+		}
+		seasons = append(seasons, s)
+	}
+	return seasons, rows.Err()
+}
+
+// SeasonOptions configures how a closed season rolls over into the next one.
+type SeasonOptions struct {
+	Name       string
+	StartDate  string        // Friday of the first match week (YYYY-MM-DD); defaults to after the offseason
+	Regression float64       // Share (0 to 1) of the gap to the average rating each team loses
+	NewTeams   []models.Team // Teams joining for the new season (name, power and division)
+	Withdrawn  []int         // IDs of teams leaving the league; their history is kept
+}
+
+// RolloverSeason closes the current season and starts a new one.
+// Every match of the current season must have been played. The final standings are
+// archived, the top teams of each division move up and the bottom teams move down one
+// tier, team ratings regress towards the league average if requested, and finally
+// the fixture list of the new season is generated. All of it happens in one transaction,
+// so a failure leaves the current season as it was.
+func RolloverSeason(opts SeasonOptions) (models.SeasonRollover, error) {
+	return RolloverSeasonContext(context.Background(), opts)
+}
+
+// RolloverSeasonContext is RolloverSeason with a context that cancels the queries and,
+// before it commits, the rollover.
+func RolloverSeasonContext(ctx context.Context, opts SeasonOptions) (models.SeasonRollover, error) {
+	if strings.TrimSpace(opts.Name) == "" {
+		return models.SeasonRollover{}, errors.New("Season name is required")
+	}
+	if opts.Regression < 0 || opts.Regression > 1 {
+		return models.SeasonRollover{}, errors.New("Rating regression must be between 0 and 1")
+	}
+	for _, t := range opts.NewTeams {
+		if err := validateTeam(t.Name, t.Power); err != nil {
+			return models.SeasonRollover{}, err
+		}
+		if err := checkDivision(ctx, t.DivisionID); err != nil {
+			return models.SeasonRollover{}, err
+		}
+	}
+
+	seasonID, err := CurrentSeasonIDContext(ctx)
+	if err != nil {
+		return models.SeasonRollover{}, err
+	}
+	if opts.StartDate == "" {
+		if opts.StartDate, err = nextSeasonStart(ctx, seasonID); err != nil {
+			return models.SeasonRollover{}, err
+		}
+	} else if start, err := time.Parse(dateLayout, opts.StartDate); err != nil || start.Weekday() != time.Friday {
+		return models.SeasonRollover{}, errors.New("Start date must be a Friday formatted as YYYY-MM-DD")
+	}
+
+	var unplayed int
+	err = storage.DB.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM matches
+		WHERE season_id = ? AND status NOT IN ('played', 'awarded')
+	`, seasonID).Scan(&unplayed)
+	if err != nil {
+		return models.SeasonRollover{}, err
+	}
+	if unplayed > 0 {
+		return models.SeasonRollover{}, fmt.Errorf("Season still has %d unplayed match(es)", unplayed)
+	}
+
+	movements, err := seasonMovements(ctx, seasonID)
+	if err != nil {
+		return models.SeasonRollover{}, err
+	}
+	standings, err := finalStandings(ctx, seasonID, movements)
+	if err != nil {
+		return models.SeasonRollover{}, err
+	}
+
+	tx, err := storage.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return models.SeasonRollover{}, err
+	}
+	defer tx.Rollback()
+
+	if err := archiveStandings(ctx, tx, standings); err != nil {
+		return models.SeasonRollover{}, err
+	}
+	// The new season keeps the league's time zone
+	res, err := tx.ExecContext(ctx, `
+		INSERT INTO seasons (name, start_date, timezone)
+		SELECT ?, ?, timezone FROM seasons WHERE id = ?
+	`, opts.Name, opts.StartDate, seasonID)
+	if err != nil {
+		return models.SeasonRollover{}, fmt.Errorf("Failed to create season: %v", err)
+	}
+	newSeasonID, err := res.LastInsertId()
+	if err != nil {
+		return models.SeasonRollover{}, err
+	}
+	for _, m := range movements {
+		if _, err := tx.ExecContext(ctx, "UPDATE teams SET division_id = ? WHERE id = ?", m.ToDivisionID, m.TeamID); err != nil {
+			return models.SeasonRollover{}, fmt.Errorf("Failed to move %s: %v", m.TeamName, err)
+		}
+	}
+	for _, id := range opts.Withdrawn {
+		if _, err := tx.ExecContext(ctx, "UPDATE teams SET active = 0 WHERE id = ?", id); err != nil {
+			return models.SeasonRollover{}, fmt.Errorf("Failed to withdraw team %d: %v", id, err)
+		}
+	}
+	if err := regressRatings(ctx, tx, opts.Regression); err != nil {
+		return models.SeasonRollover{}, err
+	}
+	for _, t := range opts.NewTeams {
+		_, err := tx.ExecContext(ctx, "INSERT INTO teams (name, power, division_id) VALUES (?, ?, ?)", t.Name, t.Power, t.DivisionID)
+		if err != nil {
+			return models.SeasonRollover{}, fmt.Errorf("Failed to add team %s: %v", t.Name, err)
+		}
+	}
+	if err := createFixture(ctx, tx, int(newSeasonID)); err != nil {
+		return models.SeasonRollover{}, err
+	}
+	if err := tx.Commit(); err != nil {
+		return models.SeasonRollover{}, err
+	}
+
+	for _, m := range movements {
+		slog.InfoContext(ctx, "Team moved division", "team", m.TeamName, "movement", m.Reason)
+	}
+
+	// The new season has been committed, so it is reported even when ctx is cancelled
+	ctx = context.WithoutCancel(ctx)
+	season, err := getSeason(ctx, int(newSeasonID))
+	if err != nil {
+		return models.SeasonRollover{}, err
+	}
+	ratings, err := GetTeamsContext(ctx)
+	if err != nil {
+		return models.SeasonRollover{}, err
+	}
+	return models.SeasonRollover{
+		Season:    season,
+		Movements: movements,
+		Ratings:   ratings,
+	}, nil
+}
+
+// regressRatings moves every active team's power rating the given share of the way
+// towards the average rating of all active teams.
+func regressRatings(ctx context.Context, tx *sql.Tx, regression float64) error {
+	if regression == 0 {
+		return nil
+	}
+	_, err := tx.ExecContext(ctx, `
+		UPDATE teams
+		SET power = CAST(ROUND(power + ? * ((SELECT AVG(power) FROM teams WHERE active = 1) - power)) AS INTEGER)
+		WHERE active = 1
+	`, regression)
+	if err != nil {
+		return fmt.Errorf("Failed to regress team ratings: %v", err)
+	}
+	return nil
+}
+
+// seasonMovements works out which teams go up and down at the end of a season.
+// The number of teams promoted from a division must match the number relegated
+// from the division above it, so that division sizes stay the same.
+func seasonMovements(ctx context.Context, seasonID int) ([]models.TeamMovement, error) {
+	divisions, err := GetDivisionsContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	weeks, err := seasonWeeks(ctx, seasonID)
+	if err != nil {
+		return nil, err
+	}
+
+	movements := []models.TeamMovement{}
+	for i := 1; i < len(divisions); i++ {
+		upper, lower := divisions[i-1], divisions[i]
+
+		upperTable, err := divisionTable(ctx, seasonID, upper.ID, weeks, "", TableOptions{})
+		if err != nil {
+			return nil, err
+		}
+		lowerTable, err := divisionTable(ctx, seasonID, lower.ID, weeks, "", TableOptions{})
+		if err != nil {
+			return nil, err
+		}
+
+		promoted := lower.PromotionPlaces
+		if lower.PlayoffPlaces > 0 {
+			promoted++
+		}
+		if promoted != upper.RelegationPlaces {
+			return nil, fmt.Errorf("%s promotes %d team(s) but %s relegates %d",
+				lower.Name, promoted, upper.Name, upper.RelegationPlaces)
+		}
+		if promoted > len(lowerTable) || upper.RelegationPlaces > len(upperTable) {
+			return nil, fmt.Errorf("Not enough teams to move between %s and %s", upper.Name, lower.Name)
+		}
+
+		for _, row := range lowerTable[:lower.PromotionPlaces] {
+			movements = append(movements, models.TeamMovement{
+				TeamID: row.TeamID, TeamName: row.TeamName,
+				FromDivisionID: lower.ID, ToDivisionID: upper.ID,
+				Reason: MovementPromoted,
+			})
+		}
+		if lower.PlayoffPlaces > 0 {
+			winner, err := playoffWinner(ctx, seasonID, lower, lowerTable)
+			if err != nil {
+				return nil, err
+			}
+			movements = append(movements, models.TeamMovement{
+				TeamID: winner.TeamID, TeamName: winner.TeamName,
+				FromDivisionID: lower.ID, ToDivisionID: upper.ID,
+				Reason: MovementPlayoffPromoted,
+			})
+		}
+		for _, row := range upperTable[len(upperTable)-upper.RelegationPlaces:] {
+			movements = append(movements, models.TeamMovement{
+				TeamID: row.TeamID, TeamName: row.TeamName,
+				FromDivisionID: upper.ID, ToDivisionID: lower.ID,
+				Reason: MovementRelegated,
+			})
+		}
+	}
+	return movements, nil
+}
+
+// playoffWinner returns the team that won a division's playoffs in the given season.
+func playoffWinner(ctx context.Context, seasonID int, d models.Division, table []models.LeagueTableRow) (models.LeagueTableRow, error) {
+	p, err := getPlayoffs(ctx, seasonID, d.ID)
+	if err != nil {
+		return models.LeagueTableRow{}, fmt.Errorf("Playoffs of %s have not been played", d.Name)
+	}
+	if p.PromotedTeamID == 0 {
+		return models.LeagueTableRow{}, fmt.Errorf("Playoffs of %s have not been completed", d.Name)
+	}
+	for _, row := range table {
+		if row.TeamID == p.PromotedTeamID {
+			return row, nil
+		}
+	}
+	return models.LeagueTableRow{}, fmt.Errorf("Playoff winner %s is not in the %s table", p.PromotedTeamName, d.Name)
+}
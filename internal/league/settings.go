@@ -0,0 +1,93 @@
+package league
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// Scoring models of the simulation.
+const (
+	ModelPower = "power" // Stronger teams score more; the home side gets a boost
+	ModelEven  = "even"  // Every team plays as an average side, so only luck and home advantage count
+)
+
+// Models lists the scoring models in the order they are documented.
+var Models = []string{ModelPower, ModelEven}
+
+// evenPower is the rating every team plays with under the even model.
+const evenPower = 50
+
+// Settings are the rules of the simulation that can be configured at startup.
+type Settings struct {
+	Model                string // Scoring model, one of Models
+	Seed                 int64  // Seed of the random numbers; zero picks a new one every run
+	WinPoints            int
+	DrawPoints           int
+	LossPoints           int
+	PredictionFromWeek   int // First week championship predictions are made for
+	PredictionIterations int // Seasons simulated per prediction; zero shares the title by points instead
+}
+
+// DefaultSettings returns the rules used unless they are configured.
+func DefaultSettings() Settings {
+	return Settings{
+		Model:              ModelPower,
+		WinPoints:          3,
+		DrawPoints:         1,
+		LossPoints:         0,
+		PredictionFromWeek: 4,
+	}
+}
+
+// settings are the rules in use.
+var settings = DefaultSettings()
+
+// random is the source of every random choice of the simulation. It is safe for concurrent use.
+var random = rand.New(&lockedSource{src: rand.NewSource(time.Now().UnixNano()).(rand.Source64)})
+
+// Configure sets the rules of the simulation. It must be called before any season is
+// played; a non-zero seed makes the following simulations repeatable.
+func Configure(s Settings) {
+	settings = s
+	if s.Seed != 0 {
+		random = rand.New(&lockedSource{src: rand.NewSource(s.Seed).(rand.Source64)})
+	}
+}
+
+// PredictionFromWeek returns the first week championship predictions are made for.
+func PredictionFromWeek() int {
+	return settings.PredictionFromWeek
+}
+
+// modelPower returns the rating a team plays with under the configured scoring model.
+func modelPower(power int) int {
+	if settings.Model == ModelEven {
+		return evenPower
+	}
+	return power
+}
+
+// lockedSource guards a random source so HTTP handlers can simulate concurrently.
+type lockedSource struct {
+	mu  sync.Mutex
+	src rand.Source64
+}
+
+func (s *lockedSource) Int63() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.src.Int63()
+}
+
+func (s *lockedSource) Uint64() uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.src.Uint64()
+}
+
+func (s *lockedSource) Seed(seed int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.src.Seed(seed)
+}
@@ -1,7 +1,10 @@
 package league
 
 import (
+	"context"
 	"fmt"
+	"io"
+	"log/slog"
 
 	storage "go-football-league/internal/repository"
 )
@@ -11,74 +14,80 @@ import (
 // If not played it creates fixtures and simulates the match results.
 // Returns an error if any step fails.
 func PlayWeek(week int) error {
-	if played, err := weekAlreadyPlayed(week); err != nil {
+	return PlayWeekContext(context.Background(), week)
+}
+
+// PlayWeekContext is PlayWeek with a context. A cancelled context stops the week before its
+// first match is simulated, never halfway through.
+func PlayWeekContext(ctx context.Context, week int) error {
+	if played, err := weekAlreadyPlayed(ctx, week); err != nil {
 		return fmt.Errorf("Failed to check if week was already played: %v", err)
 	} else if played {
-		fmt.Printf("Week %d already played. Skipping.\n", week)
+		slog.InfoContext(ctx, "Week already played, skipping", "week", week)
 		return nil
 	}
 
-	fmt.Printf("Generating fixtures for week %d...\n", week)
+	slog.DebugContext(ctx, "Checking fixtures", "week", week)
 
-	if err := GenerateWeeklyMatches(week); err != nil {
+	if err := GenerateWeeklyMatchesContext(ctx, week); err != nil {
 		return fmt.Errorf("Failed to generate weekly matches: %v", err)
 	}
 
-	fmt.Printf("Simulating results for week %d...\n", week)
+	slog.DebugContext(ctx, "Simulating results", "week", week)
 
-	if err := SimulateScores(week); err != nil {
+	if err := SimulateScoresContext(ctx, week); err != nil {
 		return fmt.Errorf("Failed to simulate match scores: %v", err)
 	}
-
-	fmt.Printf("Week %d simulation completed.\n", week)
 	return nil
 }
 
 // weekAlreadyPlayed determines whether the given week already has recorded results.
 // It queries the database for matches with non-null score values.
 // Returns true if the week has already been played.
-func weekAlreadyPlayed(week int) (bool, error) {
+func weekAlreadyPlayed(ctx context.Context, week int) (bool, error) {
+	seasonID, err := CurrentSeasonIDContext(ctx)
+	if err != nil {
+		return false, err
+	}
+
 	var count int
-	err := storage.DB.QueryRow(`
+	err = storage.DB.QueryRowContext(ctx, `
 		SELECT COUNT(*) FROM matches 
-		WHERE week = ? AND home_goals IS NOT NULL AND away_goals IS NOT NULL
-	`, week).Scan(&count)
+		WHERE season_id = ? AND week = ? AND status IN ('played', 'awarded')
+	`, seasonID, week).Scan(&count)
 	if err != nil {
 		return false, err
 	}
 	return count > 0, nil
 }
 
-// PrintMatchesOfWeek prints the match results or fixtures for the given week.
-// If match scores are present, it displays them; otherwise, it shows placeholders.
-func PrintMatchesOfWeek(week int) error {
-	rows, err := storage.DB.Query(`
-		SELECT m.id, t1.name, t2.name, m.home_goals, m.away_goals
-		FROM matches m
-		JOIN teams t1 ON m.home_team_id = t1.id
-		JOIN teams t2 ON m.away_team_id = t2.id
-		WHERE m.week = ?
-	`, week)
+// LastPlayedWeek returns the latest week of the current season with a result, or zero
+// before the first match has been played.
+func LastPlayedWeek() (int, error) {
+	return LastPlayedWeekContext(context.Background())
+}
+
+// LastPlayedWeekContext is LastPlayedWeek with a context that cancels the queries.
+func LastPlayedWeekContext(ctx context.Context) (int, error) {
+	seasonID, err := CurrentSeasonIDContext(ctx)
 	if err != nil {
-		return err
+		return 0, err
 	}
-	defer rows.Close()
 
-	fmt.Printf("Match results for week %d:\n", week)
-	for rows.Next() {
-		var matchID int
-		var homeTeam, awayTeam string
-		var homeGoals, awayGoals *int
-		if err := rows.Scan(&matchID, &homeTeam, &awayTeam, &homeGoals, &awayGoals); err != nil {
-			return err
-		}
-
-		score := "vs" // Default text if match has not been played
-		if homeGoals != nil && awayGoals != nil {
-			score = fmt.Sprintf("%d-%d", *homeGoals, *awayGoals)
-		}
+	var week int
+	err = storage.DB.QueryRowContext(ctx, `
+		SELECT COALESCE(MAX(week), 0) FROM matches
+		WHERE season_id = ? AND status IN ('played', 'awarded')
+	`, seasonID).Scan(&week)
+	return week, err
+}
 
-		fmt.Printf("  Match %d: %s %s %s\n", matchID, homeTeam, score, awayTeam)
+// PrintMatchesOfWeek writes the match results or fixtures of the current season for the given week to w.
+// Played matches show their score; the others show "vs" and their kickoff.
+func PrintMatchesOfWeek(w io.Writer, week int) error {
+	matches, err := GetMatchesByWeek(week)
+	if err != nil {
+		return err
 	}
-	return nil
+	return textRenderer{}.Render(w, MatchesReport(fmt.Sprintf("Match results for week %d", week), matches))
 }
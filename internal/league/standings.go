@@ -1,89 +1,314 @@
 package league
 
 import (
-	"fmt"
+	"context"
+	"errors"
+	"log/slog"
 	"sort"
+	"strconv"
+	"time"
 
 	models "go-football-league/internal/domain"
 	storage "go-football-league/internal/repository"
 )
 
-// GenerateLeagueTable computes the league standings.
-// It reads played matches from the database and calculates total points, goals, wins, losses and draws for each team. The final table is sorted by points, gd and goals scored.
+// Table views.
+const (
+	ViewOverall = "overall" // Every match
+	ViewHome    = "home"    // Home matches only
+	ViewAway    = "away"    // Away matches only
+	ViewForm    = "form"    // Each team's last matches only
+)
+
+// defaultFormMatches is the number of matches in a form string unless asked otherwise.
+const defaultFormMatches = 5
+
+// TableOptions selects the matches a table is built from.
+type TableOptions struct {
+	View string // overall (default), home, away or form
+	Last int    // Matches in the form string and, for the form view, in the table
+}
+
+// ParseTableOptions reads a table view and form length as given in a query string.
+// Empty values select the overall table and five-match form.
+func ParseTableOptions(view, last string) (TableOptions, error) {
+	opts := TableOptions{View: view}
+	switch view {
+	case "", ViewOverall, ViewHome, ViewAway, ViewForm:
+	default:
+		return opts, errors.New("View must be overall, home, away or form")
+	}
+	if last != "" {
+		n, err := strconv.Atoi(last)
+		if err != nil || n < 1 {
+			return opts, errors.New("Last must be a number of matches of 1 or more")
+		}
+		opts.Last = n
+	}
+	return opts.withDefaults(), nil
+}
+
+// withDefaults fills in the overall view and the default form length.
+func (o TableOptions) withDefaults() TableOptions {
+	if o.View == "" {
+		o.View = ViewOverall
+	}
+	if o.Last == 0 {
+		o.Last = defaultFormMatches
+	}
+	return o
+}
+
+// matchResult is a played match as read by the standings engine.
+type matchResult struct {
+	HomeID    int
+	HomeName  string
+	HomeGoals int
+	AwayGoals int
+	AwayID    int
+	AwayName  string
+}
+
+// GenerateLeagueTable computes the league standings of the top division in the current season.
+// It reads played matches from the database and calculates total points, goals, wins, losses and draws for each team. The final table is sorted by points, gd, goals scored and fair-play points.
 func GenerateLeagueTable(upToWeek int) ([]models.LeagueTableRow, error) {
+	return GenerateLeagueTableViewContext(context.Background(), upToWeek, TableOptions{})
+}
+
+// GenerateLeagueTableContext is GenerateLeagueTable with a context that cancels the queries.
+func GenerateLeagueTableContext(ctx context.Context, upToWeek int) ([]models.LeagueTableRow, error) {
+	return GenerateLeagueTableViewContext(ctx, upToWeek, TableOptions{})
+}
+
+// GenerateLeagueTableView computes a home, away, form or overall table of the top division
+// in the current season up to a week.
+func GenerateLeagueTableView(upToWeek int, opts TableOptions) ([]models.LeagueTableRow, error) {
+	return GenerateLeagueTableViewContext(context.Background(), upToWeek, opts)
+}
+
+// GenerateLeagueTableViewContext is GenerateLeagueTableView with a context that cancels the queries.
+func GenerateLeagueTableViewContext(ctx context.Context, upToWeek int, opts TableOptions) ([]models.LeagueTableRow, error) {
+	seasonID, err := CurrentSeasonIDContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	divisionID, err := topDivisionID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return divisionTable(ctx, seasonID, divisionID, upToWeek, "", opts)
+}
+
+// GenerateLeagueTableAsOf computes the standings of the top division in the current season
+// from the matches that kicked off at or before the given time.
+func GenerateLeagueTableAsOf(asOf time.Time) ([]models.LeagueTableRow, error) {
+	return GenerateLeagueTableViewAsOf(asOf, TableOptions{})
+}
+
+// GenerateLeagueTableViewAsOf computes a home, away, form or overall table of the top division
+// in the current season from the matches that kicked off at or before the given time.
+func GenerateLeagueTableViewAsOf(asOf time.Time, opts TableOptions) ([]models.LeagueTableRow, error) {
+	return GenerateLeagueTableViewAsOfContext(context.Background(), asOf, opts)
+}
+
+// GenerateLeagueTableViewAsOfContext is GenerateLeagueTableViewAsOf with a context that cancels the queries.
+func GenerateLeagueTableViewAsOfContext(ctx context.Context, asOf time.Time, opts TableOptions) ([]models.LeagueTableRow, error) {
+	seasonID, err := CurrentSeasonIDContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	divisionID, err := topDivisionID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	week, err := weekAsOf(ctx, seasonID, asOf)
+	if err != nil {
+		return nil, err
+	}
+	return divisionTable(ctx, seasonID, divisionID, week, formatKickoff(asOf), opts)
+}
+
+// GenerateDivisionTable computes the standings of one division in the given season up to a week.
+func GenerateDivisionTable(seasonID, divisionID, upToWeek int) ([]models.LeagueTableRow, error) {
+	return GenerateDivisionTableContext(context.Background(), seasonID, divisionID, upToWeek)
+}
+
+// GenerateDivisionTableContext is GenerateDivisionTable with a context that cancels the queries.
+func GenerateDivisionTableContext(ctx context.Context, seasonID, divisionID, upToWeek int) ([]models.LeagueTableRow, error) {
+	return divisionTable(ctx, seasonID, divisionID, upToWeek, "", TableOptions{})
+}
+
+// GenerateDivisionTableAsOf computes the standings of one division in the given season
+// from the matches that kicked off at or before the given time.
+func GenerateDivisionTableAsOf(seasonID, divisionID int, asOf time.Time) ([]models.LeagueTableRow, error) {
+	return GenerateDivisionTableAsOfContext(context.Background(), seasonID, divisionID, asOf)
+}
+
+// GenerateDivisionTableAsOfContext is GenerateDivisionTableAsOf with a context that cancels the queries.
+func GenerateDivisionTableAsOfContext(ctx context.Context, seasonID, divisionID int, asOf time.Time) ([]models.LeagueTableRow, error) {
+	week, err := weekAsOf(ctx, seasonID, asOf)
+	if err != nil {
+		return nil, err
+	}
+	return divisionTable(ctx, seasonID, divisionID, week, formatKickoff(asOf), TableOptions{})
+}
+
+// divisionTable computes the standings of a division from the matches up to a week or, when
+// kickoffCutoff is set (stored kickoff layout), from the matches kicking off at or before it.
+// Fair-play points are always counted up to the given week.
+func divisionTable(ctx context.Context, seasonID, divisionID, upToWeek int, kickoffCutoff string, opts TableOptions) ([]models.LeagueTableRow, error) {
 	// Query all played matches up to the specified week
-	rows, err := storage.DB.Query(`
+	rows, err := storage.DB.QueryContext(ctx, `
 		SELECT 
 			m.home_team_id, t1.name, m.home_goals, m.away_goals,
 			m.away_team_id, t2.name
 		FROM matches m
 		JOIN teams t1 ON m.home_team_id = t1.id
 		JOIN teams t2 ON m.away_team_id = t2.id
-		WHERE m.week <= ? AND m.home_goals IS NOT NULL AND m.away_goals IS NOT NULL
-	`, upToWeek)
+		WHERE m.season_id = ? AND m.division_id = ?
+		  AND CASE WHEN ? = '' THEN m.week <= ? ELSE m.kickoff <= ? END
+		  AND m.status IN ('played', 'awarded')
+		  AND m.home_goals IS NOT NULL AND m.away_goals IS NOT NULL
+		ORDER BY m.kickoff, m.week, m.id
+	`, seasonID, divisionID, kickoffCutoff, upToWeek, kickoffCutoff)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	// Use a map to collect statistics per team
-	stats := make(map[int]*models.LeagueTableRow)
-
+	var results []matchResult
 	for rows.Next() {
-		var homeID, awayID, homeGoals, awayGoals int
-		var homeName, awayName string
-
 		// Read one match result
-		if err := rows.Scan(&homeID, &homeName, &homeGoals, &awayGoals, &awayID, &awayName); err != nil {
+		var r matchResult
+		if err := rows.Scan(&r.HomeID, &r.HomeName, &r.HomeGoals, &r.AwayGoals, &r.AwayID, &r.AwayName); err != nil {
 			return nil, err
 		}
+		results = append(results, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
 
-		// Initialize team rows if not already added
-		if _, ok := stats[homeID]; !ok {
-			stats[homeID] = &models.LeagueTableRow{TeamID: homeID, TeamName: homeName}
-		}
-		if _, ok := stats[awayID]; !ok {
-			stats[awayID] = &models.LeagueTableRow{TeamID: awayID, TeamName: awayName}
+	// Fair-play points are the last tie-breaker
+	fairPlay, err := fairPlayPoints(ctx, seasonID, upToWeek)
+	if err != nil {
+		return nil, err
+	}
+	table := standingsTable(results, nil, opts, fairPlay)
+
+	slog.DebugContext(ctx, "League table generated", "season", seasonID, "division", divisionID, "week", upToWeek)
+	return table, nil
+}
+
+// standingsTable is the standings engine of league tables and tournament groups. It tallies
+// the results (in the order they were played) counted by the view, adds an empty row for
+// every team in members that has not played yet, in name order, and sorts the table.
+func standingsTable(results []matchResult, members map[int]string, opts TableOptions, fairPlay map[int]int) []models.LeagueTableRow {
+	table := tallyView(results, opts)
+
+	listed := make(map[int]bool, len(table))
+	for _, row := range table {
+		listed[row.TeamID] = true
+	}
+	var unplayed []models.LeagueTableRow
+	for id, name := range members {
+		if !listed[id] {
+			unplayed = append(unplayed, models.LeagueTableRow{TeamID: id, TeamName: name})
 		}
+	}
+	sort.Slice(unplayed, func(i, j int) bool {
+		return unplayed[i].TeamName < unplayed[j].TeamName
+	})
+	table = append(table, unplayed...)
+
+	sortStandings(table, fairPlay)
+	return table
+}
 
-		home := stats[homeID]
-		away := stats[awayID]
-
-		// Update matches played
-		home.Played++
-		away.Played++
-
-		// Update goals scored and conceded
-		home.GoalsFor += homeGoals
-		home.GoalsAgainst += awayGoals
-		away.GoalsFor += awayGoals
-		away.GoalsAgainst += homeGoals
-
-		// Assign points and match results
-		if homeGoals > awayGoals {
-			home.Wins++
-			home.Points += 3
-			away.Losses++
-		} else if awayGoals > homeGoals {
-			away.Wins++
-			away.Points += 3
-			home.Losses++
-		} else {
-			home.Draws++
-			away.Draws++
-			home.Points++
-			away.Points++
+// teamResult is one team's side of a played match.
+type teamResult struct {
+	TeamName     string
+	GoalsFor     int
+	GoalsAgainst int
+	Home         bool
+}
+
+// tallyView tallies the results (in the order they were played) counted by a table view:
+// only home or away matches, or each team's last matches for the form view. Every team
+// appearing in the results gets a row, with the form string of the matches counted.
+// The returned rows are not sorted.
+func tallyView(results []matchResult, opts TableOptions) []models.LeagueTableRow {
+	opts = opts.withDefaults()
+
+	// Split every match into the home and away team's side
+	sides := make(map[int][]teamResult)
+	var teams []int
+	add := func(teamID int, side teamResult) {
+		if _, ok := sides[teamID]; !ok {
+			teams = append(teams, teamID)
 		}
+		sides[teamID] = append(sides[teamID], side)
+	}
+	for _, r := range results {
+		add(r.HomeID, teamResult{TeamName: r.HomeName, GoalsFor: r.HomeGoals, GoalsAgainst: r.AwayGoals, Home: true})
+		add(r.AwayID, teamResult{TeamName: r.AwayName, GoalsFor: r.AwayGoals, GoalsAgainst: r.HomeGoals})
 	}
 
-	// Finalize table with goal difference and flatten the map
 	var table []models.LeagueTableRow
-	for _, row := range stats {
-		row.GoalDiff = row.GoalsFor - row.GoalsAgainst
-		table = append(table, *row)
+	for _, teamID := range teams {
+		row := models.LeagueTableRow{TeamID: teamID, TeamName: sides[teamID][0].TeamName}
+
+		var counted []teamResult
+		for _, side := range sides[teamID] {
+			if (opts.View == ViewHome && !side.Home) || (opts.View == ViewAway && side.Home) {
+				continue
+			}
+			counted = append(counted, side)
+		}
+		if opts.View == ViewForm && len(counted) > opts.Last {
+			counted = counted[len(counted)-opts.Last:]
+		}
+
+		for i, side := range counted {
+			result := addResult(&row, side.GoalsFor, side.GoalsAgainst)
+			if i >= len(counted)-opts.Last {
+				row.Form += result
+			}
+		}
+		table = append(table, row)
 	}
+	return table
+}
+
+// addResult adds one match to a team's row and returns the result as W, D or L.
+func addResult(row *models.LeagueTableRow, goalsFor, goalsAgainst int) string {
+	// Update matches played and goals scored and conceded
+	row.Played++
+	row.GoalsFor += goalsFor
+	row.GoalsAgainst += goalsAgainst
+	row.GoalDiff = row.GoalsFor - row.GoalsAgainst
 
-	// Sort the table: Points > Goal Difference > Goals For
+	// Assign points and match results
+	switch {
+	case goalsFor > goalsAgainst:
+		row.Wins++
+		row.Points += settings.WinPoints
+		return "W"
+	case goalsFor < goalsAgainst:
+		row.Losses++
+		row.Points += settings.LossPoints
+		return "L"
+	default:
+		row.Draws++
+		row.Points += settings.DrawPoints
+		return "D"
+	}
+}
+
+// sortStandings orders a table by points, goal difference and goals scored.
+// Remaining ties are broken by fair-play points (fewer is better) when given.
+func sortStandings(table []models.LeagueTableRow, fairPlay map[int]int) {
+	// Sort the table: Points > Goal Difference > Goals For > Fair Play
 	sort.SliceStable(table, func(i, j int) bool {
 		if table[i].Points != table[j].Points {
 			return table[i].Points > table[j].Points
@@ -91,9 +316,9 @@ func GenerateLeagueTable(upToWeek int) ([]models.LeagueTableRow, error) {
 		if table[i].GoalDiff != table[j].GoalDiff {
 			return table[i].GoalDiff > table[j].GoalDiff
 		}
-		return table[i].GoalsFor > table[j].GoalsFor
+		if table[i].GoalsFor != table[j].GoalsFor {
+			return table[i].GoalsFor > table[j].GoalsFor
+		}
+		return fairPlay[table[i].TeamID] < fairPlay[table[j].TeamID]
 	})
-
-	fmt.Println("League table generated.")
-	return table, nil
 }
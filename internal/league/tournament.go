@@ -0,0 +1,475 @@
+package league
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+
+	models "go-football-league/internal/domain"
+	storage "go-football-league/internal/repository"
+)
+
+// Stages of a tournament.
+const (
+	StageGroups   = "group stage"
+	StageKnockout = "knockout stage"
+	StageFinished = "finished"
+)
+
+// TournamentOptions configures a group stage plus knockout tournament.
+type TournamentOptions struct {
+	Name            string
+	TeamIDs         []int // Teams taking part; all teams when empty
+	Groups          int
+	AdvancePerGroup int  // Top teams of each group that reach the knockout stage
+	BestThirds      int  // Best teams placed just below the qualifying places that also advance
+	HomeAndAway     bool // Play each group opponent twice instead of once
+}
+
+// CreateTournament draws the groups of a new tournament in the current season and
+// schedules the group matches. Teams are split into pots by power and every group
+// receives one team from each pot, and the groups are scheduled by the league's fixture
+// scheduler. The tournament is stored in one transaction. It returns the ID of the tournament.
+func CreateTournament(opts TournamentOptions) (int, error) {
+	return CreateTournamentContext(context.Background(), opts)
+}
+
+// CreateTournamentContext is CreateTournament with a context that cancels the queries.
+func CreateTournamentContext(ctx context.Context, opts TournamentOptions) (int, error) {
+	if strings.TrimSpace(opts.Name) == "" {
+		return 0, errors.New("Tournament name is required")
+	}
+	teams, err := loadContenders(ctx, storage.DB, opts.TeamIDs)
+	if err != nil {
+		return 0, err
+	}
+	if err := validateTournament(opts, len(teams)); err != nil {
+		return 0, err
+	}
+
+	seasonID, err := CurrentSeasonIDContext(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	tx, err := storage.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, `
+		INSERT INTO tournaments (season_id, name, groups_count, advance_per_group, best_thirds, home_and_away)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, seasonID, opts.Name, opts.Groups, opts.AdvancePerGroup, opts.BestThirds, opts.HomeAndAway)
+	if err != nil {
+		return 0, fmt.Errorf("Failed to create tournament: %v", err)
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+	tournamentID := int(id)
+
+	// Seeded pot draw: the strongest teams form pot 1, the next strongest pot 2 and so on
+	sort.SliceStable(teams, func(i, j int) bool {
+		return teams[i].Power > teams[j].Power
+	})
+	groups := make([][]int, opts.Groups)
+	for start, pot := 0, 1; start < len(teams); start, pot = start+opts.Groups, pot+1 {
+		end := min(start+opts.Groups, len(teams))
+		drawn := append([]contender{}, teams[start:end]...)
+		random.Shuffle(len(drawn), func(i, j int) {
+			drawn[i], drawn[j] = drawn[j], drawn[i]
+		})
+		for g, t := range drawn {
+			_, err := tx.ExecContext(ctx, `
+				INSERT INTO tournament_teams (tournament_id, team_id, group_name, pot)
+				VALUES (?, ?, ?, ?)
+			`, tournamentID, t.ID, groupName(g), pot)
+			if err != nil {
+				return 0, fmt.Errorf("Failed to draw team %d: %v", t.ID, err)
+			}
+			groups[g] = append(groups[g], t.ID)
+		}
+	}
+
+	// Round-robin fixtures within each group, played on the same matchdays the way the
+	// divisions of the league are
+	fixtures, _ := scheduleFixture(groups, nil, nil)
+	for g, fixture := range fixtures {
+		if !opts.HomeAndAway {
+			fixture = firstLeg(fixture)
+		}
+		for _, m := range fixture {
+			_, err := tx.ExecContext(ctx, `
+				INSERT INTO tournament_matches (tournament_id, group_name, matchday, home_team_id, away_team_id)
+				VALUES (?, ?, ?, ?, ?)
+			`, tournamentID, groupName(g), m.Week, m.Home, m.Away)
+			if err != nil {
+				return 0, fmt.Errorf("Failed to schedule group match: %v", err)
+			}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	slog.InfoContext(ctx, "Tournament drawn", "tournament", tournamentID, "name", opts.Name, "groups", opts.Groups)
+	return tournamentID, nil
+}
+
+// validateTournament checks that the tournament format works for the given number of teams.
+func validateTournament(opts TournamentOptions, teams int) error {
+	if opts.Groups < 1 {
+		return errors.New("A tournament needs at least 1 group")
+	}
+	if opts.Groups > 26 {
+		return errors.New("A tournament can have at most 26 groups")
+	}
+	smallestGroup := teams / opts.Groups
+	if smallestGroup < 2 {
+		return fmt.Errorf("%d teams are not enough for %d groups", teams, opts.Groups)
+	}
+	if opts.AdvancePerGroup < 1 || opts.AdvancePerGroup >= smallestGroup {
+		return fmt.Errorf("Between 1 and %d teams per group can advance", smallestGroup-1)
+	}
+	if opts.BestThirds < 0 || opts.BestThirds > opts.Groups {
+		return fmt.Errorf("Between 0 and %d best-placed extra teams can advance", opts.Groups)
+	}
+	if opts.Groups*opts.AdvancePerGroup+opts.BestThirds < 2 {
+		return errors.New("At least 2 teams must reach the knockout stage")
+	}
+	return nil
+}
+
+// groupName returns the letter of the group with the given index.
+func groupName(index int) string {
+	return string(rune('A' + index))
+}
+
+// PlayTournament advances a tournament by one step: the next group matchday, or the
+// next knockout round once the group stage is complete. The knockout bracket is
+// drawn as soon as the last group matchday has been played.
+func PlayTournament(tournamentID int) error {
+	return PlayTournamentContext(context.Background(), tournamentID)
+}
+
+// PlayTournamentContext is PlayTournament with a context. A cancelled context stops the
+// step before its first match is played, never halfway through.
+func PlayTournamentContext(ctx context.Context, tournamentID int) error {
+	t, err := getTournament(ctx, tournamentID)
+	if err != nil {
+		return err
+	}
+	if t.CupID != 0 {
+		return PlayCupRoundContext(ctx, t.CupID)
+	}
+
+	// Play the earliest matchday that still has unplayed matches
+	var matchday sql.NullInt64
+	err = storage.DB.QueryRowContext(ctx, `
+		SELECT MIN(matchday) FROM tournament_matches
+		WHERE tournament_id = ? AND home_goals IS NULL
+	`, tournamentID).Scan(&matchday)
+	if err != nil {
+		return err
+	}
+	if matchday.Valid {
+		if err := playGroupMatchday(ctx, t, int(matchday.Int64)); err != nil {
+			return err
+		}
+
+		// The matchday has been played, so the bracket is drawn even when ctx is cancelled
+		ctx = context.WithoutCancel(ctx)
+		err = storage.DB.QueryRowContext(ctx, `
+			SELECT MIN(matchday) FROM tournament_matches
+			WHERE tournament_id = ? AND home_goals IS NULL
+		`, tournamentID).Scan(&matchday)
+		if err != nil || matchday.Valid {
+			return err
+		}
+	}
+
+	// Group stage complete: seed the qualified teams into the knockout bracket
+	return drawKnockoutStage(ctx, t)
+}
+
+// playGroupMatchday simulates all group matches of a matchday.
+// Group matches are on neutral ground unless the groups are played home and away.
+// Once the matches have been read the matchday is played to the end even when ctx is cancelled.
+func playGroupMatchday(ctx context.Context, t models.Tournament, matchday int) error {
+	rows, err := storage.DB.QueryContext(ctx, `
+		SELECT tm.id, ht.power, at.power
+		FROM tournament_matches tm
+		JOIN teams ht ON tm.home_team_id = ht.id
+		JOIN teams at ON tm.away_team_id = at.id
+		WHERE tm.tournament_id = ? AND tm.matchday = ? AND tm.home_goals IS NULL
+	`, t.ID, matchday)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	type match struct {
+		ID        int
+		PowerHome int
+		PowerAway int
+	}
+	var matches []match
+	for rows.Next() {
+		var m match
+		if err := rows.Scan(&m.ID, &m.PowerHome, &m.PowerAway); err != nil {
+			return err
+		}
+		matches = append(matches, m)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	// From here on the context only carries the request ID into the log
+	ctx = context.WithoutCancel(ctx)
+
+	for _, m := range matches {
+		homeGoals := scoreGoals(m.PowerHome, t.HomeAndAway)
+		awayGoals := scoreGoals(m.PowerAway, false)
+		_, err := storage.DB.ExecContext(ctx, `
+			UPDATE tournament_matches SET home_goals = ?, away_goals = ? WHERE id = ?
+		`, homeGoals, awayGoals, m.ID)
+		if err != nil {
+			return fmt.Errorf("Failed to update group match %d: %v", m.ID, err)
+		}
+		matchesSimulated.With(competitionTournament).Inc()
+	}
+	if len(matches) > 0 {
+		simulationsRun.With(competitionTournament).Inc()
+	}
+	slog.InfoContext(ctx, "Tournament matchday played", "tournament", t.ID, "matchday", matchday)
+	return nil
+}
+
+// drawKnockoutStage creates the knockout cup of a tournament from its qualified teams.
+// Group winners are seeded first, then runners-up and so on, then the best extra teams,
+// so the winners meet the runners-up in the first round. Teams of the same group are
+// kept apart in the first round.
+func drawKnockoutStage(ctx context.Context, t models.Tournament) error {
+	groups, err := groupTables(ctx, t.ID)
+	if err != nil {
+		return err
+	}
+	qualified := qualifiedTeams(t, groups)
+
+	groupOf := make(map[int]string)
+	for _, g := range groups {
+		for _, row := range g.Table {
+			groupOf[row.TeamID] = g.Name
+		}
+	}
+	seeds := make([]int, len(qualified))
+	for i, row := range qualified {
+		seeds[i] = row.TeamID
+	}
+	separateGroups(seeds, tiers(t, len(seeds)), groupOf)
+	teams, err := loadContenders(ctx, storage.DB, seeds)
+	if err != nil {
+		return err
+	}
+
+	tx, err := storage.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	cupID, err := createCup(ctx, tx, t.SeasonID, CupOptions{Name: t.Name + " Knockout Stage", Seeded: true}, teams)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, "UPDATE tournaments SET cup_id = ? WHERE id = ?", cupID, t.ID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// qualifiedTeams returns the teams that reach the knockout stage in seeding order.
+// Teams with the same group position are ranked by points, goal difference and goals.
+func qualifiedTeams(t models.Tournament, groups []models.TournamentGroup) []models.LeagueTableRow {
+	var qualified []models.LeagueTableRow
+	for place := 0; place < t.AdvancePerGroup; place++ {
+		var sameplace []models.LeagueTableRow
+		for _, g := range groups {
+			if place < len(g.Table) {
+				sameplace = append(sameplace, g.Table[place])
+			}
+		}
+		sortStandings(sameplace, nil)
+		qualified = append(qualified, sameplace...)
+	}
+
+	if t.BestThirds > 0 {
+		var next []models.LeagueTableRow
+		for _, g := range groups {
+			if t.AdvancePerGroup < len(g.Table) {
+				next = append(next, g.Table[t.AdvancePerGroup])
+			}
+		}
+		sortStandings(next, nil)
+		qualified = append(qualified, next[:min(t.BestThirds, len(next))]...)
+	}
+	return qualified
+}
+
+// tiers returns the tier of every seed in qualifiedTeams order: the group position for the
+// teams that qualified by their place, then one more tier for the best extra teams.
+func tiers(t models.Tournament, seeds int) []int {
+	tier := make([]int, seeds)
+	for i := range tier {
+		tier[i] = min(i/max(t.GroupCount, 1), t.AdvancePerGroup)
+	}
+	return tier
+}
+
+// separateGroups reorders the seeds of a knockout bracket (see seedPositions) so that no
+// first-round tie is between two teams of the same group. Only the lower seeds of the
+// ties move, and only within their tier, so group winners still meet runners-up. Ties
+// that cannot be separated, such as in a tournament with one group, are left as drawn.
+func separateGroups(seeds []int, tier []int, groupOf map[int]string) {
+	type tie struct{ high, low int } // Indexes into seeds
+	var ties []tie
+	positions := seedPositions(bracketSize(len(seeds)))
+	for slot := 0; slot < len(positions)/2; slot++ {
+		if away := positions[2*slot+1]; away <= len(seeds) {
+			ties = append(ties, tie{positions[2*slot] - 1, away - 1})
+		}
+	}
+	apart := func(a, b int) bool {
+		return groupOf[seeds[a]] != groupOf[seeds[b]]
+	}
+
+	for i, a := range ties {
+		if apart(a.high, a.low) {
+			continue
+		}
+		for j, b := range ties {
+			if i == j || tier[a.low] != tier[b.low] {
+				continue
+			}
+			seeds[a.low], seeds[b.low] = seeds[b.low], seeds[a.low]
+			if apart(a.high, a.low) && apart(b.high, b.low) {
+				break
+			}
+			seeds[a.low], seeds[b.low] = seeds[b.low], seeds[a.low]
+		}
+	}
+}
+
+// getTournament reads a tournament's format.
+func getTournament(ctx context.Context, tournamentID int) (models.Tournament, error) {
+	var t models.Tournament
+	var cupID sql.NullInt64
+	err := storage.DB.QueryRowContext(ctx, `
+		SELECT id, season_id, name, groups_count, advance_per_group, best_thirds, home_and_away, cup_id
+		FROM tournaments WHERE id = ?
+	`, tournamentID).Scan(&t.ID, &t.SeasonID, &t.Name, &t.GroupCount, &t.AdvancePerGroup,
+		&t.BestThirds, &t.HomeAndAway, &cupID)
+	if err == sql.ErrNoRows {
+		return t, fmt.Errorf("Tournament %d does not exist", tournamentID)
+	}
+	t.CupID = int(cupID.Int64)
+	return t, err
+}
+
+// groupTables builds the standings and match list of every group of a tournament.
+// The standings are computed by the same engine as the league table, without fair-play
+// points as tournament matches have no bookings.
+func groupTables(ctx context.Context, tournamentID int) ([]models.TournamentGroup, error) {
+	rows, err := storage.DB.QueryContext(ctx, `
+		SELECT tm.id, tm.group_name, tm.matchday, tm.home_team_id, ht.name,
+		       tm.away_team_id, at.name, tm.home_goals, tm.away_goals
+		FROM tournament_matches tm
+		JOIN teams ht ON tm.home_team_id = ht.id
+		JOIN teams at ON tm.away_team_id = at.id
+		WHERE tm.tournament_id = ?
+		ORDER BY tm.group_name, tm.matchday, tm.id
+	`, tournamentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var groups []models.TournamentGroup
+	results := make(map[string][]matchResult)
+	members := make(map[string]map[int]string)
+	for rows.Next() {
+		var m models.TournamentMatch
+		err := rows.Scan(&m.ID, &m.Group, &m.Matchday, &m.HomeTeamID, &m.HomeTeamName,
+			&m.AwayTeamID, &m.AwayTeamName, &m.HomeGoals, &m.AwayGoals)
+		if err != nil {
+			return nil, err
+		}
+		if len(groups) == 0 || groups[len(groups)-1].Name != m.Group {
+			groups = append(groups, models.TournamentGroup{Name: m.Group})
+			members[m.Group] = make(map[int]string)
+		}
+		g := &groups[len(groups)-1]
+		g.Matches = append(g.Matches, m)
+		members[m.Group][m.HomeTeamID] = m.HomeTeamName
+		members[m.Group][m.AwayTeamID] = m.AwayTeamName
+
+		if m.HomeGoals != nil && m.AwayGoals != nil {
+			results[m.Group] = append(results[m.Group], matchResult{
+				HomeID: m.HomeTeamID, HomeName: m.HomeTeamName, HomeGoals: *m.HomeGoals,
+				AwayID: m.AwayTeamID, AwayName: m.AwayTeamName, AwayGoals: *m.AwayGoals,
+			})
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// Teams that have not played yet still get a row
+	for i := range groups {
+		g := &groups[i]
+		g.Table = standingsTable(results[g.Name], members[g.Name], TableOptions{}, nil)
+	}
+	return groups, nil
+}
+
+// GetTournament returns the groups, qualified teams and knockout bracket of a tournament.
+func GetTournament(tournamentID int) (models.TournamentOverview, error) {
+	return GetTournamentContext(context.Background(), tournamentID)
+}
+
+// GetTournamentContext is GetTournament with a context that cancels the queries.
+func GetTournamentContext(ctx context.Context, tournamentID int) (models.TournamentOverview, error) {
+	t, err := getTournament(ctx, tournamentID)
+	if err != nil {
+		return models.TournamentOverview{}, err
+	}
+	overview := models.TournamentOverview{Tournament: t, Stage: StageGroups}
+
+	overview.Groups, err = groupTables(ctx, tournamentID)
+	if err != nil {
+		return overview, err
+	}
+	if t.CupID == 0 {
+		return overview, nil
+	}
+
+	overview.Stage = StageKnockout
+	overview.Qualified = qualifiedTeams(t, overview.Groups)
+	bracket, err := GetCupBracketContext(ctx, t.CupID)
+	if err != nil {
+		return overview, err
+	}
+	if bracket.WinnerTeamID != 0 {
+		overview.Stage = StageFinished
+	}
+	overview.Knockout = &bracket
+	return overview, nil
+}
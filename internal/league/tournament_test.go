@@ -0,0 +1,97 @@
+package league
+
+import (
+	"testing"
+
+	models "go-football-league/internal/domain"
+)
+
+func TestSeparateGroups(t *testing.T) {
+	tests := []struct {
+		name    string
+		format  models.Tournament
+		seeds   []string // Group of every seed in qualifiedTeams order
+		wantAll bool     // Every first-round tie is between teams of different groups
+	}{
+		{
+			name:    "two groups, two advance",
+			format:  models.Tournament{GroupCount: 2, AdvancePerGroup: 2},
+			seeds:   []string{"A", "B", "B", "A"},
+			wantAll: true,
+		},
+		{
+			name:    "four groups, two advance",
+			format:  models.Tournament{GroupCount: 4, AdvancePerGroup: 2},
+			seeds:   []string{"A", "B", "C", "D", "C", "B", "D", "A"},
+			wantAll: true,
+		},
+		{
+			name:    "eight groups, two advance",
+			format:  models.Tournament{GroupCount: 8, AdvancePerGroup: 2},
+			seeds:   []string{"A", "B", "C", "D", "E", "F", "G", "H", "H", "G", "F", "E", "D", "C", "B", "A"},
+			wantAll: true,
+		},
+		{
+			name:    "three groups and two best thirds",
+			format:  models.Tournament{GroupCount: 3, AdvancePerGroup: 2, BestThirds: 2},
+			seeds:   []string{"A", "B", "C", "B", "A", "C", "A", "B"},
+			wantAll: true,
+		},
+		{
+			name:    "three groups with byes",
+			format:  models.Tournament{GroupCount: 3, AdvancePerGroup: 2},
+			seeds:   []string{"A", "B", "C", "C", "A", "B"},
+			wantAll: true,
+		},
+		{
+			name:   "one group",
+			format: models.Tournament{GroupCount: 1, AdvancePerGroup: 2},
+			seeds:  []string{"A", "A"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			seeds := make([]int, len(tt.seeds))
+			groupOf := make(map[int]string)
+			for i, g := range tt.seeds {
+				seeds[i] = i + 1
+				groupOf[i+1] = g
+			}
+			tier := tiers(tt.format, len(seeds))
+			separateGroups(seeds, tier, groupOf)
+
+			// The seeds are a permutation that keeps every team in its tier
+			seen := make(map[int]bool)
+			for i, id := range seeds {
+				if seen[id] || tier[id-1] != tier[i] {
+					t.Fatalf("seeds %v are not a reordering within the tiers %v", seeds, tier)
+				}
+				seen[id] = true
+			}
+
+			positions := seedPositions(bracketSize(len(seeds)))
+			for slot := 0; slot < len(positions)/2; slot++ {
+				home, away := positions[2*slot], positions[2*slot+1]
+				if away > len(seeds) {
+					continue
+				}
+				if tt.wantAll && groupOf[seeds[home-1]] == groupOf[seeds[away-1]] {
+					t.Errorf("seeds %v: first-round tie %d between two teams of group %s", seeds, slot, groupOf[seeds[home-1]])
+				}
+				if tier[home-1] > tier[away-1] {
+					t.Errorf("seeds %v: first-round tie %d puts a lower tier above a higher one", seeds, slot)
+				}
+			}
+		})
+	}
+}
+
+func TestTiers(t *testing.T) {
+	got := tiers(models.Tournament{GroupCount: 3, AdvancePerGroup: 2, BestThirds: 2}, 8)
+	want := []int{0, 0, 0, 1, 1, 1, 2, 2}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+	}
+}
@@ -0,0 +1,235 @@
+package league
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	models "go-football-league/internal/domain"
+	storage "go-football-league/internal/repository"
+)
+
+// GetVenues returns all venues ordered by name.
+func GetVenues() ([]models.Venue, error) {
+	return GetVenuesContext(context.Background())
+}
+
+// GetVenuesContext is GetVenues with a context that cancels the query.
+func GetVenuesContext(ctx context.Context) ([]models.Venue, error) {
+	rows, err := storage.DB.QueryContext(ctx, "SELECT id, name, city, capacity FROM venues ORDER BY name")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	venues := []models.Venue{}
+	for rows.Next() {
+		var v models.Venue
+		if err := rows.Scan(&v.ID, &v.Name, &v.City, &v.Capacity); err != nil {
+			return nil, err
+		}
+		venues = append(venues, v)
+	}
+	return venues, rows.Err()
+}
+
+// GetVenue returns a single venue.
+func GetVenue(venueID int) (models.Venue, error) {
+	return GetVenueContext(context.Background(), venueID)
+}
+
+// GetVenueContext is GetVenue with a context that cancels the query.
+func GetVenueContext(ctx context.Context, venueID int) (models.Venue, error) {
+	var v models.Venue
+	err := storage.DB.QueryRowContext(ctx, `
+		SELECT id, name, city, capacity FROM venues WHERE id = ?
+	`, venueID).Scan(&v.ID, &v.Name, &v.City, &v.Capacity)
+	if err == sql.ErrNoRows {
+		return v, fmt.Errorf("Venue %d does not exist", venueID)
+	}
+	return v, err
+}
+
+// CreateVenue adds a venue and returns its ID.
+func CreateVenue(v models.Venue) (int, error) {
+	return CreateVenueContext(context.Background(), v)
+}
+
+// CreateVenueContext is CreateVenue with a context that cancels the insert.
+func CreateVenueContext(ctx context.Context, v models.Venue) (int, error) {
+	if err := validateVenue(v); err != nil {
+		return 0, err
+	}
+
+	res, err := storage.DB.ExecContext(ctx, `
+		INSERT INTO venues (name, city, capacity) VALUES (?, ?, ?)
+	`, v.Name, v.City, v.Capacity)
+	if err != nil {
+		return 0, fmt.Errorf("Failed to create venue: %v", err)
+	}
+	id, err := res.LastInsertId()
+	return int(id), err
+}
+
+// UpdateVenue changes the name, city and capacity of a venue. The matches still to be played
+// there get a new revision, so calendars pick up the new location.
+func UpdateVenue(v models.Venue) error {
+	return UpdateVenueContext(context.Background(), v)
+}
+
+// UpdateVenueContext is UpdateVenue with a context that cancels the update.
+func UpdateVenueContext(ctx context.Context, v models.Venue) error {
+	if err := validateVenue(v); err != nil {
+		return err
+	}
+
+	tx, err := storage.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, `
+		UPDATE venues SET name = ?, city = ?, capacity = ? WHERE id = ?
+	`, v.Name, v.City, v.Capacity, v.ID)
+	if err != nil {
+		return fmt.Errorf("Failed to update venue: %v", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("Venue %d does not exist", v.ID)
+	}
+	_, err = tx.ExecContext(ctx, `
+		UPDATE matches
+		SET revision = revision + 1, updated_at = `+nowUTC+`
+		WHERE status NOT IN ('played', 'awarded')
+		  AND (venue_id = ?1 OR (venue_id IS NULL AND home_team_id IN (SELECT id FROM teams WHERE venue_id = ?1)))
+	`, v.ID)
+	if err != nil {
+		return fmt.Errorf("Failed to update the matches at venue %d: %v", v.ID, err)
+	}
+	return tx.Commit()
+}
+
+// DeleteVenue removes a venue that is neither a team's home ground nor the venue of a match.
+func DeleteVenue(venueID int) error {
+	return DeleteVenueContext(context.Background(), venueID)
+}
+
+// DeleteVenueContext is DeleteVenue with a context that cancels the queries.
+func DeleteVenueContext(ctx context.Context, venueID int) error {
+	var used bool
+	err := storage.DB.QueryRowContext(ctx, `
+		SELECT EXISTS (SELECT 1 FROM teams WHERE venue_id = ?1)
+		    OR EXISTS (SELECT 1 FROM matches WHERE venue_id = ?1)
+	`, venueID).Scan(&used)
+	if err != nil {
+		return err
+	}
+	if used {
+		return fmt.Errorf("Venue %d is still used by a team or match", venueID)
+	}
+
+	res, err := storage.DB.ExecContext(ctx, "DELETE FROM venues WHERE id = ?", venueID)
+	if err != nil {
+		return fmt.Errorf("Failed to delete venue: %v", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("Venue %d does not exist", venueID)
+	}
+	return nil
+}
+
+// validateVenue checks the details of a venue before it is stored.
+func validateVenue(v models.Venue) error {
+	if strings.TrimSpace(v.Name) == "" {
+		return errors.New("Venue name is required")
+	}
+	if strings.TrimSpace(v.City) == "" {
+		return errors.New("Venue city is required")
+	}
+	if v.Capacity < 0 {
+		return errors.New("Venue capacity cannot be negative")
+	}
+	return nil
+}
+
+// SetTeamVenue sets the home ground of a team; a zero venue clears it.
+// Matches still to be played follow the new ground, and get a new revision, unless they have
+// a venue of their own.
+func SetTeamVenue(teamID, venueID int) error {
+	return SetTeamVenueContext(context.Background(), teamID, venueID)
+}
+
+// SetTeamVenueContext is SetTeamVenue with a context that cancels the queries.
+func SetTeamVenueContext(ctx context.Context, teamID, venueID int) error {
+	venue, err := optionalVenue(ctx, venueID)
+	if err != nil {
+		return err
+	}
+	tx, err := storage.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, "UPDATE teams SET venue_id = ? WHERE id = ?", venue, teamID)
+	if err != nil {
+		return fmt.Errorf("Failed to update team venue: %v", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("Team %d does not exist", teamID)
+	}
+	_, err = tx.ExecContext(ctx, `
+		UPDATE matches
+		SET revision = revision + 1, updated_at = `+nowUTC+`
+		WHERE home_team_id = ? AND venue_id IS NULL AND status NOT IN ('played', 'awarded')
+	`, teamID)
+	if err != nil {
+		return fmt.Errorf("Failed to update the home matches of team %d: %v", teamID, err)
+	}
+	return tx.Commit()
+}
+
+// SetMatchVenue moves a match to another venue, or back to the home team's ground with a
+// zero venue. A match on neutral ground gives the home side no advantage.
+func SetMatchVenue(matchID, venueID int, neutral bool) error {
+	return SetMatchVenueContext(context.Background(), matchID, venueID, neutral)
+}
+
+// SetMatchVenueContext is SetMatchVenue with a context that cancels the queries.
+func SetMatchVenueContext(ctx context.Context, matchID, venueID int, neutral bool) error {
+	_, status, err := matchState(ctx, matchID)
+	if err != nil {
+		return err
+	}
+	if status == StatusPlayed || status == StatusAwarded {
+		return fmt.Errorf("Match %d has already been %s", matchID, status)
+	}
+	venue, err := optionalVenue(ctx, venueID)
+	if err != nil {
+		return err
+	}
+
+	_, err = storage.DB.ExecContext(ctx, `
+		UPDATE matches
+		SET venue_id = ?, neutral = ?, revision = revision + 1, updated_at = `+nowUTC+`
+		WHERE id = ?
+	`, venue, neutral, matchID)
+	if err != nil {
+		return fmt.Errorf("Failed to update match %d: %v", matchID, err)
+	}
+	return nil
+}
+
+// optionalVenue checks a venue ID and returns the value to store: NULL for zero.
+func optionalVenue(ctx context.Context, venueID int) (interface{}, error) {
+	if venueID == 0 {
+		return nil, nil
+	}
+	if _, err := GetVenueContext(ctx, venueID); err != nil {
+		return nil, err
+	}
+	return venueID, nil
+}
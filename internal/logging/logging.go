@@ -0,0 +1,98 @@
+// Package logging sets up the structured logger of the league. Library packages log through
+// log/slog with the context of the work they do; the logger set up here writes the records
+// to standard error as text or JSON and adds the ID of the HTTP request a record belongs to.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+// Output formats of the logger.
+const (
+	FormatText = "text" // key=value pairs, one record per line
+	FormatJSON = "json" // One JSON object per line
+)
+
+// Formats lists the output formats in the order they are documented.
+var Formats = []string{FormatText, FormatJSON}
+
+// Levels lists the level names accepted by ParseLevel, from the most verbose.
+var Levels = []string{"debug", "info", "warn", "error"}
+
+// ParseLevel reads a level name such as "info" or "debug", in any case.
+func ParseLevel(name string) (slog.Level, error) {
+	var level slog.Level
+	for _, l := range Levels {
+		if strings.EqualFold(name, l) {
+			err := level.UnmarshalText([]byte(l))
+			return level, err
+		}
+	}
+	return level, fmt.Errorf("%q is not one of %s", name, strings.Join(Levels, ", "))
+}
+
+// New returns a logger writing the records of at least the given level to w in the given
+// format. Records logged with a context carrying a request ID get a request_id attribute.
+func New(w io.Writer, format string, level slog.Level) (*slog.Logger, error) {
+	opts := &slog.HandlerOptions{Level: level}
+	var handler slog.Handler
+	switch format {
+	case FormatText:
+		handler = slog.NewTextHandler(w, opts)
+	case FormatJSON:
+		handler = slog.NewJSONHandler(w, opts)
+	default:
+		return nil, fmt.Errorf("%q is not one of %s", format, strings.Join(Formats, ", "))
+	}
+	return slog.New(contextHandler{handler}), nil
+}
+
+// Setup makes a logger writing to standard error the default logger of slog and of the
+// log package.
+func Setup(format string, level slog.Level) error {
+	logger, err := New(os.Stderr, format, level)
+	if err != nil {
+		return err
+	}
+	slog.SetDefault(logger)
+	return nil
+}
+
+// requestIDKey is the context key of the request ID.
+type requestIDKey struct{}
+
+// WithRequestID returns a context carrying the ID of the request it serves.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestID returns the request ID carried by a context, or "" when there is none.
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// contextHandler adds the request ID of a record's context to the record.
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if id := RequestID(ctx); id != "" {
+		r.AddAttrs(slog.String("request_id", id))
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
@@ -0,0 +1,289 @@
+// Package metrics keeps counters and histograms of the running league and writes them in
+// the Prometheus text exposition format. Each package declares its own metrics as package
+// variables; they are registered in Default when created and served by Handler.
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefaultBuckets are the upper bounds in seconds of histograms of request latencies.
+var DefaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// metric is a counter or histogram family that can write itself in the text format.
+type metric interface {
+	write(w io.Writer) error
+}
+
+// Registry holds the metrics written by WriteText.
+type Registry struct {
+	mu      sync.Mutex
+	names   map[string]bool
+	metrics []metric
+}
+
+// Default is the registry every metric is added to and Handler serves.
+var Default = &Registry{names: map[string]bool{}}
+
+// register adds a metric; a name used twice is a programming error.
+func (r *Registry) register(name string, m metric) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.names[name] {
+		panic("metrics: " + name + " registered twice")
+	}
+	r.names[name] = true
+	r.metrics = append(r.metrics, m)
+}
+
+// WriteText writes every metric in the Prometheus text exposition format, in the order
+// they were created.
+func (r *Registry) WriteText(w io.Writer) error {
+	r.mu.Lock()
+	metrics := append([]metric(nil), r.metrics...)
+	r.mu.Unlock()
+	for _, m := range metrics {
+		if err := m.write(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Handler serves the metrics of Default in the Prometheus text exposition format.
+func Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		Default.WriteText(w)
+	})
+}
+
+// family is what counters and histograms share: a name, a help text and label names, with
+// one series per combination of label values.
+type family struct {
+	name   string
+	help   string
+	kind   string // counter or histogram
+	labels []string
+
+	mu     sync.Mutex
+	series map[string]interface{} // Keyed by the label values joined with a zero byte
+	values map[string][]string
+}
+
+func newFamily(name, help, kind string, labels []string) *family {
+	return &family{name: name, help: help, kind: kind, labels: labels,
+		series: map[string]interface{}{}, values: map[string][]string{}}
+}
+
+// get returns the series of the label values, creating it with create on first use.
+func (f *family) get(values []string, create func() interface{}) interface{} {
+	if len(values) != len(f.labels) {
+		panic(fmt.Sprintf("metrics: %s takes %d label values, got %d", f.name, len(f.labels), len(values)))
+	}
+	key := strings.Join(values, "\x00")
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	s, ok := f.series[key]
+	if !ok {
+		s = create()
+		f.series[key] = s
+		f.values[key] = append([]string(nil), values...)
+	}
+	return s
+}
+
+// each calls fn for every series, ordered by label values, after writing the HELP and TYPE lines.
+func (f *family) each(w io.Writer, fn func(labels string, series interface{}) error) error {
+	if _, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", f.name, escapeHelp(f.help), f.name, f.kind); err != nil {
+		return err
+	}
+	f.mu.Lock()
+	keys := make([]string, 0, len(f.series))
+	for k := range f.series {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	series := make([]interface{}, len(keys))
+	labels := make([]string, len(keys))
+	for i, k := range keys {
+		series[i], labels[i] = f.series[k], f.labelPairs(f.values[k])
+	}
+	f.mu.Unlock()
+
+	for i := range keys {
+		if err := fn(labels[i], series[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// labelPairs renders label values as name="value" pairs separated by commas.
+func (f *family) labelPairs(values []string) string {
+	pairs := make([]string, len(values))
+	for i, v := range values {
+		pairs[i] = f.labels[i] + `="` + escapeLabel(v) + `"`
+	}
+	return strings.Join(pairs, ",")
+}
+
+// Counter is a value that only goes up.
+type Counter struct {
+	mu    sync.Mutex
+	value float64
+}
+
+// Inc adds one to the counter.
+func (c *Counter) Inc() {
+	c.Add(1)
+}
+
+// Add adds a non-negative amount to the counter.
+func (c *Counter) Add(n float64) {
+	if n < 0 {
+		panic("metrics: counters cannot go down")
+	}
+	c.mu.Lock()
+	c.value += n
+	c.mu.Unlock()
+}
+
+func (c *Counter) get() float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.value
+}
+
+// CounterVec is a family of counters told apart by label values.
+type CounterVec struct {
+	*family
+}
+
+// NewCounter creates and registers a counter without labels.
+func NewCounter(name, help string) *Counter {
+	return NewCounterVec(name, help).With()
+}
+
+// NewCounterVec creates and registers a family of counters with the given label names.
+func NewCounterVec(name, help string, labels ...string) *CounterVec {
+	v := &CounterVec{newFamily(name, help, "counter", labels)}
+	Default.register(name, v)
+	return v
+}
+
+// With returns the counter of the label values, in the order of the label names.
+func (v *CounterVec) With(values ...string) *Counter {
+	return v.get(values, func() interface{} { return &Counter{} }).(*Counter)
+}
+
+func (v *CounterVec) write(w io.Writer) error {
+	return v.each(w, func(labels string, s interface{}) error {
+		_, err := fmt.Fprintf(w, "%s%s %s\n", v.name, braces(labels), formatValue(s.(*Counter).get()))
+		return err
+	})
+}
+
+// Histogram counts observations, such as latencies in seconds, in cumulative buckets.
+type Histogram struct {
+	mu      sync.Mutex
+	buckets []float64 // Upper bounds, ascending
+	counts  []uint64  // Observations per bucket, not cumulative
+	sum     float64
+	count   uint64
+}
+
+// Observe adds one observation.
+func (h *Histogram) Observe(value float64) {
+	i := sort.SearchFloat64s(h.buckets, value)
+	h.mu.Lock()
+	if i < len(h.counts) {
+		h.counts[i]++
+	}
+	h.sum += value
+	h.count++
+	h.mu.Unlock()
+}
+
+// HistogramVec is a family of histograms told apart by label values.
+type HistogramVec struct {
+	*family
+	buckets []float64
+}
+
+// NewHistogramVec creates and registers a family of histograms with the given bucket upper
+// bounds and label names.
+func NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	buckets = append([]float64(nil), buckets...)
+	sort.Float64s(buckets)
+	v := &HistogramVec{family: newFamily(name, help, "histogram", labels), buckets: buckets}
+	Default.register(name, v)
+	return v
+}
+
+// With returns the histogram of the label values, in the order of the label names.
+func (v *HistogramVec) With(values ...string) *Histogram {
+	return v.get(values, func() interface{} {
+		return &Histogram{buckets: v.buckets, counts: make([]uint64, len(v.buckets))}
+	}).(*Histogram)
+}
+
+func (v *HistogramVec) write(w io.Writer) error {
+	return v.each(w, func(labels string, s interface{}) error {
+		h := s.(*Histogram)
+		h.mu.Lock()
+		counts, sum, count := append([]uint64(nil), h.counts...), h.sum, h.count
+		h.mu.Unlock()
+
+		sep := ""
+		if labels != "" {
+			sep = ","
+		}
+		var cumulative uint64
+		for i, bound := range v.buckets {
+			cumulative += counts[i]
+			if _, err := fmt.Fprintf(w, "%s_bucket{%s%sle=\"%s\"} %d\n", v.name, labels, sep, formatValue(bound), cumulative); err != nil {
+				return err
+			}
+		}
+		_, err := fmt.Fprintf(w, "%s_bucket{%s%sle=\"+Inf\"} %d\n%s_sum%s %s\n%s_count%s %d\n",
+			v.name, labels, sep, count, v.name, braces(labels), formatValue(sum), v.name, braces(labels), count)
+		return err
+	})
+}
+
+// braces wraps label pairs in braces, or returns nothing when there are none.
+func braces(labels string) string {
+	if labels == "" {
+		return ""
+	}
+	return "{" + labels + "}"
+}
+
+// formatValue writes a sample value the way Prometheus reads it.
+func formatValue(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// escapeLabel escapes a label value: backslash, double quote and newline.
+func escapeLabel(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s)
+}
+
+// escapeHelp escapes a help text: backslash and newline.
+func escapeHelp(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(s)
+}
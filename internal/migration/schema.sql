@@ -4,13 +4,51 @@
 -- This SQL script creates the necessary tables for a football league simulation system.
 -- It includes tables for teams, match results, and calculated championship predictions.
 
+-- ============================
+-- Seasons Table
+-- ============================
+CREATE TABLE IF NOT EXISTS seasons (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL UNIQUE,         -- Display name such as '2025/26'
+    start_date TEXT NOT NULL,          -- Friday of the first match week (YYYY-MM-DD, league time)
+    timezone TEXT NOT NULL DEFAULT 'Europe/London' -- IANA time zone kickoffs are shown in
+);
+
+-- ============================
+-- Divisions Table
+-- ============================
+-- Divisions form a pyramid: tier 1 is the top flight, tier 2 the division below it and so on.
+CREATE TABLE IF NOT EXISTS divisions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL UNIQUE,
+    tier INTEGER NOT NULL UNIQUE CHECK (tier >= 1),
+    promotion_places INTEGER NOT NULL DEFAULT 0 CHECK (promotion_places >= 0),   -- Automatically promoted teams
+    relegation_places INTEGER NOT NULL DEFAULT 0 CHECK (relegation_places >= 0), -- Automatically relegated teams
+    playoff_places INTEGER NOT NULL DEFAULT 0 CHECK (playoff_places >= 0)        -- Teams below the promotion places playing off for one more place
+);
+
+-- ============================
+-- Venues Table
+-- ============================
+CREATE TABLE IF NOT EXISTS venues (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL UNIQUE,
+    city TEXT NOT NULL,
+    capacity INTEGER NOT NULL CHECK (capacity >= 0)
+);
+
 -- ============================
 -- Teams Table
 -- ============================
 CREATE TABLE IF NOT EXISTS teams (
     id INTEGER PRIMARY KEY AUTOINCREMENT,
     name TEXT NOT NULL UNIQUE,        -- Team name must be unique
-    power INTEGER NOT NULL CHECK (power BETWEEN 1 AND 100) -- Power rating from 1 to 100
+    power INTEGER NOT NULL CHECK (power BETWEEN 1 AND 100), -- Power rating from 1 to 100
+    division_id INTEGER NOT NULL DEFAULT 1,
+    active INTEGER NOT NULL DEFAULT 1,    -- Withdrawn teams keep their history but get no new fixtures
+    venue_id INTEGER DEFAULT NULL,        -- Home ground
+    FOREIGN KEY (division_id) REFERENCES divisions(id),
+    FOREIGN KEY (venue_id) REFERENCES venues(id)
 );
 
 -- ============================
@@ -18,16 +56,59 @@ CREATE TABLE IF NOT EXISTS teams (
 -- ============================
 CREATE TABLE IF NOT EXISTS matches (
     id INTEGER PRIMARY KEY AUTOINCREMENT,
-    week INTEGER NOT NULL CHECK (week >= 1 AND week <= 6), -- Week must be within valid range
+    season_id INTEGER NOT NULL DEFAULT 1,
+    division_id INTEGER NOT NULL DEFAULT 1,
+    week INTEGER NOT NULL CHECK (week >= 1), -- Week must be within valid range
     home_team_id INTEGER NOT NULL,
     away_team_id INTEGER NOT NULL,
     home_goals INTEGER DEFAULT NULL CHECK (home_goals >= 0),
     away_goals INTEGER DEFAULT NULL CHECK (away_goals >= 0),
+    status TEXT NOT NULL DEFAULT 'scheduled'
+        CHECK (status IN ('scheduled', 'postponed', 'abandoned', 'played', 'awarded')),
+    original_week INTEGER DEFAULT NULL,  -- Week the match was first scheduled in, if it has been moved
+    kickoff TEXT DEFAULT NULL,           -- Scheduled kickoff in UTC (YYYY-MM-DDTHH:MM:SSZ)
+    revision INTEGER NOT NULL DEFAULT 0, -- Bumped when the result is posted or the match is rescheduled
+    updated_at TEXT DEFAULT NULL,        -- Time of the last change in UTC
+    venue_id INTEGER DEFAULT NULL,       -- Overrides the home team's ground
+    neutral INTEGER NOT NULL DEFAULT 0,  -- 1: neutral ground, no home advantage
+    kickoff_set INTEGER NOT NULL DEFAULT 0, -- 1: kickoff given by hand, kept when the season is rescheduled
+    FOREIGN KEY (season_id) REFERENCES seasons(id),
+    FOREIGN KEY (division_id) REFERENCES divisions(id),
+    FOREIGN KEY (venue_id) REFERENCES venues(id),
     FOREIGN KEY (home_team_id) REFERENCES teams(id),
     FOREIGN KEY (away_team_id) REFERENCES teams(id),
-    CONSTRAINT unique_match UNIQUE (week, home_team_id, away_team_id) -- Prevent duplicate fixtures
+    CONSTRAINT unique_match UNIQUE (season_id, week, home_team_id, away_team_id) -- Prevent duplicate fixtures
 );
 
+-- ============================
+-- Result Changes Table
+-- ============================
+-- Audit log of match results: one row for every change of a match's score or status,
+-- however it was made (simulation, manual result, import, postponement or award)
+CREATE TABLE IF NOT EXISTS result_changes (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    match_id INTEGER NOT NULL,
+    revision INTEGER NOT NULL,          -- Revision of the match after the change
+    old_status TEXT NOT NULL,
+    old_home_goals INTEGER DEFAULT NULL,
+    old_away_goals INTEGER DEFAULT NULL,
+    new_status TEXT NOT NULL,
+    new_home_goals INTEGER DEFAULT NULL,
+    new_away_goals INTEGER DEFAULT NULL,
+    changed_at TEXT NOT NULL,           -- Time of the change in UTC
+    FOREIGN KEY (match_id) REFERENCES matches(id)
+);
+
+CREATE TRIGGER IF NOT EXISTS log_result_change
+AFTER UPDATE OF home_goals, away_goals, status ON matches
+WHEN OLD.home_goals IS NOT NEW.home_goals OR OLD.away_goals IS NOT NEW.away_goals OR OLD.status IS NOT NEW.status
+BEGIN
+    INSERT INTO result_changes (match_id, revision, old_status, old_home_goals, old_away_goals,
+                                new_status, new_home_goals, new_away_goals, changed_at)
+    VALUES (NEW.id, NEW.revision, OLD.status, OLD.home_goals, OLD.away_goals,
+            NEW.status, NEW.home_goals, NEW.away_goals, strftime('%Y-%m-%dT%H:%M:%SZ', 'now'));
+END;
+
 -- ============================
 -- Championship Predictions Table
 -- ============================
@@ -39,11 +120,311 @@ CREATE TABLE IF NOT EXISTS championship_predictions (
     CONSTRAINT unique_team_prediction UNIQUE (team_id)
 );
 
+-- ============================
+-- Players Table
+-- ============================
+CREATE TABLE IF NOT EXISTS players (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    team_id INTEGER NOT NULL,
+    name TEXT NOT NULL,
+    squad_number INTEGER NOT NULL CHECK (squad_number BETWEEN 1 AND 99),
+    position TEXT NOT NULL CHECK (position IN ('GK', 'DF', 'MF', 'FW')),
+    FOREIGN KEY (team_id) REFERENCES teams(id),
+    CONSTRAINT unique_squad_number UNIQUE (team_id, squad_number)
+);
+
+-- ============================
+-- Match Lineups Table
+-- ============================
+-- Players used by the simulator for each match and the minutes they played.
+CREATE TABLE IF NOT EXISTS match_lineups (
+    match_id INTEGER NOT NULL,
+    player_id INTEGER NOT NULL,
+    team_id INTEGER NOT NULL,
+    minutes INTEGER NOT NULL DEFAULT 90 CHECK (minutes BETWEEN 0 AND 120),
+    FOREIGN KEY (match_id) REFERENCES matches(id),
+    FOREIGN KEY (player_id) REFERENCES players(id),
+    FOREIGN KEY (team_id) REFERENCES teams(id),
+    PRIMARY KEY (match_id, player_id)
+);
+
+-- ============================
+-- Match Cards Table
+-- ============================
+CREATE TABLE IF NOT EXISTS match_cards (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    match_id INTEGER NOT NULL,
+    player_id INTEGER NOT NULL,
+    team_id INTEGER NOT NULL,
+    card TEXT NOT NULL CHECK (card IN ('Y', 'R')), -- Yellow or red card
+    minute INTEGER NOT NULL CHECK (minute BETWEEN 1 AND 120),
+    FOREIGN KEY (match_id) REFERENCES matches(id),
+    FOREIGN KEY (player_id) REFERENCES players(id),
+    FOREIGN KEY (team_id) REFERENCES teams(id)
+);
+
+-- ============================
+-- Suspensions Table
+-- ============================
+-- A suspension keeps a player out of the league matches their team plays after it was
+-- handed out, until the team has played the banned number of matches without them.
+-- Byes and postponed matches do not count; a rescheduled match counts when it is played.
+CREATE TABLE IF NOT EXISTS suspensions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    season_id INTEGER NOT NULL,
+    player_id INTEGER NOT NULL,
+    match_id INTEGER NOT NULL,          -- Match in which the suspension was triggered
+    reason TEXT NOT NULL,
+    start_week INTEGER NOT NULL CHECK (start_week >= 1), -- Week after the one of the triggering match
+    matches INTEGER NOT NULL CHECK (matches >= 1),
+    served INTEGER NOT NULL DEFAULT 0 CHECK (served >= 0), -- Matches the player has sat out so far
+    FOREIGN KEY (season_id) REFERENCES seasons(id),
+    FOREIGN KEY (player_id) REFERENCES players(id),
+    FOREIGN KEY (match_id) REFERENCES matches(id)
+);
+
+-- The matches served are counted again for both teams whenever a match is completed or
+-- loses its result, whichever way that happens (simulation, manual result, import, award).
+CREATE TRIGGER IF NOT EXISTS serve_suspensions
+AFTER UPDATE OF status ON matches
+WHEN (OLD.status IN ('played', 'awarded')) IS NOT (NEW.status IN ('played', 'awarded'))
+BEGIN
+    UPDATE suspensions SET served = MIN(matches, (
+        SELECT COUNT(*) FROM matches m JOIN players p ON p.id = suspensions.player_id
+        WHERE m.season_id = suspensions.season_id AND m.status IN ('played', 'awarded')
+          AND p.team_id IN (m.home_team_id, m.away_team_id)
+          AND m.week >= suspensions.start_week
+    ))
+    WHERE season_id = NEW.season_id
+      AND player_id IN (SELECT id FROM players WHERE team_id IN (NEW.home_team_id, NEW.away_team_id));
+END;
+
+-- ============================
+-- Injuries Table
+-- ============================
+-- An injury keeps a player out for weeks_out weeks starting from start_week.
+CREATE TABLE IF NOT EXISTS injuries (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    season_id INTEGER NOT NULL,
+    player_id INTEGER NOT NULL,
+    match_id INTEGER NOT NULL,          -- Match in which the injury happened
+    description TEXT NOT NULL,
+    start_week INTEGER NOT NULL CHECK (start_week >= 1),
+    weeks_out INTEGER NOT NULL CHECK (weeks_out >= 1),
+    FOREIGN KEY (season_id) REFERENCES seasons(id),
+    FOREIGN KEY (player_id) REFERENCES players(id),
+    FOREIGN KEY (match_id) REFERENCES matches(id)
+);
+
+-- ============================
+-- Cups Table
+-- ============================
+-- Knockout cup competitions and their format.
+CREATE TABLE IF NOT EXISTS cups (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    season_id INTEGER NOT NULL,
+    name TEXT NOT NULL,
+    seeded INTEGER NOT NULL DEFAULT 0,       -- 1: seeded by power, 0: random draw
+    two_legged INTEGER NOT NULL DEFAULT 0,   -- 1: ties are played home and away
+    away_goals INTEGER NOT NULL DEFAULT 0,   -- 1: away goals break level aggregates
+    winner_team_id INTEGER DEFAULT NULL,
+    FOREIGN KEY (season_id) REFERENCES seasons(id),
+    FOREIGN KEY (winner_team_id) REFERENCES teams(id)
+);
+
+-- ============================
+-- Cup Ties Table
+-- ============================
+-- One pairing of a cup round. A tie without an away team is a bye.
+CREATE TABLE IF NOT EXISTS cup_ties (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    cup_id INTEGER NOT NULL,
+    round INTEGER NOT NULL CHECK (round >= 1),
+    slot INTEGER NOT NULL CHECK (slot >= 0),  -- Position in the bracket within the round
+    home_team_id INTEGER NOT NULL,
+    away_team_id INTEGER DEFAULT NULL,
+    winner_team_id INTEGER DEFAULT NULL,
+    decided_by TEXT DEFAULT NULL,             -- bye, normal time, aggregate, away goals, extra time, penalties
+    FOREIGN KEY (cup_id) REFERENCES cups(id),
+    FOREIGN KEY (home_team_id) REFERENCES teams(id),
+    FOREIGN KEY (away_team_id) REFERENCES teams(id),
+    FOREIGN KEY (winner_team_id) REFERENCES teams(id),
+    CONSTRAINT unique_cup_slot UNIQUE (cup_id, round, slot)
+);
+
+-- ============================
+-- Cup Legs Table
+-- ============================
+-- Matches played in a cup tie. Goals include extra time.
+CREATE TABLE IF NOT EXISTS cup_legs (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    tie_id INTEGER NOT NULL,
+    leg INTEGER NOT NULL CHECK (leg IN (1, 2)),
+    home_team_id INTEGER NOT NULL,
+    away_team_id INTEGER NOT NULL,
+    home_goals INTEGER NOT NULL CHECK (home_goals >= 0),
+    away_goals INTEGER NOT NULL CHECK (away_goals >= 0),
+    extra_time INTEGER NOT NULL DEFAULT 0,
+    home_penalties INTEGER DEFAULT NULL,      -- Set only when a shootout took place
+    away_penalties INTEGER DEFAULT NULL,
+    FOREIGN KEY (tie_id) REFERENCES cup_ties(id),
+    FOREIGN KEY (home_team_id) REFERENCES teams(id),
+    FOREIGN KEY (away_team_id) REFERENCES teams(id),
+    CONSTRAINT unique_cup_leg UNIQUE (tie_id, leg)
+);
+
+-- ============================
+-- Tournaments Table
+-- ============================
+-- Group stage plus knockout competitions. The knockout stage is stored as a cup.
+CREATE TABLE IF NOT EXISTS tournaments (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    season_id INTEGER NOT NULL,
+    name TEXT NOT NULL,
+    groups_count INTEGER NOT NULL CHECK (groups_count >= 1),
+    advance_per_group INTEGER NOT NULL CHECK (advance_per_group >= 1),
+    best_thirds INTEGER NOT NULL DEFAULT 0 CHECK (best_thirds >= 0),
+    home_and_away INTEGER NOT NULL DEFAULT 0,
+    cup_id INTEGER DEFAULT NULL,             -- Set once the group stage is complete
+    FOREIGN KEY (season_id) REFERENCES seasons(id),
+    FOREIGN KEY (cup_id) REFERENCES cups(id)
+);
+
+-- ============================
+-- Tournament Teams Table
+-- ============================
+-- Group and pot each team was drawn into.
+CREATE TABLE IF NOT EXISTS tournament_teams (
+    tournament_id INTEGER NOT NULL,
+    team_id INTEGER NOT NULL,
+    group_name TEXT NOT NULL,
+    pot INTEGER NOT NULL CHECK (pot >= 1),
+    FOREIGN KEY (tournament_id) REFERENCES tournaments(id),
+    FOREIGN KEY (team_id) REFERENCES teams(id),
+    PRIMARY KEY (tournament_id, team_id)
+);
+
+-- ============================
+-- Tournament Matches Table
+-- ============================
+CREATE TABLE IF NOT EXISTS tournament_matches (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    tournament_id INTEGER NOT NULL,
+    group_name TEXT NOT NULL,
+    matchday INTEGER NOT NULL CHECK (matchday >= 1),
+    home_team_id INTEGER NOT NULL,
+    away_team_id INTEGER NOT NULL,
+    home_goals INTEGER DEFAULT NULL CHECK (home_goals >= 0),
+    away_goals INTEGER DEFAULT NULL CHECK (away_goals >= 0),
+    FOREIGN KEY (tournament_id) REFERENCES tournaments(id),
+    FOREIGN KEY (home_team_id) REFERENCES teams(id),
+    FOREIGN KEY (away_team_id) REFERENCES teams(id)
+);
+
+-- ============================
+-- Playoffs Table
+-- ============================
+-- End-of-season playoffs deciding a division's last promotion place. The bracket is stored as a cup.
+CREATE TABLE IF NOT EXISTS playoffs (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    season_id INTEGER NOT NULL,
+    division_id INTEGER NOT NULL,
+    cup_id INTEGER NOT NULL,
+    promoted_team_id INTEGER DEFAULT NULL,   -- Set once the final has been played
+    FOREIGN KEY (season_id) REFERENCES seasons(id),
+    FOREIGN KEY (division_id) REFERENCES divisions(id),
+    FOREIGN KEY (cup_id) REFERENCES cups(id),
+    FOREIGN KEY (promoted_team_id) REFERENCES teams(id),
+    CONSTRAINT unique_playoff UNIQUE (season_id, division_id)
+);
+
+-- ============================
+-- Season Standings Table
+-- ============================
+-- Final standings frozen when a season is closed. Team and division names are copied
+-- so the archive stays readable when teams are renamed or withdrawn.
+CREATE TABLE IF NOT EXISTS season_standings (
+    season_id INTEGER NOT NULL,
+    division_id INTEGER NOT NULL,
+    division_name TEXT NOT NULL,
+    tier INTEGER NOT NULL,
+    position INTEGER NOT NULL CHECK (position >= 1),
+    team_id INTEGER NOT NULL,
+    team_name TEXT NOT NULL,
+    power INTEGER NOT NULL,                  -- Rating the team played the season with
+    played INTEGER NOT NULL,
+    wins INTEGER NOT NULL,
+    draws INTEGER NOT NULL,
+    losses INTEGER NOT NULL,
+    goals_for INTEGER NOT NULL,
+    goals_against INTEGER NOT NULL,
+    points INTEGER NOT NULL,
+    movement TEXT DEFAULT NULL,              -- promoted, promoted via playoffs or relegated
+    FOREIGN KEY (season_id) REFERENCES seasons(id),
+    FOREIGN KEY (division_id) REFERENCES divisions(id),
+    FOREIGN KEY (team_id) REFERENCES teams(id),
+    PRIMARY KEY (season_id, team_id)
+);
+
+-- ============================
+-- Fixture Constraints Table
+-- ============================
+-- Rules the fixture scheduler tries to satisfy when it generates a season's fixture:
+--   shared_stadium: team_id and other_team_id cannot both play at home in the same week
+--   derby:          team_id and other_team_id meet in the given week
+--   unavailable:    team_id cannot play in the match week containing the given date
+CREATE TABLE IF NOT EXISTS fixture_constraints (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    kind TEXT NOT NULL CHECK (kind IN ('shared_stadium', 'derby', 'unavailable')),
+    team_id INTEGER NOT NULL,
+    other_team_id INTEGER DEFAULT NULL,
+    week INTEGER DEFAULT NULL CHECK (week >= 1),
+    date TEXT DEFAULT NULL,                  -- YYYY-MM-DD
+    FOREIGN KEY (team_id) REFERENCES teams(id),
+    FOREIGN KEY (other_team_id) REFERENCES teams(id)
+);
+
+-- ============================
+-- Initial Data: Season
+-- ============================
+INSERT OR IGNORE INTO seasons (id, name, start_date) VALUES (1, '2025/26', '2025-08-15');
+
+-- ============================
+-- Initial Data: Divisions
+-- ============================
+INSERT OR IGNORE INTO divisions (id, name, tier) VALUES (1, 'Premier League', 1);
+
+-- ============================
+-- Initial Data: Venues
+-- ============================
+INSERT OR IGNORE INTO venues (id, name, city, capacity) VALUES
+(1, 'Stamford Bridge', 'London', 40343),
+(2, 'Emirates Stadium', 'London', 60704),
+(3, 'Etihad Stadium', 'Manchester', 53400),
+(4, 'Anfield', 'Liverpool', 61276);
+
 -- ============================
 -- Initial Data: Teams
 -- ============================
-INSERT INTO teams (name, power) VALUES 
-('Chelsea', 90),
-('Arsenal', 85),
-('Manchester City', 88),
-('Liverpool', 83)
+INSERT OR IGNORE INTO teams (name, power, venue_id) VALUES 
+('Chelsea', 90, 1),
+('Arsenal', 85, 2),
+('Manchester City', 88, 3),
+('Liverpool', 83, 4);
+
+-- ============================
+-- Initial Data: Squads
+-- ============================
+-- Sixteen numbered players per team: two goalkeepers, then defenders, midfielders and forwards.
+WITH RECURSIVE squad(n) AS (
+    SELECT 1 UNION ALL SELECT n + 1 FROM squad WHERE n < 16
+)
+INSERT OR IGNORE INTO players (team_id, name, squad_number, position)
+SELECT t.id, t.name || ' Player ' || n, n,
+       CASE
+           WHEN n IN (1, 13) THEN 'GK'
+           WHEN n BETWEEN 2 AND 5 OR n = 14 THEN 'DF'
+           WHEN n BETWEEN 6 AND 9 OR n = 15 THEN 'MF'
+           ELSE 'FW'
+       END
+FROM teams t, squad;
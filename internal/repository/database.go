@@ -1,39 +1,142 @@
 package storage
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"os"
-
-	_ "github.com/mattn/go-sqlite3" // SQLite driver import 
+	"strings"
 )
 
 var DB *sql.DB // Global database connection handle
 
+// Querier runs statements on DB or within one of its transactions, so the same code can
+// take part in a larger transaction.
+type Querier interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// DSN is the SQLite data source name: a file path, relative to the working directory, or a
+// file: URI with options.
+var DSN = "./league.db"
+
+// SchemaPath is the schema applied on every Connect.
+var SchemaPath = "internal/migration/schema.sql"
+
 // Connect initializes the SQLite database connection and executes schema setup.
-// It reads the schema SQL file and runs its statements to create required tables.
+// It migrates the tables of an existing database to SchemaVersion, then reads the schema
+// SQL file and runs its statements to create missing tables and the initial data.
 // If any step fails, the application logs the error and terminates.
 func Connect() {
 	var err error
 
 	// Open or create the SQLite database file
-	DB, err = sql.Open("sqlite3", "./league.db")
+	DB, err = sql.Open(driverName, DSN)
 	if err != nil {
-		log.Fatal("Failed to connect to the database:", err)
+		fatal("Failed to connect to the database", err)
+	}
+
+	// Upgrade the tables of a database created by an older version
+	if err := migrate(DB); err != nil {
+		fatal("Failed to migrate database", err)
 	}
 
 	// Load SQL schema from file
-	schema, err := os.ReadFile("internal/migration/schema.sql")
+	schema, err := os.ReadFile(SchemaPath)
 	if err != nil {
-		log.Fatal("Could not read schema.sql file:", err)
+		fatal("Could not read schema file", err)
 	}
 
 	// Execute the schema SQL to set up tables
 	_, err = DB.Exec(string(schema))
 	if err != nil {
-		log.Fatal("Failed to execute schema:", err)
+		fatal("Failed to execute schema", err)
+	}
+	if _, err := DB.Exec(fmt.Sprintf("PRAGMA user_version = %d", SchemaVersion)); err != nil {
+		fatal("Failed to record schema version", err)
+	}
+
+	slog.Debug("Database connection established and schema applied", "dsn", DSN)
+}
+
+// SeedDB opens a private in-memory database holding only the schema and its initial data,
+// to compare a store against. The caller closes it.
+func SeedDB() (*sql.DB, error) {
+	db, err := sql.Open(driverName, ":memory:")
+	if err != nil {
+		return nil, err
 	}
+	db.SetMaxOpenConns(1) // Every connection to :memory: is a database of its own
+
+	schema, err := os.ReadFile(SchemaPath)
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("Could not read schema file: %v", err)
+	}
+	if _, err := db.Exec(string(schema)); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("Failed to execute schema: %v", err)
+	}
+	return db, nil
+}
 
-	fmt.Println("Database connection established and schema applied successfully.")
+// fatal logs why the database cannot be used and terminates the application.
+func fatal(msg string, err error) {
+	slog.Error(msg, "err", err)
+	os.Exit(1)
+}
+
+// Reset closes the connection and deletes the database file, so the next Connect
+// starts again from the schema and its initial data.
+func Reset() error {
+	Close()
+	if err := os.Remove(File()); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("Failed to delete database: %v", err)
+	}
+	return nil
+}
+
+// Close closes the database connection after the queries in progress have finished.
+// It does nothing when the database is not open.
+func Close() error {
+	if DB == nil {
+		return nil
+	}
+	err := DB.Close()
+	DB = nil
+	return err
+}
+
+// File returns the database file named by the DSN, without the file: scheme and options.
+func File() string {
+	file := strings.TrimPrefix(DSN, "file:")
+	if i := strings.IndexByte(file, '?'); i >= 0 {
+		file = file[:i]
+	}
+	return file
+}
+
+// Ready reports whether the database is reachable and its schema is current: the version
+// stored in PRAGMA user_version is SchemaVersion. Connect stores it after migrating, so it
+// only differs when another build has changed the database since.
+func Ready(ctx context.Context) error {
+	if DB == nil {
+		return errors.New("Database is not connected")
+	}
+	if err := DB.PingContext(ctx); err != nil {
+		return fmt.Errorf("Database is unreachable: %v", err)
+	}
+
+	version, err := storedVersion(ctx, DB)
+	if err != nil {
+		return fmt.Errorf("Failed to read database schema version: %v", err)
+	}
+	if version != SchemaVersion {
+		return fmt.Errorf("Database schema version is %d, this build needs %d", version, SchemaVersion)
+	}
+	return nil
 }
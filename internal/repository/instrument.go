@@ -0,0 +1,82 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"time"
+
+	sqlite3 "github.com/mattn/go-sqlite3"
+
+	"go-football-league/internal/metrics"
+)
+
+// driverName is the SQLite driver that times every query and statement for the metrics.
+const driverName = "sqlite3-timed"
+
+// queryBuckets are the upper bounds in seconds of the query latency histogram; SQLite
+// queries mostly take well under a millisecond.
+var queryBuckets = []float64{.0001, .00025, .0005, .001, .0025, .005, .01, .025, .05, .1, .25, 1}
+
+var queryDuration = metrics.NewHistogramVec("league_db_query_duration_seconds",
+	"Time spent in database queries, from the start of a query until its rows are closed.",
+	queryBuckets, "operation")
+
+func init() {
+	sql.Register(driverName, timedDriver{&sqlite3.SQLiteDriver{}})
+}
+
+// timedDriver opens SQLite connections that time their queries.
+type timedDriver struct {
+	*sqlite3.SQLiteDriver
+}
+
+func (d timedDriver) Open(name string) (driver.Conn, error) {
+	conn, err := d.SQLiteDriver.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return &timedConn{conn.(*sqlite3.SQLiteConn)}, nil
+}
+
+// timedConn is a SQLite connection that observes the duration of queries and statements.
+// Everything else is the SQLite connection's own behaviour.
+type timedConn struct {
+	*sqlite3.SQLiteConn
+}
+
+func (c *timedConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	start := time.Now()
+	rows, err := c.SQLiteConn.QueryContext(ctx, query, args)
+	if err != nil {
+		observeQuery("query", start)
+		return nil, err
+	}
+	if r, ok := rows.(*sqlite3.SQLiteRows); ok {
+		return &timedRows{SQLiteRows: r, start: start}, nil
+	}
+	observeQuery("query", start)
+	return rows, nil
+}
+
+func (c *timedConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	defer observeQuery("exec", time.Now())
+	return c.SQLiteConn.ExecContext(ctx, query, args)
+}
+
+// timedRows observes a query when its rows are closed, since SQLite does the work of a
+// query while its rows are read.
+type timedRows struct {
+	*sqlite3.SQLiteRows
+	start time.Time
+}
+
+func (r *timedRows) Close() error {
+	observeQuery("query", r.start)
+	return r.SQLiteRows.Close()
+}
+
+// observeQuery records the time since start of a query or exec operation.
+func observeQuery(operation string, start time.Time) {
+	queryDuration.With(operation).Observe(time.Since(start).Seconds())
+}
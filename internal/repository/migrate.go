@@ -0,0 +1,263 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"strings"
+)
+
+// migration upgrades the tables of an existing database by one schema version. The schema
+// file only creates the tables that are missing, so every change to a table that already
+// exists, such as a new column, needs a migration as well.
+type migration struct {
+	version     int
+	description string
+	apply       func(tx *sql.Tx) error
+}
+
+// migrations lists the schema changes in version order. New ones are appended; a
+// migration that has been released is never changed.
+var migrations = []migration{
+	{1, "Upgrade the tables created before schema versioning", upgradeUnversioned},
+	{2, "Count the matches served of every suspension", countServedSuspensions},
+	{3, "Keep the kickoffs set by hand", keepKickoffsSet},
+}
+
+// SchemaVersion is the version of the schema this build uses, stored in PRAGMA user_version.
+var SchemaVersion = migrations[len(migrations)-1].version
+
+// migrate brings the tables of an existing database up to SchemaVersion, one migration per
+// transaction. A database without tables is left alone: the schema file creates it at the
+// current version.
+func migrate(db *sql.DB) error {
+	version, err := storedVersion(context.Background(), db)
+	if err != nil {
+		return err
+	}
+	if version > SchemaVersion {
+		return fmt.Errorf("Database schema version %d is newer than this build supports (%d)", version, SchemaVersion)
+	}
+	var tables int
+	if err := db.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'teams'").Scan(&tables); err != nil {
+		return err
+	}
+	if tables == 0 {
+		return nil
+	}
+
+	for _, m := range migrations {
+		if m.version <= version {
+			continue
+		}
+		tx, err := db.Begin()
+		if err != nil {
+			return err
+		}
+		if err := m.apply(tx); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("Migration %d (%s) failed: %v", m.version, m.description, err)
+		}
+		if _, err := tx.Exec(fmt.Sprintf("PRAGMA user_version = %d", m.version)); err != nil {
+			tx.Rollback()
+			return err
+		}
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+		slog.Info("Database migrated", "version", m.version, "migration", m.description)
+	}
+	return nil
+}
+
+// storedVersion returns the schema version recorded in a database, 0 for one that has none.
+func storedVersion(ctx context.Context, db *sql.DB) (int, error) {
+	var version int
+	err := db.QueryRowContext(ctx, "PRAGMA user_version").Scan(&version)
+	return version, err
+}
+
+// upgradeUnversioned upgrades a database created before schema versions were recorded,
+// from the first release onwards. Its teams and matches tables lack the columns and
+// constraints added since; every other table is created by the schema file.
+func upgradeUnversioned(tx *sql.Tx) error {
+	// Teams have a division, can be withdrawn and have a home ground
+	for _, c := range []struct{ name, definition string }{
+		{"division_id", "INTEGER NOT NULL DEFAULT 1 REFERENCES divisions(id)"},
+		{"active", "INTEGER NOT NULL DEFAULT 1"},
+		{"venue_id", "INTEGER DEFAULT NULL REFERENCES venues(id)"},
+	} {
+		if err := addColumn(tx, "teams", c.name, c.definition); err != nil {
+			return err
+		}
+	}
+
+	// The initial teams are inserted with INSERT OR IGNORE on every start, which relies on
+	// unique names. The earliest databases got the teams again on every start, so the
+	// copies are merged into the first team of each name.
+	for _, stmt := range []string{
+		`UPDATE matches SET home_team_id = (SELECT MIN(t.id) FROM teams t JOIN teams h ON h.name = t.name WHERE h.id = matches.home_team_id)`,
+		`UPDATE matches SET away_team_id = (SELECT MIN(t.id) FROM teams t JOIN teams a ON a.name = t.name WHERE a.id = matches.away_team_id)`,
+		`DELETE FROM championship_predictions WHERE team_id NOT IN (SELECT MIN(id) FROM teams GROUP BY name)`,
+		`DELETE FROM teams WHERE id NOT IN (SELECT MIN(id) FROM teams GROUP BY name)`,
+		`CREATE UNIQUE INDEX IF NOT EXISTS unique_team_name ON teams (name)`,
+	} {
+		if _, err := tx.Exec(stmt); err != nil {
+			return fmt.Errorf("Failed to make team names unique: %v", err)
+		}
+	}
+
+	// Matches lost the six-week limit and are unique per season, which only a new table can
+	// express. Matches with a score were played.
+	if err := rebuildTable(tx, "matches", matchesV1); err != nil {
+		return err
+	}
+	_, err := tx.Exec(`
+		UPDATE matches SET status = 'played'
+		WHERE status = 'scheduled' AND home_goals IS NOT NULL AND away_goals IS NOT NULL
+	`)
+	return err
+}
+
+// countServedSuspensions adds suspensions.served. Bans used to run for weeks, so the
+// matches the player's team played from the first week of the ban count as served.
+func countServedSuspensions(tx *sql.Tx) error {
+	if exists, err := tableExists(tx, "suspensions"); err != nil || !exists {
+		return err
+	}
+	if err := addColumn(tx, "suspensions", "served", "INTEGER NOT NULL DEFAULT 0 CHECK (served >= 0)"); err != nil {
+		return err
+	}
+	_, err := tx.Exec(`
+		UPDATE suspensions SET served = MIN(matches, (
+			SELECT COUNT(*) FROM matches m JOIN players p ON p.id = suspensions.player_id
+			WHERE m.season_id = suspensions.season_id AND m.status IN ('played', 'awarded')
+			  AND p.team_id IN (m.home_team_id, m.away_team_id)
+			  AND m.week >= suspensions.start_week
+		))
+	`)
+	return err
+}
+
+// keepKickoffsSet adds matches.kickoff_set. Kickoffs given by hand were not told apart
+// before, so the matches that have been moved to another week keep theirs.
+func keepKickoffsSet(tx *sql.Tx) error {
+	if err := addColumn(tx, "matches", "kickoff_set", "INTEGER NOT NULL DEFAULT 0"); err != nil {
+		return err
+	}
+	_, err := tx.Exec("UPDATE matches SET kickoff_set = 1 WHERE original_week IS NOT NULL AND kickoff IS NOT NULL")
+	return err
+}
+
+// matchesV1 is the matches table of schema version 1.
+const matchesV1 = `
+	CREATE TABLE matches (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		season_id INTEGER NOT NULL DEFAULT 1,
+		division_id INTEGER NOT NULL DEFAULT 1,
+		week INTEGER NOT NULL CHECK (week >= 1),
+		home_team_id INTEGER NOT NULL,
+		away_team_id INTEGER NOT NULL,
+		home_goals INTEGER DEFAULT NULL CHECK (home_goals >= 0),
+		away_goals INTEGER DEFAULT NULL CHECK (away_goals >= 0),
+		status TEXT NOT NULL DEFAULT 'scheduled'
+			CHECK (status IN ('scheduled', 'postponed', 'abandoned', 'played', 'awarded')),
+		original_week INTEGER DEFAULT NULL,
+		kickoff TEXT DEFAULT NULL,
+		revision INTEGER NOT NULL DEFAULT 0,
+		updated_at TEXT DEFAULT NULL,
+		venue_id INTEGER DEFAULT NULL,
+		neutral INTEGER NOT NULL DEFAULT 0,
+		FOREIGN KEY (season_id) REFERENCES seasons(id),
+		FOREIGN KEY (division_id) REFERENCES divisions(id),
+		FOREIGN KEY (venue_id) REFERENCES venues(id),
+		FOREIGN KEY (home_team_id) REFERENCES teams(id),
+		FOREIGN KEY (away_team_id) REFERENCES teams(id),
+		CONSTRAINT unique_match UNIQUE (season_id, week, home_team_id, away_team_id)
+	)`
+
+// tableExists reports whether a database has a table.
+func tableExists(tx *sql.Tx, table string) (bool, error) {
+	var n int
+	err := tx.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?", table).Scan(&n)
+	return n > 0, err
+}
+
+// addColumn adds a column to a table unless it already has it.
+func addColumn(tx *sql.Tx, table, column, definition string) error {
+	columns, err := columnsOf(tx, table)
+	if err != nil {
+		return err
+	}
+	for _, c := range columns {
+		if c == column {
+			return nil
+		}
+	}
+	if _, err := tx.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, column, definition)); err != nil {
+		return fmt.Errorf("Failed to add %s.%s: %v", table, column, err)
+	}
+	return nil
+}
+
+// rebuildTable replaces a table with one created by the given CREATE TABLE statement,
+// copying the columns both have. SQLite cannot change the constraints of a table in place.
+func rebuildTable(tx *sql.Tx, table, create string) error {
+	old, err := columnsOf(tx, table)
+	if err != nil {
+		return err
+	}
+	temp := table + "_new"
+	if _, err := tx.Exec(strings.Replace(create, "CREATE TABLE "+table, "CREATE TABLE "+temp, 1)); err != nil {
+		return fmt.Errorf("Failed to create new %s table: %v", table, err)
+	}
+	current, err := columnsOf(tx, temp)
+	if err != nil {
+		return err
+	}
+	kept := map[string]bool{}
+	for _, c := range current {
+		kept[c] = true
+	}
+	var shared []string
+	for _, c := range old {
+		if kept[c] {
+			shared = append(shared, c)
+		}
+	}
+
+	list := strings.Join(shared, ", ")
+	for _, stmt := range []string{
+		fmt.Sprintf("INSERT INTO %s (%s) SELECT %s FROM %s", temp, list, list, table),
+		"DROP TABLE " + table,
+		fmt.Sprintf("ALTER TABLE %s RENAME TO %s", temp, table),
+	} {
+		if _, err := tx.Exec(stmt); err != nil {
+			return fmt.Errorf("Failed to rebuild %s table: %v", table, err)
+		}
+	}
+	return nil
+}
+
+// columnsOf returns the column names of a table in the order they were defined.
+func columnsOf(tx *sql.Tx, table string) ([]string, error) {
+	rows, err := tx.Query("SELECT name FROM pragma_table_info(?)", table)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var columns []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		columns = append(columns, name)
+	}
+	if len(columns) == 0 && rows.Err() == nil {
+		return nil, fmt.Errorf("Table %s does not exist", table)
+	}
+	return columns, rows.Err()
+}
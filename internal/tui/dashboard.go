@@ -0,0 +1,247 @@
+// Package tui implements a full-screen match-day dashboard for the terminal: the fixtures
+// of a week, the live league table and the title probabilities, with keys to play the
+// next week, edit scores and undo the edits. It only uses ANSI escape codes and stty.
+package tui
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+
+	models "go-football-league/internal/domain"
+	"go-football-league/internal/league"
+)
+
+// Key presses understood by the dashboard besides printable characters.
+const (
+	keyEnter     = "\r"
+	keyEscape    = "\x1b"
+	keyBackspace = "\x7f"
+	keyCtrlC     = "\x03"
+	keyUp        = "\x1b[A"
+	keyDown      = "\x1b[B"
+	keyRight     = "\x1b[C"
+	keyLeft      = "\x1b[D"
+)
+
+// edit is a score change made on the dashboard, kept so it can be undone.
+type edit struct {
+	match     models.Match // The match as it was before the change
+	homeGoals int
+	awayGoals int
+}
+
+// dashboard is the state of the screen.
+type dashboard struct {
+	week     int // Week on screen
+	weeks    int // Weeks in the season's fixture
+	selected int // Index of the highlighted match
+	matches  []models.Match
+	table    []models.LeagueTableRow
+
+	editing bool   // A score is being typed for the highlighted match
+	input   string // The score typed so far
+	undo    []edit
+	message string // Result of the last action, shown above the key help
+	quit    bool
+}
+
+// Run shows the dashboard on the terminal until q is pressed. The fixture of the current
+// season must exist.
+func Run() error {
+	weeks, err := league.SeasonWeeks()
+	if err != nil {
+		return fmt.Errorf("Failed to read season fixture: %v", err)
+	}
+	if weeks == 0 {
+		return fmt.Errorf("No fixture yet, create it before opening the dashboard")
+	}
+	last, err := league.LastPlayedWeek()
+	if err != nil {
+		return err
+	}
+
+	restore, err := makeRaw(os.Stdin)
+	if err != nil {
+		return err
+	}
+	defer restore()
+
+	// The league package logs its progress to stderr, which shares the terminal; keep the
+	// log off the dashboard while it is on screen
+	screen := os.Stdout
+	logger := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	defer slog.SetDefault(logger)
+
+	fmt.Fprint(screen, enterScreen)
+	defer fmt.Fprint(screen, leaveScreen)
+
+	d := &dashboard{week: max(last, 1), weeks: weeks}
+	if err := d.refresh(); err != nil {
+		return err
+	}
+	buf := make([]byte, 16)
+	for !d.quit {
+		if err := d.draw(screen); err != nil {
+			return err
+		}
+		n, err := os.Stdin.Read(buf)
+		if err != nil {
+			return err
+		}
+		d.handleKey(string(buf[:n]))
+	}
+	return nil
+}
+
+// refresh reloads the matches of the week on screen and the table after it.
+func (d *dashboard) refresh() error {
+	matches, err := league.GetMatchesByWeek(d.week)
+	if err != nil {
+		return fmt.Errorf("Failed to load matches: %v", err)
+	}
+	table, err := league.GenerateLeagueTable(d.week)
+	if err != nil {
+		return fmt.Errorf("Failed to generate league table: %v", err)
+	}
+	d.matches, d.table = matches, table
+	d.selected = min(d.selected, max(len(matches)-1, 0))
+	return nil
+}
+
+// handleKey applies a key press, reporting failures in the message line.
+func (d *dashboard) handleKey(key string) {
+	var err error
+	if d.editing {
+		err = d.handleEditKey(key)
+	} else {
+		err = d.handleCommandKey(key)
+	}
+	if err == nil {
+		err = d.refresh()
+	}
+	if err != nil {
+		d.message = err.Error()
+	}
+}
+
+// handleCommandKey applies a key press outside of score editing.
+func (d *dashboard) handleCommandKey(key string) error {
+	d.message = ""
+	switch key {
+	case "q", keyCtrlC:
+		d.quit = true
+	case keyUp, "k":
+		d.selected = max(d.selected-1, 0)
+	case keyDown, "j":
+		d.selected = min(d.selected+1, max(len(d.matches)-1, 0))
+	case keyLeft, "h":
+		d.showWeek(d.week - 1)
+	case keyRight, "l":
+		d.showWeek(d.week + 1)
+	case "n":
+		return d.playNextWeek()
+	case "e", keyEnter:
+		return d.startEdit()
+	case "u":
+		return d.undoEdit()
+	}
+	return nil
+}
+
+// handleEditKey applies a key press while a score is typed.
+func (d *dashboard) handleEditKey(key string) error {
+	switch {
+	case key == keyEscape || key == keyCtrlC:
+		d.editing = false
+		d.message = "Edit cancelled"
+	case key == keyEnter:
+		return d.saveEdit()
+	case key == keyBackspace || key == "\b":
+		if d.input != "" {
+			d.input = d.input[:len(d.input)-1]
+		}
+	case len(key) == 1 && (key[0] >= '0' && key[0] <= '9' || key[0] == '-'):
+		d.input += key
+	}
+	return nil
+}
+
+// showWeek moves the screen to another week of the season.
+func (d *dashboard) showWeek(week int) {
+	if week >= 1 && week <= d.weeks {
+		d.week, d.selected = week, 0
+	}
+}
+
+// playNextWeek simulates the first week without results and shows it.
+func (d *dashboard) playNextWeek() error {
+	last, err := league.LastPlayedWeek()
+	if err != nil {
+		return err
+	}
+	if last >= d.weeks {
+		d.message = "The season is complete"
+		return nil
+	}
+	if err := league.PlayWeek(last + 1); err != nil {
+		return err
+	}
+	d.showWeek(last + 1)
+	d.message = fmt.Sprintf("Week %d played", last+1)
+	return nil
+}
+
+// startEdit starts typing a score for the highlighted match.
+func (d *dashboard) startEdit() error {
+	if len(d.matches) == 0 {
+		return nil
+	}
+	m := d.matches[d.selected]
+	if m.Status != league.StatusScheduled && m.Status != league.StatusPlayed {
+		return fmt.Errorf("Match %d is %s and cannot be edited", m.ID, m.Status)
+	}
+	d.editing, d.input = true, ""
+	return nil
+}
+
+// saveEdit stores the typed score of the highlighted match and remembers the old one.
+func (d *dashboard) saveEdit() error {
+	m := d.matches[d.selected]
+	homeGoals, awayGoals, err := league.ParseScore(d.input)
+	if err != nil {
+		return err
+	}
+	if err := league.UpdateMatchResult(m.ID, homeGoals, awayGoals); err != nil {
+		return err
+	}
+	d.editing = false
+	d.undo = append(d.undo, edit{match: m, homeGoals: homeGoals, awayGoals: awayGoals})
+	d.message = fmt.Sprintf("%s %d-%d %s saved", m.HomeTeamName, homeGoals, awayGoals, m.AwayTeamName)
+	return nil
+}
+
+// undoEdit puts back the result a match had before the last edit.
+func (d *dashboard) undoEdit() error {
+	if len(d.undo) == 0 {
+		d.message = "Nothing to undo"
+		return nil
+	}
+	e := d.undo[len(d.undo)-1]
+	m := e.match
+	var err error
+	if m.Status == league.StatusPlayed {
+		err = league.UpdateMatchResult(m.ID, m.HomeGoals, m.AwayGoals)
+	} else {
+		err = league.ClearMatchResult(m.ID)
+	}
+	if err != nil {
+		return err
+	}
+	d.undo = d.undo[:len(d.undo)-1]
+	d.showWeek(m.Week)
+	d.message = fmt.Sprintf("Undid %s %d-%d %s", m.HomeTeamName, e.homeGoals, e.awayGoals, m.AwayTeamName)
+	return nil
+}
@@ -0,0 +1,124 @@
+package tui
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+
+	"go-football-league/internal/league"
+)
+
+// ANSI escape codes used to draw the dashboard.
+const (
+	enterScreen = "\x1b[?1049h\x1b[?25l" // Switch to the alternate screen and hide the cursor
+	leaveScreen = "\x1b[?25h\x1b[?1049l" // Show the cursor and go back to the normal screen
+	clearScreen = "\x1b[H\x1b[2J"
+	bold        = "\x1b[1m"
+	reverse     = "\x1b[7m"
+	dim         = "\x1b[2m"
+	reset       = "\x1b[0m"
+)
+
+// paneGap separates the league table from the title probabilities.
+const paneGap = 4
+
+// keyHelp lists the key bindings at the bottom of the screen.
+const keyHelp = "←/→ week  ↑/↓ match  n play next week  e edit score  u undo  q quit"
+
+// draw redraws the whole screen. Lines end in CR LF because the terminal is in raw mode.
+func (d *dashboard) draw(w io.Writer) error {
+	var lines []string
+	lines = append(lines, fmt.Sprintf("%sGo Football League — Week %d of %d%s", bold, d.week, d.weeks, reset), "")
+
+	fixtures, err := d.fixturesPane()
+	if err != nil {
+		return err
+	}
+	lines = append(lines, fixtures...)
+	lines = append(lines, "")
+
+	table, err := renderText(league.LeagueTableReport(fmt.Sprintf("League Table (After Week %d)", d.week), d.table))
+	if err != nil {
+		return err
+	}
+	var predictions []string
+	if from := league.PredictionFromWeek(); d.week >= from {
+		report, err := league.PredictionsReport(d.week, d.table)
+		if err != nil {
+			return err
+		}
+		if predictions, err = renderText(report); err != nil {
+			return err
+		}
+	} else {
+		predictions = []string{"Title Probabilities:", dim + fmt.Sprintf("Available from week %d", from) + reset}
+	}
+	lines = append(lines, sideBySide(table, predictions)...)
+	lines = append(lines, "")
+
+	switch {
+	case d.editing:
+		m := d.matches[d.selected]
+		lines = append(lines, fmt.Sprintf("Score for %s v %s: %s%s_%s   (Enter save, Esc cancel)",
+			m.HomeTeamName, m.AwayTeamName, bold, d.input, reset))
+	case d.message != "":
+		lines = append(lines, d.message)
+	default:
+		lines = append(lines, "")
+	}
+	lines = append(lines, dim+keyHelp+reset)
+
+	_, err = io.WriteString(w, clearScreen+strings.Join(lines, "\r\n"))
+	return err
+}
+
+// fixturesPane renders the matches of the week with the selected one highlighted.
+func (d *dashboard) fixturesPane() ([]string, error) {
+	lines, err := renderText(league.MatchesReport("", d.matches))
+	if err != nil {
+		return nil, err
+	}
+	pane := []string{fmt.Sprintf("Fixtures (Week %d):", d.week)}
+	// The rendered table is a rule, the header and a rule, then one line per match
+	for i, line := range lines {
+		if i-3 == d.selected && i-3 < len(d.matches) {
+			line = reverse + line + reset
+		}
+		pane = append(pane, line)
+	}
+	return pane, nil
+}
+
+// renderText renders a report as a text table and splits it into lines.
+func renderText(r league.Report) ([]string, error) {
+	renderer, err := league.NewRenderer(league.FormatTable)
+	if err != nil {
+		return nil, err
+	}
+	var b strings.Builder
+	if err := renderer.Render(&b, r); err != nil {
+		return nil, err
+	}
+	return strings.Split(strings.TrimRight(b.String(), "\n"), "\n"), nil
+}
+
+// sideBySide places two panes next to each other, padding the left one to its widest line.
+func sideBySide(left, right []string) []string {
+	width := 0
+	for _, line := range left {
+		width = max(width, utf8.RuneCountInString(line))
+	}
+	var lines []string
+	for i := 0; i < max(len(left), len(right)); i++ {
+		var l, r string
+		if i < len(left) {
+			l = left[i]
+		}
+		if i < len(right) {
+			r = right[i]
+		}
+		lines = append(lines, l+strings.Repeat(" ", width-utf8.RuneCountInString(l)+paneGap)+r)
+	}
+	return lines
+}
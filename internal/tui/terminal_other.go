@@ -0,0 +1,13 @@
+//go:build !unix
+
+package tui
+
+import (
+	"errors"
+	"os"
+)
+
+// makeRaw is only supported on Unix terminals, where raw mode is set with stty.
+func makeRaw(f *os.File) (func(), error) {
+	return nil, errors.New("The dashboard needs a Unix terminal")
+}
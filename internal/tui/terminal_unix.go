@@ -0,0 +1,31 @@
+//go:build unix
+
+package tui
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// makeRaw switches the terminal on f to raw mode, so every key press is read at once and
+// not echoed, and returns a function that restores the previous settings.
+func makeRaw(f *os.File) (func(), error) {
+	state, err := stty(f, "-g")
+	if err != nil {
+		return nil, fmt.Errorf("The dashboard needs an interactive terminal: %v", err)
+	}
+	if _, err := stty(f, "raw", "-echo"); err != nil {
+		return nil, fmt.Errorf("Failed to switch the terminal to raw mode: %v", err)
+	}
+	return func() { stty(f, strings.TrimSpace(state)) }, nil
+}
+
+// stty runs stty on the terminal f and returns its output.
+func stty(f *os.File, args ...string) (string, error) {
+	cmd := exec.Command("stty", args...)
+	cmd.Stdin = f
+	out, err := cmd.Output()
+	return string(out), err
+}